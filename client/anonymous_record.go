@@ -0,0 +1,184 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"fmt"
+	"sync"
+)
+
+//AnonymousRecord is a record handle that can be pointed to a different record at any time
+type AnonymousRecord struct {
+	client        *Client
+	mu            sync.Mutex
+	record        *Record
+	subscriptions []*anonymousSubscription
+	lastSubID     int
+}
+
+type anonymousSubscription struct {
+	id        int
+	recordSub int
+	path      string
+	callback  func(data interface{})
+}
+
+//GetAnonymousRecord returns an anonymous record without a name, use SetName to point it to a record
+func (c *Client) GetAnonymousRecord() *AnonymousRecord {
+	return &AnonymousRecord{client: c}
+}
+
+//Name returns the name of the record currently pointed to, or an empty string
+func (a *AnonymousRecord) Name() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.record == nil {
+		return ""
+	}
+	return a.record.Name
+}
+
+//SetName points the anonymous record to the record with the given name, moving all
+//subscriptions over and firing them with the new record data
+func (a *AnonymousRecord) SetName(name string) error {
+	rec, err := a.client.GetRecord(name)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	previous := a.record
+	if previous == rec {
+		a.mu.Unlock()
+		return rec.Discard()
+	}
+	for _, sub := range a.subscriptions {
+		if previous != nil {
+			previous.Unsubscribe(sub.recordSub)
+		}
+		sub.recordSub = rec.Subscribe(sub.path, sub.callback)
+	}
+	a.record = rec
+	subscriptions := append([]*anonymousSubscription{}, a.subscriptions...)
+	a.mu.Unlock()
+
+	// the subscriptions already moved, fire them even if the discard fails
+	for _, sub := range subscriptions {
+		sub.callback(rec.GetPath(sub.path))
+	}
+
+	if previous == nil {
+		return nil
+	}
+	return previous.Discard()
+}
+
+func (a *AnonymousRecord) current() (*Record, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.record == nil {
+		return nil, fmt.Errorf("Anonymous record has no name set")
+	}
+	return a.record, nil
+}
+
+//Get returns a copy of the whole record data, or nil if no name is set
+func (a *AnonymousRecord) Get() interface{} {
+	return a.GetPath("")
+}
+
+//GetPath returns a copy of the value stored at path, or nil if no name is set
+func (a *AnonymousRecord) GetPath(path string) interface{} {
+	rec, err := a.current()
+	if err != nil {
+		return nil
+	}
+	return rec.GetPath(path)
+}
+
+//Set replaces the whole data of the current record
+func (a *AnonymousRecord) Set(data interface{}) error {
+	rec, err := a.current()
+	if err != nil {
+		return err
+	}
+	return rec.Set(data)
+}
+
+//SetPath replaces the value stored at path in the current record
+func (a *AnonymousRecord) SetPath(path string, value interface{}) error {
+	rec, err := a.current()
+	if err != nil {
+		return err
+	}
+	return rec.SetPath(path, value)
+}
+
+//Subscribe registers a callback fired whenever the value at path changes, including
+//when the anonymous record is pointed to another record. It returns an id for Unsubscribe.
+func (a *AnonymousRecord) Subscribe(path string, callback func(data interface{})) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lastSubID++
+	sub := &anonymousSubscription{
+		id:       a.lastSubID,
+		path:     path,
+		callback: callback,
+	}
+	if a.record != nil {
+		sub.recordSub = a.record.Subscribe(path, callback)
+	}
+	a.subscriptions = append(a.subscriptions, sub)
+	return sub.id
+}
+
+//Unsubscribe removes the subscription with the given id
+func (a *AnonymousRecord) Unsubscribe(id int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for i, sub := range a.subscriptions {
+		if sub.id == id {
+			if a.record != nil {
+				a.record.Unsubscribe(sub.recordSub)
+			}
+			a.subscriptions = append(a.subscriptions[:i], a.subscriptions[i+1:]...)
+			return
+		}
+	}
+}
+
+//Discard stops receiving updates for the current record and removes its name
+func (a *AnonymousRecord) Discard() error {
+	a.mu.Lock()
+	rec := a.record
+	for _, sub := range a.subscriptions {
+		if rec != nil {
+			rec.Unsubscribe(sub.recordSub)
+		}
+	}
+	a.record = nil
+	a.mu.Unlock()
+
+	if rec == nil {
+		return nil
+	}
+	return rec.Discard()
+}
+
+//Delete removes the current record from the server
+func (a *AnonymousRecord) Delete() error {
+	rec, err := a.current()
+	if err != nil {
+		return err
+	}
+	return rec.Delete()
+}
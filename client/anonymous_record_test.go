@@ -0,0 +1,133 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AnonymousRecord", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var cli *client.Client
+		var anon *client.AnonymousRecord
+
+		// setName points anon to name, answering the read with data
+		setName := func(name, data string) error {
+			result := make(chan error, 1)
+			go func() {
+				result <- anon.SetName(name)
+			}()
+			_, err := protocol.WaitForSent(`^R\|CR\|`+name+`$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(protocol.DeliverRaw(raw("R", "R", name, "1", data))).To(Succeed())
+
+			var setErr error
+			Eventually(result).Should(Receive(&setErr))
+			return setErr
+		}
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			cli = loggedInClient(protocol)
+			anon = cli.GetAnonymousRecord()
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		It("Should have no name nor data until SetName", func() {
+			Expect(anon.Name()).To(BeEmpty())
+			Expect(anon.Get()).To(BeNil())
+			Expect(anon.Set(map[string]interface{}{"name": "Lisa"})).NotTo(Succeed())
+			Expect(protocol).To(HaveSentMessages(0))
+		})
+
+		It("Should point to the record with the name", func() {
+			Expect(setName("user/Lisa", `{"name":"Lisa"}`)).To(Succeed())
+			Expect(anon.Name()).To(Equal("user/Lisa"))
+			Expect(anon.GetPath("name")).To(Equal("Lisa"))
+
+			Expect(anon.SetPath("name", "Lisa Simpson")).To(Succeed())
+			Expect(protocol).To(HaveLastSentMessage(`R|P|user/Lisa|2|name|SLisa Simpson`))
+		})
+
+		It("Should re-point to another record and discard the previous one", func() {
+			Expect(setName("user/Lisa", `{"name":"Lisa"}`)).To(Succeed())
+			Expect(setName("user/Bart", `{"name":"Bart"}`)).To(Succeed())
+
+			Expect(anon.Name()).To(Equal("user/Bart"))
+			Expect(anon.GetPath("name")).To(Equal("Bart"))
+			Expect(protocol).To(HaveSentMessage("R|US|user/Lisa"))
+		})
+
+		It("Should move the subscriptions to the new record", func() {
+			names := make(chan interface{}, 4)
+			anon.Subscribe("name", func(data interface{}) { names <- data })
+
+			Expect(setName("user/Lisa", `{"name":"Lisa"}`)).To(Succeed())
+			Eventually(names).Should(Receive(Equal("Lisa")))
+			Expect(setName("user/Bart", `{"name":"Bart"}`)).To(Succeed())
+			Eventually(names).Should(Receive(Equal("Bart")))
+
+			// only the current record fires the subscription
+			Expect(protocol.DeliverRaw(raw("R", "P", "user/Lisa", "2", "name", "SLisa Simpson"))).To(Succeed())
+			Expect(protocol.DeliverRaw(raw("R", "P", "user/Bart", "2", "name", "SBart Simpson"))).To(Succeed())
+			Eventually(names).Should(Receive(Equal("Bart Simpson")))
+			Consistently(names, 50*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("Should fire the moved subscriptions when discarding the previous record fails", func() {
+			names := make(chan interface{}, 2)
+			anon.Subscribe("name", func(data interface{}) { names <- data })
+			Expect(setName("user/Lisa", `{"name":"Lisa"}`)).To(Succeed())
+			Eventually(names).Should(Receive(Equal("Lisa")))
+
+			// the R|CR for user/Bart goes through, its R|US for user/Lisa doesn't
+			protocol.FailOn(testing.MethodSendAction, protocol.Calls(testing.MethodSendAction)+2, fmt.Errorf("mock error"))
+			Expect(setName("user/Bart", `{"name":"Bart"}`)).To(MatchError("mock error"))
+			Eventually(names).Should(Receive(Equal("Bart")))
+			Expect(anon.Name()).To(Equal("user/Bart"))
+		})
+
+		It("Should keep the record when SetName is called with the same name", func() {
+			names := make(chan interface{}, 2)
+			anon.Subscribe("name", func(data interface{}) { names <- data })
+			Expect(setName("user/Lisa", `{"name":"Lisa"}`)).To(Succeed())
+			Eventually(names).Should(Receive(Equal("Lisa")))
+			protocol.ResetSent()
+
+			Expect(anon.SetName("user/Lisa")).To(Succeed())
+			Expect(anon.Name()).To(Equal("user/Lisa"))
+			Expect(protocol).To(HaveSentMessages(0))
+			Consistently(names, 50*time.Millisecond).ShouldNot(Receive())
+
+			Expect(protocol.DeliverRaw(raw("R", "P", "user/Lisa", "2", "name", "SLisa Simpson"))).To(Succeed())
+			Eventually(names).Should(Receive(Equal("Lisa Simpson")))
+		})
+
+		It("Should stop the subscriptions on Discard", func() {
+			names := make(chan interface{}, 2)
+			anon.Subscribe("name", func(data interface{}) { names <- data })
+			Expect(setName("user/Lisa", `{"name":"Lisa"}`)).To(Succeed())
+			Eventually(names).Should(Receive(Equal("Lisa")))
+
+			Expect(anon.Discard()).To(Succeed())
+			Expect(protocol).To(HaveLastSentMessage("R|US|user/Lisa"))
+			Expect(anon.Name()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,86 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+)
+
+//Binding keeps a Go value populated with the latest data of a record.
+//Hold its read lock while reading the bound value.
+type Binding struct {
+	sync.RWMutex
+
+	record *Record
+	target interface{}
+	subID  int
+}
+
+//GetTyped decodes the value stored at path into out, using ds or json struct tags
+func (r *Record) GetTyped(path string, out interface{}) error {
+	return decodeData(r.GetPath(path), out)
+}
+
+//Bind populates target, a pointer to a struct, with the record data and
+//re-populates it every time the record changes
+func (r *Record) Bind(target interface{}) (*Binding, error) {
+	b := &Binding{
+		record: r,
+		target: target,
+	}
+	if err := b.populate(r.Get()); err != nil {
+		return nil, err
+	}
+
+	b.subID = r.Subscribe("", func(data interface{}) {
+		if err := b.populate(data); err != nil {
+			r.client.log(interfaces.LogLevelWarn, "Binding: could not populate target", interfaces.LogField{Key: "record", Value: r.Name}, errField(err))
+		}
+	})
+	return b, nil
+}
+
+func (b *Binding) populate(data interface{}) error {
+	b.Lock()
+	defer b.Unlock()
+
+	v := reflect.ValueOf(b.target)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+	return decodeData(data, b.target)
+}
+
+//Set sends the changes made to the bound value to the server
+func (b *Binding) Set() error {
+	// snapshot the bound value, incoming updates re-populate it concurrently
+	b.RLock()
+	data, err := encodeData(b.target)
+	b.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	r := b.record
+	r.mu.RLock()
+	old := r.data
+	r.mu.RUnlock()
+	if reflect.DeepEqual(old, data) {
+		return nil
+	}
+	_, _, err = r.writeData("", data, true, false)
+	return err
+}
+
+//Unbind stops updating the bound value
+func (b *Binding) Unbind() {
+	b.record.Unsubscribe(b.subID)
+}
@@ -0,0 +1,182 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"sync"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type boundPet struct {
+	Name string `json:"name"`
+}
+
+type boundPerson struct {
+	Name    string            `ds:"name"`
+	Pets    []boundPet        `ds:"pets"`
+	Address map[string]string `ds:"address"`
+}
+
+var _ = Describe("Binding", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var cli *client.Client
+		var rec *client.Record
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			cli = loggedInClient(protocol)
+			rec = readRecord(cli, protocol, "user/Lisa", 1, `{"name":"Lisa","pets":[{"name":"Max"}],"address":{"city":"Paris"}}`)
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		It("Should populate the bound value with the record data", func() {
+			var person boundPerson
+			binding, err := rec.Bind(&person)
+			Expect(err).NotTo(HaveOccurred())
+			defer binding.Unbind()
+
+			binding.RLock()
+			Expect(person).To(Equal(boundPerson{
+				Name:    "Lisa",
+				Pets:    []boundPet{{Name: "Max"}},
+				Address: map[string]string{"city": "Paris"},
+			}))
+			binding.RUnlock()
+
+			Expect(protocol.DeliverRaw(raw("R", "P", "user/Lisa", "2", "pets.0.name", "SSnowball"))).To(Succeed())
+			Eventually(func() string {
+				binding.RLock()
+				defer binding.RUnlock()
+				return person.Pets[0].Name
+			}).Should(Equal("Snowball"))
+		})
+
+		It("Should stop populating the bound value once unbound", func() {
+			var person boundPerson
+			binding, err := rec.Bind(&person)
+			Expect(err).NotTo(HaveOccurred())
+			binding.Unbind()
+
+			Expect(protocol.DeliverRaw(raw("R", "P", "user/Lisa", "2", "name", "SSmith"))).To(Succeed())
+			Eventually(rec.Version).Should(Equal(2))
+			Expect(person.Name).To(Equal("Lisa"))
+		})
+
+		It("Should send only the paths changed in the bound value", func() {
+			var person boundPerson
+			binding, err := rec.Bind(&person)
+			Expect(err).NotTo(HaveOccurred())
+			defer binding.Unbind()
+
+			Expect(binding.Set()).To(Succeed())
+			Expect(protocol).To(HaveSentMessages(0))
+
+			binding.Lock()
+			person.Name = "Smith"
+			person.Pets[0].Name = "Snowball"
+			binding.Unlock()
+			Expect(binding.Set()).To(Succeed())
+
+			Expect(protocol).To(HaveSentMessages(2))
+			Expect(protocol).To(HaveSentMessage("R|P|user/Lisa|2|name|SSmith"))
+			Expect(protocol).To(HaveSentMessage("R|P|user/Lisa|3|pets.0.name|SSnowball"))
+			Expect(rec.Get()).To(Equal(map[string]interface{}{
+				"name":    "Smith",
+				"pets":    []interface{}{map[string]interface{}{"name": "Snowball"}},
+				"address": map[string]interface{}{"city": "Paris"},
+			}))
+		})
+
+		It("Should send the whole record when the changes can't be patched", func() {
+			var person boundPerson
+			binding, err := rec.Bind(&person)
+			Expect(err).NotTo(HaveOccurred())
+			defer binding.Unbind()
+
+			binding.Lock()
+			person.Address = map[string]string{"country": "France"}
+			binding.Unlock()
+			Expect(binding.Set()).To(Succeed())
+
+			Expect(protocol).To(HaveSentMessages(1))
+			Expect(protocol).To(HaveLastSentMessage(`R|U|user/Lisa|2|{"address":{"country":"France"},"name":"Lisa","pets":[{"name":"Max"}]}`))
+		})
+
+		It("Should diff structs set on the record", func() {
+			Expect(rec.Set(boundPerson{
+				Name:    "Lisa",
+				Pets:    []boundPet{{Name: "Max"}, {Name: "Ruffus"}},
+				Address: map[string]string{"city": "Lyon"},
+			})).To(Succeed())
+
+			// arrays changing length are sent whole
+			Expect(protocol).To(HaveSentMessages(2))
+			Expect(protocol).To(HaveSentMessage("R|P|user/Lisa|2|address.city|SLyon"))
+			Expect(protocol).To(HaveSentMessage(`R|P|user/Lisa|3|pets|O[{"name":"Max"},{"name":"Ruffus"}]`))
+		})
+
+		It("Should patch whole objects with keys that can't be part of a path", func() {
+			Expect(rec.Set(boundPerson{
+				Name:    "Lisa",
+				Pets:    []boundPet{{Name: "Max"}},
+				Address: map[string]string{"city": "Paris", "st.": "Lepic", "75018": "zip"},
+			})).To(Succeed())
+
+			Expect(protocol).To(HaveSentMessages(1))
+			Expect(protocol).To(HaveLastSentMessage(`R|P|user/Lisa|2|address|O{"75018":"zip","city":"Paris","st.":"Lepic"}`))
+		})
+
+		It("Should send the whole record when its own keys can't be part of a path", func() {
+			type nicknamedPerson struct {
+				boundPerson
+				Nickname string `ds:"nick.name"`
+			}
+			Expect(rec.Set(nicknamedPerson{
+				boundPerson: boundPerson{Name: "Lisa", Pets: []boundPet{{Name: "Max"}}, Address: map[string]string{"city": "Paris"}},
+				Nickname:    "Lis",
+			})).To(Succeed())
+
+			Expect(protocol).To(HaveSentMessages(1))
+			Expect(protocol).To(HaveLastSentMessage(`R|U|user/Lisa|2|{"address":{"city":"Paris"},"name":"Lisa","nick.name":"Lis","pets":[{"name":"Max"}]}`))
+		})
+
+		It("Should set the bound value while updates are received", func() {
+			var person boundPerson
+			binding, err := rec.Bind(&person)
+			Expect(err).NotTo(HaveOccurred())
+			defer binding.Unbind()
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					name := []string{"Lisa", "Marge"}[i%2]
+					Expect(protocol.DeliverRaw(raw("R", "U", "user/Lisa", "1", `{"name":"`+name+`"}`))).To(Succeed())
+				}
+			}()
+			for i := 0; i < 100; i++ {
+				binding.Lock()
+				person.Name = "Smith"
+				binding.Unlock()
+				Expect(binding.Set()).To(Succeed())
+			}
+			wg.Wait()
+		})
+	})
+})
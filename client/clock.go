@@ -0,0 +1,103 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+)
+
+// realClock is the default clock, backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+func (realClock) NewTimer(d time.Duration) interfaces.Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+//WithClock sets the clock used for timeouts and reconnection intervals, e.g. a
+//testing.FakeClock advanced by hand in tests
+func WithClock(clock interfaces.Clock) ClientOption {
+	return func(opts *ClientOptions) error {
+		opts.Clock = clock
+		return nil
+	}
+}
+
+func (c *Client) clock() interfaces.Clock {
+	if c.Options.Clock == nil {
+		return realClock{}
+	}
+	return c.Options.Clock
+}
+
+// withTimeout is context.WithTimeout measured with the client clock
+func (c *Client) withTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	clock := c.clock()
+	if _, ok := clock.(realClock); ok {
+		return context.WithTimeout(parent, timeout)
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	timed := &clockContext{Context: ctx, deadline: clock.Now().Add(timeout)}
+	timer := clock.NewTimer(timeout)
+	go func() {
+		select {
+		case <-timer.C():
+			timed.expire()
+			cancel()
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}()
+	return timed, cancel
+}
+
+// clockContext is a context cancelled when a clock reaches its deadline
+type clockContext struct {
+	context.Context
+	deadline time.Time
+
+	mu      sync.Mutex
+	expired bool
+}
+
+func (c *clockContext) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expired = true
+}
+
+func (c *clockContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *clockContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.expired {
+		return context.DeadlineExceeded
+	}
+	return c.Context.Err()
+}
@@ -0,0 +1,404 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// fieldKey returns the record key of a struct field. The ds tag takes
+// precedence over the json tag, which takes precedence over the field name.
+func fieldKey(field reflect.StructField) (key string, omitEmpty bool, skip bool) {
+	tag, ok := field.Tag.Lookup("ds")
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	key = parts[0]
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	if key == "" {
+		key = field.Name
+	}
+	return key, omitEmpty, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+func isEmbeddedStruct(field reflect.StructField) bool {
+	if !field.Anonymous {
+		return false
+	}
+	if _, ok := field.Tag.Lookup("ds"); ok {
+		return false
+	}
+	if _, ok := field.Tag.Lookup("json"); ok {
+		return false
+	}
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// encodeData converts a Go value into the generic form records are stored in
+// (map[string]interface{}, []interface{}, float64, string, bool and nil)
+func encodeData(value interface{}) (interface{}, error) {
+	return encodeValue(reflect.ValueOf(value))
+}
+
+func encodeValue(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if v.Type().Implements(jsonMarshalerType) {
+		return jsonRoundTrip(v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return encodeValue(v.Elem())
+	case reflect.Struct:
+		obj := map[string]interface{}{}
+		if err := encodeStruct(v, obj); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return jsonRoundTrip(v.Interface())
+		}
+		obj := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			item, err := encodeValue(v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			obj[key.String()] = item
+		}
+		return obj, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return jsonRoundTrip(v.Interface())
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			item, err := encodeValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+	}
+	return jsonRoundTrip(v.Interface())
+}
+
+func encodeStruct(v reflect.Value, obj map[string]interface{}) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		fv := v.Field(i)
+
+		if isEmbeddedStruct(field) {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if err := encodeStruct(fv, obj); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key, omitEmpty, skip := fieldKey(field)
+		if skip || (omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		item, err := encodeValue(fv)
+		if err != nil {
+			return err
+		}
+		obj[key] = item
+	}
+	return nil
+}
+
+//Decode populates out, which must be a pointer, from record, event or RPC data
+//using ds or json struct tags
+func Decode(data interface{}, out interface{}) error {
+	return decodeData(data, out)
+}
+
+// decodeData populates target, which must be a pointer, from generic record data
+func decodeData(data interface{}, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("Decode target must be a non nil pointer, got %T", target)
+	}
+	return decodeValue(data, v.Elem())
+}
+
+func decodeValue(data interface{}, v reflect.Value) error {
+	if v.CanAddr() && v.Addr().Type().Implements(jsonUnmarshalerType) {
+		return jsonAssign(data, v)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if data == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(data, v.Elem())
+	case reflect.Struct:
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			if data == nil {
+				return nil
+			}
+			return fmt.Errorf("Cannot decode %T into %s", data, v.Type())
+		}
+		return decodeStruct(obj, v)
+	case reflect.Map:
+		obj, ok := data.(map[string]interface{})
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return jsonAssign(data, v)
+		}
+		result := reflect.MakeMapWithSize(v.Type(), len(obj))
+		for key, item := range obj {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeValue(item, elem); err != nil {
+				return err
+			}
+			result.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		v.Set(result)
+		return nil
+	case reflect.Slice:
+		list, ok := data.([]interface{})
+		if !ok {
+			return jsonAssign(data, v)
+		}
+		result := reflect.MakeSlice(v.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := decodeValue(item, result.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(result)
+		return nil
+	}
+	return jsonAssign(data, v)
+}
+
+func decodeStruct(obj map[string]interface{}, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		if isEmbeddedStruct(field) {
+			if fv.Kind() == reflect.Ptr {
+				if !fv.CanSet() {
+					continue
+				}
+				if fv.IsNil() {
+					fv.Set(reflect.New(field.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if err := decodeStruct(obj, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key, _, skip := fieldKey(field)
+		if skip {
+			continue
+		}
+		item, ok := obj[key]
+		if !ok {
+			continue
+		}
+		if err := decodeValue(item, fv); err != nil {
+			return fmt.Errorf("%s.%s: %v", t.Name(), field.Name, err)
+		}
+	}
+	return nil
+}
+
+func jsonRoundTrip(value interface{}) (interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func jsonAssign(data interface{}, v reflect.Value) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	target := reflect.New(v.Type())
+	if err := json.Unmarshal(raw, target.Interface()); err != nil {
+		return err
+	}
+	v.Set(target.Elem())
+	return nil
+}
+
+type recordPatch struct {
+	path  string
+	value interface{}
+}
+
+// diffData returns the path patches turning before into after. Objects with
+// keys that can't be part of a path are patched whole. It returns false when
+// the change can't be expressed as patches, e.g. removed keys.
+func diffData(before, after interface{}, prefix string) ([]recordPatch, bool) {
+	if reflect.DeepEqual(before, after) {
+		return nil, true
+	}
+
+	beforeObj, beforeIsObj := before.(map[string]interface{})
+	afterObj, afterIsObj := after.(map[string]interface{})
+	if beforeIsObj && afterIsObj {
+		for key := range beforeObj {
+			if _, ok := afterObj[key]; !ok {
+				return nil, false
+			}
+		}
+		for key := range afterObj {
+			if !isPathKey(key) {
+				// the key would resolve elsewhere on the server, send the subtree
+				if prefix == "" {
+					return nil, false
+				}
+				return []recordPatch{{path: prefix, value: after}}, true
+			}
+		}
+		patches := []recordPatch{}
+		for _, key := range sortedKeys(afterObj) {
+			sub, ok := diffData(beforeObj[key], afterObj[key], joinPath(prefix, key))
+			if !ok {
+				return nil, false
+			}
+			patches = append(patches, sub...)
+		}
+		return patches, true
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	if beforeIsList && afterIsList && len(beforeList) == len(afterList) && prefix != "" {
+		patches := []recordPatch{}
+		for i := range afterList {
+			sub, ok := diffData(beforeList[i], afterList[i], joinPath(prefix, strconv.Itoa(i)))
+			if !ok {
+				return nil, false
+			}
+			patches = append(patches, sub...)
+		}
+		return patches, true
+	}
+
+	if prefix == "" {
+		return nil, false
+	}
+	return []recordPatch{{path: prefix, value: after}}, true
+}
+
+// isPathKey tells whether key can be a token of a record path. Keys holding
+// path separators are split, and numeric ones may be taken as array indexes.
+func isPathKey(key string) bool {
+	if key == "" || strings.ContainsAny(key, ".[]") {
+		return false
+	}
+	_, err := strconv.Atoi(key)
+	return err != nil
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
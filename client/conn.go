@@ -9,15 +9,18 @@ package client
 
 import (
 	"encoding/json"
+	"net/http"
 	"fmt"
+	"sort"
 	"time"
+	"strings"
 	"sync"
 	"github.com/gorilla/websocket"
+	"github.com/ga-con/deepstream.io-client-go/errors"
 	"github.com/ga-con/deepstream.io-client-go/interfaces"
 	"github.com/ga-con/deepstream.io-client-go/message"
 	"github.com/jpillora/backoff"
 	"math/rand"
-	"log"
 )
 
 type AuthUser struct {
@@ -42,6 +45,48 @@ type ClientOptions struct {
 	// HandshakeTimeout specifies the duration for the handshake to complete,
 	// default to 2 seconds
 	HandshakeTimeout time.Duration
+	// RecordReadTimeout specifies the duration to wait for a record's data
+	// after requesting it, default to 3 seconds
+	RecordReadTimeout time.Duration
+	// RecordWriteAckTimeout specifies the duration to wait for the server to
+	// acknowledge a record update made with Update, default to 3 seconds
+	RecordWriteAckTimeout time.Duration
+	// RecordUpdateAttempts specifies how many times Update retries when the
+	// record version changed concurrently, default to 5
+	RecordUpdateAttempts int
+	// RPCAckTimeout specifies the duration to wait for the server to
+	// acknowledge an RPC request, default to 1 second like the server's rpcAckTimeout
+	RPCAckTimeout time.Duration
+	// RPCResponseTimeout specifies the duration to wait for an RPC response,
+	// default to 10 seconds like the server's rpcTimeout
+	RPCResponseTimeout time.Duration
+	// PresenceQueryTimeout specifies the duration to wait for the server to
+	// list the connected clients, default to 3 seconds
+	PresenceQueryTimeout time.Duration
+	// RPCProviderInterceptors wrap the handler of every provided RPC,
+	// the first one being the outermost
+	RPCProviderInterceptors []RPCProviderInterceptor
+	// RPCRequesterInterceptors wrap every RPC request made with Make,
+	// the first one being the outermost
+	RPCRequesterInterceptors []RPCRequesterInterceptor
+	// OnError is called with the errors sent by the server that aren't
+	// handled by a record, event or RPC provider error callback, default
+	// to logging them
+	OnError ErrorCallback
+	// ManualLogin skips logging in with AuthUser once connected, Login must
+	// be called once the state is AWAITING_AUTHENTICATION
+	ManualLogin bool
+	// Logger receives the logs of the client, default to the standard
+	// logger at LogLevelInfo, use NopLogger to silence the client
+	Logger interfaces.Logger
+	// Clock measures the timeouts and reconnection intervals, default to
+	// the system clock
+	Clock interfaces.Clock
+	// NewUID generates the correlation ids of RPC requests and streams,
+	// default to random ids
+	NewUID func() string
+	// Recorder receives every frame going over the connection, see WithRecorder
+	Recorder *message.FrameWriter
 
 	AuthUser AuthUser
 }
@@ -49,10 +94,16 @@ type ClientOptions struct {
 // GetDefaultOptions returns default configuration options for the client.
 func GetDefaultOptions() ClientOptions {
 	return ClientOptions{
-		RecIntvlMin:      2 * time.Second,
-		RecIntvlMax:      30 * time.Second,
-		RecIntvlFactor:   1.5,
-		HandshakeTimeout: 2 * time.Second,
+		RecIntvlMin:           2 * time.Second,
+		RecIntvlMax:           30 * time.Second,
+		RecIntvlFactor:        1.5,
+		HandshakeTimeout:      2 * time.Second,
+		RecordReadTimeout:     3 * time.Second,
+		RecordWriteAckTimeout: 3 * time.Second,
+		RecordUpdateAttempts:  5,
+		RPCAckTimeout:         1 * time.Second,
+		RPCResponseTimeout:    10 * time.Second,
+		PresenceQueryTimeout:  3 * time.Second,
 	}
 }
 
@@ -60,17 +111,82 @@ func GetDefaultOptions() ClientOptions {
 type Client struct {
 	Options         ClientOptions
 	URL             string
-	ConnectionState interfaces.ConnectionState
+	state           interfaces.ConnectionState
+	stateMu         sync.Mutex
 	mu              sync.Mutex
 	dialErr         error
 	isConnected     bool
+	// redirectURL is the server the connection was redirected to by C|RED,
+	// the client goes back to URL once the connection is lost
+	redirectURL     string
+	// connID identifies the current connection, so that a connection failing
+	// for both a read and a write is only reconnected once
+	connID          uint64
 	isLogin         bool
+	hasLoggedIn     bool
+	isClosed        bool
 	dialer          *websocket.Dialer
+	records         map[string]*Record
+	recordsMu       sync.Mutex
+	events          map[string][]*eventSubscription
+	eventsMu        sync.Mutex
+	lastEventSubID  int
+	rpcs            map[string]*rpcCall
+	rpcsMu          sync.Mutex
+	providers       map[string]*rpcProvider
+	providersMu     sync.Mutex
+	activeRequests  map[*rpcRequestState]*RPCRequest
+	unprovideAcks   map[string]chan struct{}
+	presence        []*presenceSubscription
+	presenceMu      sync.Mutex
+	lastPresenceID  int
+	presenceQueries *singleNotifier
+	hasRequests     *singleNotifier
+	snapshots       *singleNotifier
+	listeners       map[string]map[string]ListenCallback
+	listenersMu     sync.Mutex
+	isDraining      bool
+	readDone        chan struct{}
+	protocol        interfaces.Protocol
 	*websocket.Conn
 }
 
-//Dial creates a new client connection.
+//Dial creates a new client connection. url is either a host:port, dialed as
+//wss://host:port/deepstream, or a full websocket URL.
 func Dial(url string, options ...ClientOption) (*Client, error) {
+	cli, err := newClient(dialURL(url), options)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		cli.connect()
+	}()
+
+	// wait on first attempt
+	cli.clock().Sleep(cli.Options.HandshakeTimeout)
+
+	return cli, nil
+}
+
+//New creates a client talking to the server at url over protocol instead of a
+//websocket, e.g. a testing.MockProtocol. It connects in the background and
+//returns right away, the connection state tells how far the handshake went.
+func New(url string, protocol interfaces.Protocol, options ...ClientOption) (*Client, error) {
+	cli, err := newClient(url, options)
+	if err != nil {
+		return nil, err
+	}
+	cli.protocol = protocol
+
+	go func() {
+		cli.connect()
+	}()
+
+	return cli, nil
+}
+
+func newClient(url string, options []ClientOption) (*Client, error) {
 	opts := GetDefaultOptions()
 	for _, opt := range options {
 		if err := opt(&opts); err != nil {
@@ -79,23 +195,34 @@ func Dial(url string, options ...ClientOption) (*Client, error) {
 	}
 
 	cli := &Client{
-		URL:             fmt.Sprintf("wss://%s/deepstream", url),
-		ConnectionState: interfaces.ConnectionStateClosed,
-		dialer:          websocket.DefaultDialer,
+		URL:             url,
+		state:           interfaces.ConnectionStateClosed,
+		dialer:          &websocket.Dialer{Proxy: http.ProxyFromEnvironment},
 		Options:         opts,
+		records:         map[string]*Record{},
+		events:          map[string][]*eventSubscription{},
+		rpcs:            map[string]*rpcCall{},
+		providers:       map[string]*rpcProvider{},
+		activeRequests:  map[*rpcRequestState]*RPCRequest{},
+		unprovideAcks:   map[string]chan struct{}{},
+		presenceQueries: newSingleNotifier(),
+		hasRequests:     newSingleNotifier(),
+		snapshots:       newSingleNotifier(),
+		listeners:       map[string]map[string]ListenCallback{},
 	}
 	cli.dialer.HandshakeTimeout = cli.Options.HandshakeTimeout
-
-	go func() {
-		cli.connect()
-	}()
-
-	// wait on first attempt
-	time.Sleep(cli.Options.HandshakeTimeout)
-
 	return cli, nil
 }
 
+// dialURL returns url if it has a scheme, e.g. ws://localhost:6020/deepstream,
+// or the secure deepstream endpoint of the host url otherwise
+func dialURL(url string) string {
+	if strings.Contains(url, "://") {
+		return url
+	}
+	return fmt.Sprintf("wss://%s/deepstream", url)
+}
+
 func (cli *Client) connect() {
 	b := &backoff.Backoff{
 		Min:    cli.Options.RecIntvlMin,
@@ -109,36 +236,59 @@ func (cli *Client) connect() {
 	for {
 		nextItvl := b.Duration()
 
-		wsConn, _, err := cli.dialer.Dial(cli.URL, nil)
+		url := cli.serverURL()
+		var wsConn *websocket.Conn
+		var err error
+		if cli.protocol != nil {
+			err = cli.protocol.Connect()
+		} else {
+			wsConn, _, err = cli.dialer.Dial(url, nil)
+		}
 
 		cli.mu.Lock()
 		cli.Conn = wsConn
 		cli.dialErr = err
 		cli.isConnected = err == nil
+		if err == nil {
+			cli.connID++
+		}
 		cli.mu.Unlock()
 		if err == nil {
-			log.Printf("Dial: connection was successfully established with %s\n", cli.URL)
+			cli.record(message.FrameConnect, url)
+			cli.setState(interfaces.ConnectionStateAwaitingConnection)
+			cli.log(interfaces.LogLevelInfo, "Dial: connection was successfully established")
 
 			err = cli.getAuthChallenge()
 			if err != nil {
-				fmt.Println(err)
+				cli.log(interfaces.LogLevelError, "Dial: authentication challenge failed", errField(err))
 				return
 			}
 
 			//Send Challenge Response
 			err = cli.sendChallengeResponse()
 			if err != nil {
-				fmt.Println(err)
-
+				cli.log(interfaces.LogLevelError, "Dial: challenge response failed", errField(err))
 				return
 			}
+			cli.setState(interfaces.ConnectionStateChallenging)
 
-			err = cli.receiveAck("C")
+			redirect, err := cli.receiveConnectionAck()
 			if err != nil {
-				fmt.Println(err)
-
+				cli.log(interfaces.LogLevelError, "Dial: connection was not acknowledged", errField(err))
 				return
 			}
+			if redirect != "" {
+				cli.log(interfaces.LogLevelInfo, "Dial: redirected", interfaces.LogField{Key: "redirect", Value: redirect})
+				cli.close()
+				cli.mu.Lock()
+				cli.redirectURL = redirect
+				cli.mu.Unlock()
+				continue
+			}
+			cli.setState(interfaces.ConnectionStateAwaitingAuthentication)
+			if cli.Options.ManualLogin {
+				break
+			}
 
 			var param map[string]interface{}
 			if len(cli.Options.AuthUser.Token) > 0 {
@@ -150,43 +300,60 @@ func (cli *Client) connect() {
 			}
 
 			if err := cli.Login(param); err != nil {
-				log.Println(err)
+				cli.log(interfaces.LogLevelError, "Login failed", errField(err))
 			} else {
-				cli.mu.Lock()
-				cli.isLogin = true
-				cli.mu.Unlock()
-				log.Println("Login OK")
+				cli.log(interfaces.LogLevelInfo, "Login OK")
 			}
 
 			break
 		} else {
-			log.Println("Dial: will try again in", nextItvl, "seconds.", " Error:", err)
+			cli.log(interfaces.LogLevelWarn, "Dial: connection failed", errField(err), interfaces.LogField{Key: "retryIn", Value: nextItvl})
 		}
 
-		time.Sleep(nextItvl)
+		cli.clock().Sleep(nextItvl)
 	}
 }
 
 func (c *Client) sendChallengeResponse() error {
-	challenge := message.NewChallengeResponseAction(c.URL)
+	challenge := message.NewChallengeResponseAction(c.serverURL())
 	return c.SendAction(challenge)
 }
 
-func (c *Client) receiveAck(expectedTopic string) error {
-	// Receive connection Ack
+// receiveConnectionAck waits for C|A, or for C|RED|url redirecting the client
+// to another server, in which case it returns url
+func (c *Client) receiveConnectionAck() (string, error) {
 	actions, err := c.RecvActions()
 	if err != nil {
-		return err
+		return "", err
 	}
+	if len(actions) == 1 {
+		if a, ok := actions[0].(*message.RedirectAction); ok && a.Topic == interfaces.TopicConnection {
+			return a.RawData[0], nil
+		}
+	}
+	return "", expectAck(interfaces.TopicConnection, actions)
+}
 
+// serverURL returns the URL of the server the client connects to, where it was
+// redirected to if any
+func (c *Client) serverURL() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.redirectURL != "" {
+		return c.redirectURL
+	}
+	return c.URL
+}
+
+func expectAck(expectedTopic string, actions []interfaces.Action) error {
 	if len(actions) != 1 {
-		//TODO: change this
-		return fmt.Errorf("Expected Ack")
+		return errors.NewDeepstreamError(expectedTopic, errors.ErrUnsolicitedMessage.Event, "Expected Ack", "")
 	}
 
 	action := actions[0]
 	if a, ok := action.(*message.AckAction); !ok || a.Topic != expectedTopic {
-		return fmt.Errorf("Expected Ack with topic %s.", expectedTopic)
+		return unexpectedAction(expectedTopic, fmt.Sprintf("Ack with topic %s.", expectedTopic), action)
 	}
 
 	return nil
@@ -195,17 +362,39 @@ func (c *Client) receiveAck(expectedTopic string) error {
 //Close connection to deepstream.io server
 // Close closes the underlying network connection without
 // sending or waiting for a close frame.
+// The client doesn't reconnect once closed.
 func (cli *Client) Close() error {
+	cli.mu.Lock()
+	cli.isClosed = true
+	cli.mu.Unlock()
+
+	return cli.close()
+}
+
+func (cli *Client) close() error {
 	cli.mu.Lock()
 	defer cli.mu.Unlock()
 
+	if cli.isConnected {
+		reason := ""
+		if cli.isClosed {
+			reason = message.FrameClosedByClient
+		}
+		cli.record(message.FrameClose, reason)
+	}
+	if cli.protocol != nil {
+		if err := cli.protocol.Close(); err != nil {
+			cli.setState(interfaces.ConnectionStateError)
+			return err
+		}
+	}
 	if cli.Conn != nil {
 		cli.Conn.Close()
 	}
 	cli.isConnected = false
 	cli.isLogin = false
 
-	cli.ConnectionState = interfaces.ConnectionStateClosed
+	cli.setState(interfaces.ConnectionStateClosed)
 	return nil
 }
 
@@ -230,75 +419,172 @@ func (c *Client) Login(authParams map[string]interface{}) error {
 	if err != nil {
 		return err
 	}
+	c.setState(interfaces.ConnectionStateAuthenticating)
 
 	// Receive authentication Ack
 	actions, err := c.RecvActions()
+	if err != nil {
+		return c.Error(err)
+	}
 	if len(actions) != 1 {
-		//TODO: change this
-		return c.Error(fmt.Errorf("Expected Auth Ack"))
+		return c.Error(errors.NewDeepstreamError(interfaces.TopicAuth, errors.ErrUnsolicitedMessage.Event, "Expected Auth Ack", ""))
 	}
 
 	action := actions[0]
-	if a, ok := action.(*message.AckAction); !ok || a.Topic != "A" {
-		return c.Error(fmt.Errorf("Expected Auth Ack"))
+	if a, ok := action.(*message.AckAction); !ok || a.Topic != interfaces.TopicAuth {
+		return c.Error(unexpectedAction(interfaces.TopicAuth, "Auth Ack", action))
 	}
 
-	c.ConnectionState = interfaces.ConnectionStateOpen
+	c.setState(interfaces.ConnectionStateOpen)
 
 	// Listen RecvActions
-	go func() {
-		for {
-			acts, err := c.RecvActions()
+	readDone := make(chan struct{})
+	c.mu.Lock()
+	relogin := c.hasLoggedIn
+	c.isLogin = true
+	c.hasLoggedIn = true
+	c.readDone = readDone
+	c.mu.Unlock()
+	go c.readLoop(readDone)
+
+	if relogin {
+		c.restoreSubscriptions()
+	}
+	return nil
+}
 
-			if err != nil {
-				fmt.Errorf("handlerConnection:%v", err)
-				return
+// restoreSubscriptions subscribes again to the records, events, RPCs and
+// presence still in use once logged in again after the connection was lost,
+// the server forgot them with the previous connection
+func (c *Client) restoreSubscriptions() {
+	c.recordsMu.Lock()
+	records := make([]string, 0, len(c.records))
+	for name := range c.records {
+		records = append(records, name)
+	}
+	c.recordsMu.Unlock()
+
+	c.eventsMu.Lock()
+	events := make([]string, 0, len(c.events))
+	for name, subs := range c.events {
+		if len(subs) > 0 {
+			events = append(events, name)
+		}
+	}
+	c.eventsMu.Unlock()
+
+	c.providersMu.Lock()
+	providers := make([]string, 0, len(c.providers))
+	for name := range c.providers {
+		providers = append(providers, name)
+	}
+	c.providersMu.Unlock()
+
+	c.presenceMu.Lock()
+	presence := len(c.presence) > 0
+	c.presenceMu.Unlock()
+
+	sort.Strings(records)
+	sort.Strings(events)
+	sort.Strings(providers)
+	for _, name := range records {
+		if err := c.sendRecordAction(interfaces.ActionCreateOrRead, name); err != nil {
+			c.log(interfaces.LogLevelWarn, "Login: could not subscribe again", interfaces.LogField{Key: "record", Value: name}, errField(err))
+		}
+	}
+	for _, name := range events {
+		if err := c.sendEventAction(interfaces.ActionSubscribe, name); err != nil {
+			c.log(interfaces.LogLevelWarn, "Login: could not subscribe again", interfaces.LogField{Key: "event", Value: name}, errField(err))
+		}
+	}
+	for _, name := range providers {
+		if err := c.sendRPCAction(interfaces.ActionSubscribe, name); err != nil {
+			c.log(interfaces.LogLevelWarn, "Login: could not provide again", interfaces.LogField{Key: "rpc", Value: name}, errField(err))
+		}
+	}
+	if presence {
+		if err := c.sendPresenceAction(interfaces.ActionSubscribe, interfaces.ActionSubscribe); err != nil {
+			c.log(interfaces.LogLevelWarn, "Login: could not subscribe to presence again", errField(err))
+		}
+	}
+	for _, topic := range []string{interfaces.TopicEvent, interfaces.TopicRecord} {
+		for _, pattern := range c.listenedPatterns(topic) {
+			if err := c.sendListenAction(topic, interfaces.ActionListen, pattern); err != nil {
+				c.log(interfaces.LogLevelWarn, "Login: could not listen again", interfaces.LogField{Key: "pattern", Value: pattern}, errField(err))
 			}
+		}
+	}
+}
+
+func (c *Client) readLoop(done chan struct{}) {
+	defer close(done)
+
+	for {
+		acts, err := c.RecvActions()
+		if err != nil {
+			c.log(interfaces.LogLevelWarn, "readLoop: connection lost", errField(err))
+			return
+		}
 
-			for _, act := range acts {
-				if a, ok := act.(*message.PingAction); !ok || a.Topic != "C" {
-					fmt.Print("handlerConnection:", act)
-					continue
-				}
+		for _, act := range acts {
+			c.dispatch(act)
+		}
+	}
+}
 
-				rAction, _ := message.NewPongAction(&message.Message{
-					Topic:  interfaces.TopicConnection,
-					Action: interfaces.ActionPong,
-				})
+func (c *Client) dispatch(act interfaces.Action) {
+	if a, ok := act.(*message.PingAction); ok && a.Topic == interfaces.TopicConnection {
+		rAction, _ := message.NewPongAction(&message.Message{
+			Topic:  interfaces.TopicConnection,
+			Action: interfaces.ActionPong,
+		})
 
-				if err := c.SendAction(rAction); err != nil {
-					fmt.Println("NewPongAction Error:", err)
-				}
-			}
+		if err := c.SendAction(rAction); err != nil {
+			c.log(interfaces.LogLevelWarn, "dispatch: pong failed", errField(err))
 		}
-	}()
+		return
+	}
 
-	return nil
+	msg := message.MessageOf(act)
+	if msg == nil {
+		return
+	}
+
+	switch msg.Topic {
+	case interfaces.TopicRecord:
+		c.handleRecord(msg)
+	case interfaces.TopicEvent:
+		c.handleEvent(msg)
+	case interfaces.TopicRPC:
+		c.handleRPC(msg)
+	case interfaces.TopicPresence:
+		c.handlePresence(msg)
+	default:
+		if msg.Action == interfaces.ActionError {
+			c.reportError(deepstreamError(msg))
+			return
+		}
+		c.reportError(errors.NewDeepstreamError(msg.Topic, errors.ErrUnsolicitedMessage.Event, "", msg.Raw))
+	}
 }
 
 //Error handlers errors in client
 func (c *Client) Error(err error) error {
-	c.ConnectionState = interfaces.ConnectionStateError
+	c.setState(interfaces.ConnectionStateError)
 	return err
 }
 
 func (c *Client) getAuthChallenge() error {
-	_, body, err := c.Conn.ReadMessage()
+	actions, _, err := c.readActions()
 	if err != nil {
 		return err
 	}
-	msgs, err := message.ParseMessages(string(body))
-	if len(msgs) != 1 {
-		//TODO: Change this
-		return fmt.Errorf("authentication challenge expected")
-	}
-	msg := msgs[0]
-	action, err := message.CathegorizeAction(msg)
-	if err != nil {
-		return err
+	if len(actions) != 1 {
+		return errors.NewDeepstreamError(interfaces.TopicConnection, errors.ErrUnsolicitedMessage.Event, "authentication challenge expected", "")
 	}
+	action := actions[0]
 	if _, ok := action.(*message.ChallengeAction); !ok {
-		return fmt.Errorf("authentication challenge expected 2")
+		return unexpectedAction(interfaces.TopicConnection, "authentication challenge", action)
 	}
 
 	return nil
@@ -308,45 +594,112 @@ func (c *Client) getAuthChallenge() error {
 func (c *Client) SendAction(action interfaces.Action) error {
 	if c.IsConnected() {
 		c.mu.Lock()
-		defer c.mu.Unlock()
+		connID := c.connID
+		var err error
+		if c.protocol != nil {
+			err = c.protocol.SendAction(action)
+		} else {
+			err = c.Conn.WriteMessage(websocket.TextMessage, []byte(action.ToAction()))
+		}
+		if err == nil {
+			c.record(message.FrameOutbound, message.RedactFrame(action.ToAction()))
+		}
+		c.mu.Unlock()
 
-		msg := action.ToAction()
-		err := c.Conn.WriteMessage(websocket.TextMessage, []byte(msg))
 		if err != nil {
-			c.closeAndRecconect()
+			c.closeAndRecconect(connID)
 			return err
 		}
 		return nil
 	}
-	return fmt.Errorf("Not Connected!")
+	return errNotConnected
 }
 
 //RecvActions receives actions from the websocket stream
 func (c *Client) RecvActions() ([]interfaces.Action, error) {
 	if c.IsConnected() {
-		_, body, err := c.Conn.ReadMessage()
+		c.mu.Lock()
+		connID := c.connID
+		c.mu.Unlock()
+		actions, failed, err := c.readActions()
+		if failed {
+			c.closeAndRecconect(connID)
+		}
+		return actions, err
+	}
+
+	return []interfaces.Action{}, errNotConnected
+
+}
+
+// readActions reads the next batch of actions from the protocol or the websocket,
+// failed reports whether the connection failed rather than the parsing
+func (c *Client) readActions() (actions []interfaces.Action, failed bool, err error) {
+	if c.protocol != nil {
+		actions, err = c.protocol.RecvActions()
 		if err != nil {
-			c.closeAndRecconect()
-			return nil, err
+			return nil, true, err
 		}
-		msgs, err := message.ParseMessages(string(body))
+		c.record(message.FrameInbound, rawFrame(actions))
+		return actions, false, nil
+	}
+
+	c.mu.Lock()
+	conn := c.Conn
+	c.mu.Unlock()
+	_, body, err := conn.ReadMessage()
+	if err != nil {
+		return nil, true, err
+	}
+	c.record(message.FrameInbound, string(body))
+	return c.parseFrame(string(body)), false, nil
+}
+
+// parseFrame returns the actions of the messages in a frame, the messages that
+// can't be understood are skipped and reported so that one bad message doesn't
+// cost the others or the connection
+func (c *Client) parseFrame(frame string) []interfaces.Action {
+	actions := []interfaces.Action{}
+	raws := strings.Split(frame, interfaces.MessageSeparator)
+	for i, raw := range raws {
+		if raw == "" && i == len(raws)-1 {
+			continue
+		}
+		msg, err := message.NewMessage(raw)
 		if err != nil {
-			return nil, err
+			c.reportMalformed(interfaces.TopicConnection, raw, err)
+			continue
 		}
-		var actions []interfaces.Action
-		for _, msg := range msgs {
-			action, err := message.CathegorizeAction(msg)
-			if err != nil {
-				return nil, err
-			}
-			actions = append(actions, action)
+		action, err := message.CathegorizeAction(msg)
+		if err != nil {
+			c.reportMalformed(msg.Topic, raw, err)
+			continue
 		}
-
-		return actions, nil
+		actions = append(actions, action)
 	}
+	return actions
+}
+
+// reportMalformed tells OnError about a message received from the server that
+// couldn't be understood
+func (c *Client) reportMalformed(topic, raw string, err error) {
+	c.log(interfaces.LogLevelWarn, "readActions: malformed message", interfaces.LogField{Key: "message", Value: message.ToReadable(raw)}, errField(err))
+	c.reportError(errors.NewDeepstreamError(topic, errors.ErrMessageParseError.Event, err.Error(), raw))
+}
 
-	return []interfaces.Action{}, fmt.Errorf("Not Connected!")
+//State returns the state of the connection, it is safe to call from any goroutine
+func (c *Client) State() interfaces.ConnectionState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
 
+	return c.state
+}
+
+func (c *Client) setState(state interfaces.ConnectionState) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	c.state = state
 }
 
 // IsConnected returns the WebSocket connection state
@@ -365,10 +718,31 @@ func (cli *Client) IsLogined() bool {
 	return cli.isLogin
 }
 
-// CloseAndRecconect will try to reconnect.
-func (rc *Client) closeAndRecconect() {
-	rc.Close()
+// CloseAndRecconect will try to reconnect when the connection connID failed,
+// unless it was already closed or is being reconnected.
+func (rc *Client) closeAndRecconect(connID uint64) {
+	rc.mu.Lock()
+	current := !rc.isClosed && rc.connID == connID
+	if current {
+		rc.connID++
+		// redirects only last as long as the connection
+		rc.redirectURL = ""
+	}
+	readDone := rc.readDone
+	rc.mu.Unlock()
+	if !current {
+		return
+	}
+
+	rc.close()
+	if rc.IsDraining() {
+		return
+	}
 	go func() {
+		// the read loop of the lost connection must not read from the new one
+		if readDone != nil {
+			<-readDone
+		}
 		rc.connect()
 	}()
 
@@ -8,15 +8,77 @@
 package client_test
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/errors"
 	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
 	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
+func raw(parts ...string) string {
+	return strings.Join(parts, interfaces.MessagePartSeparator)
+}
+
+func testOptions(opts *client.ClientOptions) error {
+	opts.ManualLogin = true
+	opts.RecIntvlMin = 10 * time.Millisecond
+	opts.RecIntvlMax = 50 * time.Millisecond
+	opts.Logger = client.NopLogger{}
+	return nil
+}
+
+// connectedClient returns a client awaiting authentication over protocol
+func connectedClient(protocol *testing.MockProtocol, options ...client.ClientOption) *client.Client {
+	cli, err := client.New("localhost:6020", protocol, append([]client.ClientOption{testOptions}, options...)...)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(protocol.DeliverRaw(raw("C", "CH"))).To(Succeed())
+	Expect(protocol.DeliverRaw(raw("C", "A"))).To(Succeed())
+	Eventually(func() interfaces.ConnectionState {
+		return cli.State()
+	}).Should(Equal(interfaces.ConnectionStateAwaitingAuthentication))
+	return cli
+}
+
+// loggedInClient returns a client logged in over protocol, forgetting what it sent
+func loggedInClient(protocol *testing.MockProtocol, options ...client.ClientOption) *client.Client {
+	cli := connectedClient(protocol, options...)
+	Expect(protocol.DeliverRaw(raw("A", "A"))).To(Succeed())
+	Expect(cli.Login(map[string]interface{}{"username": "x"})).To(Succeed())
+	protocol.ResetSent()
+	return cli
+}
+
+// readRecord gets a record over protocol, answering its read with data at
+// version, and forgets what was sent
+func readRecord(cli *client.Client, protocol *testing.MockProtocol, name string, version int, data string) *client.Record {
+	records := make(chan *client.Record, 1)
+	go func() {
+		defer GinkgoRecover()
+		rec, err := cli.GetRecord(name)
+		Expect(err).NotTo(HaveOccurred())
+		records <- rec
+	}()
+	_, err := protocol.WaitForSent(`^R\|CR\|`+regexp.QuoteMeta(name)+`$`, time.Second)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(protocol.DeliverRaw(raw("R", "R", name, strconv.Itoa(version), data))).To(Succeed())
+
+	var rec *client.Record
+	Eventually(records).Should(Receive(&rec))
+	protocol.ResetSent()
+	return rec
+}
+
 var _ = Describe("Client Package", func() {
 	Describe("[Unit]", func() {
 		Describe("Client", func() {
@@ -26,106 +88,412 @@ var _ = Describe("Client Package", func() {
 				protocol = testing.NewMockProtocol()
 			})
 
+			connected := func(options ...client.ClientOption) *client.Client {
+				return connectedClient(protocol, options...)
+			}
+
+			loggedIn := func(options ...client.ClientOption) *client.Client {
+				return loggedInClient(protocol, options...)
+			}
+
 			Describe("Connection", func() {
 				It("Should create a client", func() {
-					client, err := client.New("localhost:6020", protocol)
+					cli := connected()
+					Expect(cli).NotTo(BeNil())
+					Expect(protocol.HasConnected).To(BeTrue())
+					Expect(protocol).To(BeMessage("C|CHR|localhost:6020"))
+				})
+
+				It("Should retry connecting when an error happens", func() {
+					protocol.FailOn(testing.MethodConnect, 1, fmt.Errorf("mock error"))
+
+					_, err := client.New("localhost:6020", protocol, testOptions)
 					Expect(err).NotTo(HaveOccurred())
-					Expect(client).NotTo(BeNil())
-					Expect(client.ConnectionState).To(Equal(interfaces.ConnectionStateAwaitingAuthentication))
+
+					Eventually(func() int {
+						return protocol.Calls(testing.MethodConnect)
+					}).Should(Equal(2))
 					Expect(protocol.HasConnected).To(BeTrue())
 				})
 
-				It("Should be in error state when an error happens", func() {
-					expErr := fmt.Errorf("mock error")
-					protocol.Error = expErr
+				It("Should fail the handshake when the challenge isn't received", func() {
+					cli, err := client.New("localhost:6020", protocol, testOptions)
+					Expect(err).NotTo(HaveOccurred())
 
-					client, err := client.New("localhost:6020", protocol)
-					Expect(client).NotTo(BeNil())
-					Expect(err).To(MatchError(expErr))
-					Expect(client.ConnectionState).To(Equal(interfaces.ConnectionStateError))
-					Expect(protocol.HasConnected).To(BeFalse())
+					Expect(protocol.DeliverRaw(raw("C", "A"))).To(Succeed())
+
+					Consistently(func() int {
+						return protocol.Calls(testing.MethodSendAction)
+					}, 100*time.Millisecond).Should(Equal(0))
+					Expect(cli.State()).To(Equal(interfaces.ConnectionStateAwaitingConnection))
 				})
 
 				It("Should close a connection", func() {
-					client, err := client.New("localhost:6020", protocol)
-					Expect(err).NotTo(HaveOccurred())
+					cli := connected()
 
-					err = client.Close()
+					err := cli.Close()
 					Expect(err).NotTo(HaveOccurred())
-					Expect(client.ConnectionState).To(Equal(interfaces.ConnectionStateClosed))
+					Expect(cli.State()).To(Equal(interfaces.ConnectionStateClosed))
 
 					Expect(protocol.IsClosed).To(BeTrue())
 				})
 
+				It("Should not reconnect once closed", func() {
+					cli := loggedIn()
+
+					Expect(cli.Close()).To(Succeed())
+
+					Consistently(func() int {
+						return protocol.Calls(testing.MethodConnect)
+					}, 100*time.Millisecond).Should(Equal(1))
+					Expect(protocol.IsClosed).To(BeTrue())
+				})
+
 				It("Should error when closing a connection", func() {
-					client, err := client.New("localhost:6020", protocol)
-					Expect(err).NotTo(HaveOccurred())
+					cli := connected()
 
 					expErr := fmt.Errorf("mock error")
-					protocol.Error = expErr
-					err = client.Close()
+					protocol.FailOn(testing.MethodClose, 1, expErr)
+					err := cli.Close()
 					Expect(err).To(MatchError(expErr))
-					Expect(client.ConnectionState).To(Equal(interfaces.ConnectionStateError))
+					Expect(cli.State()).To(Equal(interfaces.ConnectionStateError))
 
 					Expect(protocol.IsClosed).To(BeFalse())
 				})
 
+				It("Should reconnect when sending fails", func() {
+					cli := loggedIn()
+
+					expErr := fmt.Errorf("mock error")
+					protocol.FailOn(testing.MethodSendAction, 3, expErr)
+					err := cli.EmitEvent("test1", "data")
+					Expect(err).To(MatchError(expErr))
+
+					Eventually(func() int {
+						return protocol.Calls(testing.MethodConnect)
+					}).Should(BeNumerically(">=", 2))
+				})
+
+				It("Should follow redirects until the connection is lost", func() {
+					cli, err := client.New("localhost:6020", protocol, testOptions)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(protocol.DeliverRaw(raw("C", "CH"))).To(Succeed())
+					_, err = protocol.WaitForSent(`^C\|CHR\|localhost:6020$`, time.Second)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(protocol.DeliverRaw(raw("C", "RED", "localhost:6021"))).To(Succeed())
+					Eventually(func() int {
+						return protocol.Calls(testing.MethodConnect)
+					}).Should(Equal(2))
+					Expect(protocol.DeliverRaw(raw("C", "CH"))).To(Succeed())
+					_, err = protocol.WaitForSent(`^C\|CHR\|localhost:6021$`, time.Second)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(protocol.DeliverRaw(raw("C", "A"))).To(Succeed())
+					Eventually(cli.State).Should(Equal(interfaces.ConnectionStateAwaitingAuthentication))
+
+					protocol.FailOn(testing.MethodSendAction, protocol.Calls(testing.MethodSendAction)+1, fmt.Errorf("mock error"))
+					Expect(cli.Login(map[string]interface{}{"username": "x"})).NotTo(Succeed())
+					Eventually(func() int {
+						return protocol.Calls(testing.MethodConnect)
+					}).Should(Equal(3))
+					protocol.ResetSent()
+					Expect(protocol.DeliverRaw(raw("C", "CH"))).To(Succeed())
+					_, err = protocol.WaitForSent(`^C\|CHR\|localhost:6020$`, time.Second)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("Should subscribe again once logged in after reconnecting", func() {
+					cli := loggedIn()
+					_, err := cli.SubscribeEvent("test1", func(data interface{}) {})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(cli.Provide("toUppercase", func(req *client.RPCRequest) {})).To(Succeed())
+					Expect(cli.ListenEvents("test/.*", func(string, bool) bool { return true })).To(Succeed())
+					readRecord(cli, protocol, "user/Lisa", 1, `{"name":"Lisa"}`)
+
+					protocol.FailOn(testing.MethodSendAction, protocol.Calls(testing.MethodSendAction)+1, fmt.Errorf("mock error"))
+					Expect(cli.EmitEvent("test1", "data")).NotTo(Succeed())
+					Eventually(func() int {
+						return protocol.Calls(testing.MethodConnect)
+					}).Should(Equal(2))
+
+					Expect(protocol.DeliverRaw(raw("C", "CH"))).To(Succeed())
+					Expect(protocol.DeliverRaw(raw("C", "A"))).To(Succeed())
+					Eventually(cli.State).Should(Equal(interfaces.ConnectionStateAwaitingAuthentication))
+					protocol.ResetSent()
+
+					Expect(protocol.DeliverRaw(raw("A", "A"))).To(Succeed())
+					Expect(cli.Login(map[string]interface{}{"username": "x"})).To(Succeed())
+					Expect(protocol).To(HaveSentMessages(5))
+					Expect(protocol).To(HaveSentMessage("R|CR|user/Lisa"))
+					Expect(protocol).To(HaveSentMessage("E|S|test1"))
+					Expect(protocol).To(HaveSentMessage("P|S|toUppercase"))
+					Expect(protocol).To(HaveSentMessage("E|L|test/.*"))
+				})
+
+				It("Should not subscribe again on the first login", func() {
+					cli := connected()
+					protocol.ResetSent()
+					Expect(protocol.DeliverRaw(raw("A", "A"))).To(Succeed())
+					Expect(cli.Login(map[string]interface{}{"username": "x"})).To(Succeed())
+					Expect(protocol).To(HaveSentMessages(1))
+				})
+			})
+
+			Describe("Clock", func() {
+				var clock *testing.FakeClock
+
+				BeforeEach(func() {
+					clock = testing.NewFakeClock()
+				})
+
+				It("Should wait for the reconnection interval on the clock", func() {
+					protocol.FailOn(testing.MethodConnect, 1, fmt.Errorf("mock error"))
+
+					_, err := client.New("localhost:6020", protocol, testOptions, client.WithClock(clock))
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(clock.WaitForTimers(1, time.Second)).To(Succeed())
+					Expect(protocol.Calls(testing.MethodConnect)).To(Equal(1))
+
+					clock.Advance(time.Second)
+					Eventually(func() int {
+						return protocol.Calls(testing.MethodConnect)
+					}).Should(Equal(2))
+				})
+
+				It("Should time RPC requests out on the clock", func() {
+					cli := loggedIn(client.WithClock(clock))
+
+					result := make(chan error, 1)
+					go func() {
+						_, err := cli.Make("toUppercase", "abc")
+						result <- err
+					}()
+
+					Expect(clock.WaitForTimers(2, time.Second)).To(Succeed())
+					Consistently(result, 20*time.Millisecond).ShouldNot(Receive())
+
+					clock.Advance(cli.Options.RPCAckTimeout)
+					Eventually(result).Should(Receive(MatchError(errors.ErrAckTimeout)))
+				})
+
+				It("Should expire RPC requests on the clock", func() {
+					cli := loggedIn(client.WithClock(clock))
+					requests := make(chan *client.RPCRequest, 1)
+					Expect(cli.Provide("toUppercase", func(req *client.RPCRequest) {
+						requests <- req
+					})).To(Succeed())
+
+					Expect(protocol.DeliverRaw(raw("P", "REQ", "toUppercase", "1", "Sabc"))).To(Succeed())
+					var req *client.RPCRequest
+					Eventually(requests).Should(Receive(&req))
+					Expect(req.Context().Err()).NotTo(HaveOccurred())
+
+					clock.Advance(cli.Options.RPCResponseTimeout)
+					Eventually(req.Context().Done()).Should(BeClosed())
+					Expect(req.Context().Err()).To(Equal(context.DeadlineExceeded))
+				})
+			})
+
+			Describe("Recorder", func() {
+				It("Should record the frames going over the connection", func() {
+					var buf bytes.Buffer
+					clock := testing.NewFakeClock()
+					cli := connected(client.WithRecorder(&buf), client.WithClock(clock))
+					Expect(cli.Close()).To(Succeed())
+
+					frames, err := message.ReadFrames(&buf)
+					Expect(err).NotTo(HaveOccurred())
+					directions := []string{}
+					readable := []string{}
+					for _, frame := range frames {
+						Expect(frame.Time).To(Equal(clock.Now()))
+						directions = append(directions, frame.Direction)
+						readable = append(readable, frame.Readable())
+					}
+					Expect(directions).To(Equal([]string{
+						message.FrameConnect, message.FrameInbound, message.FrameOutbound, message.FrameInbound, message.FrameClose,
+					}))
+					Expect(readable).To(Equal([]string{"localhost:6020", "C|CH+", "C|CHR|localhost:6020+", "C|A+", "client"}))
+				})
 			})
 
 			Describe("Authentication", func() {
 				It("Should authenticate", func() {
-					client, err := client.New("localhost:6020", protocol)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(client).NotTo(BeNil())
+					cli := connected()
+					Expect(protocol.DeliverRaw(raw("A", "A"))).To(Succeed())
 
-					authParams := map[string]interface{}{
+					err := cli.Login(map[string]interface{}{
 						"user":     "x",
 						"password": "y",
-					}
-					err = client.Login(authParams)
+					})
 					Expect(err).NotTo(HaveOccurred())
 
-					Expect(protocol.IsAuthenticated).To(BeTrue())
-					Expect(protocol.AuthParams).To(Equal(authParams))
+					Expect(protocol).To(HaveLastSentMessage(`A|REQ|{"password":"y","user":"x"}`))
+					Expect(cli.State()).To(Equal(interfaces.ConnectionStateOpen))
+					Expect(cli.IsLogined()).To(BeTrue())
 				})
 
 				It("Should return error when authentication fails", func() {
-					client, err := client.New("localhost:6020", protocol)
-					Expect(err).NotTo(HaveOccurred())
-					Expect(client).NotTo(BeNil())
-
-					expErr := fmt.Errorf("mock error")
-					protocol.Error = expErr
+					cli := connected()
+					Expect(protocol.DeliverRaw(raw("A", "E", "INVALID_AUTH_DATA", "Sinvalid authentication data"))).To(Succeed())
 
-					authParams := map[string]interface{}{
+					err := cli.Login(map[string]interface{}{
 						"user":     "x",
 						"password": "y",
-					}
-					err = client.Login(authParams)
+					})
 					Expect(err).To(HaveOccurred())
+					Expect(errors.Is(err, errors.ErrInvalidAuthData)).To(BeTrue())
+					Expect(err.Error()).To(ContainSubstring("invalid authentication data"))
+
+					Expect(cli.State()).To(Equal(interfaces.ConnectionStateError))
+					Expect(cli.IsLogined()).To(BeFalse())
+				})
+
+				It("Should return error when the request can't be sent", func() {
+					cli := connected()
+
+					expErr := fmt.Errorf("mock error")
+					protocol.FailOn(testing.MethodSendAction, 2, expErr)
+
+					err := cli.Login(map[string]interface{}{"user": "x"})
 					Expect(err).To(MatchError(expErr))
+					Expect(cli.IsLogined()).To(BeFalse())
+				})
+
+				It("Should wait for Login with ManualLogin", func() {
+					cli := connected(func(opts *client.ClientOptions) error {
+						opts.AuthUser = client.AuthUser{Username: "x", Password: "y"}
+						return nil
+					})
+					Consistently(func() int {
+						return protocol.Calls(testing.MethodSendAction)
+					}, 100*time.Millisecond).Should(Equal(1))
+					Expect(cli.State()).To(Equal(interfaces.ConnectionStateAwaitingAuthentication))
+					Expect(cli.IsLogined()).To(BeFalse())
+				})
+
+				It("Should log in with AuthUser without ManualLogin", func() {
+					cli, err := client.New("localhost:6020", protocol, testOptions, func(opts *client.ClientOptions) error {
+						opts.ManualLogin = false
+						opts.AuthUser = client.AuthUser{Username: "x", Password: "y"}
+						return nil
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(protocol.DeliverRaw(raw("C", "CH"))).To(Succeed())
+					Expect(protocol.DeliverRaw(raw("C", "A"))).To(Succeed())
+					_, err = protocol.WaitForSent(`^A\|REQ\|`, time.Second)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(protocol).To(HaveLastSentMessage(`A|REQ|{"password":"y","username":"x"}`))
+
+					Expect(protocol.DeliverRaw(raw("A", "A"))).To(Succeed())
+					Eventually(cli.IsLogined).Should(BeTrue())
+				})
+
+				It("Should log in with the AuthUser token without ManualLogin", func() {
+					_, err := client.New("localhost:6020", protocol, testOptions, func(opts *client.ClientOptions) error {
+						opts.ManualLogin = false
+						opts.AuthUser = client.AuthUser{Token: "secret"}
+						return nil
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(protocol.DeliverRaw(raw("C", "CH"))).To(Succeed())
+					Expect(protocol.DeliverRaw(raw("C", "A"))).To(Succeed())
+					_, err = protocol.WaitForSent(`^A\|REQ\|`, time.Second)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(protocol).To(HaveLastSentMessage(`A|REQ|{"token":"secret"}`))
+				})
+			})
+
+			Describe("Dispatch", func() {
+				It("Should answer pings", func() {
+					loggedIn()
 
-					Expect(protocol.IsAuthenticated).To(BeFalse())
-					Expect(protocol.AuthParams).To(Equal(authParams))
+					Expect(protocol.DeliverRaw(raw("C", "PI"))).To(Succeed())
+
+					action, err := protocol.WaitForSent(`^C\|PO$`, time.Second)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(action.Raw).To(Equal(raw("C", "PO")))
+				})
+
+				It("Should deliver events to subscribers", func() {
+					cli := loggedIn()
+					received := make(chan interface{}, 1)
+					go func() {
+						defer GinkgoRecover()
+						_, err := cli.SubscribeEvent("test1", func(data interface{}) {
+							received <- data
+						})
+						Expect(err).NotTo(HaveOccurred())
+					}()
+
+					_, err := protocol.WaitForSent(`^E\|S\|test1$`, time.Second)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(protocol.DeliverRaw(raw("E", "A", "S", "test1"))).To(Succeed())
+					Expect(protocol.DeliverRaw(raw("E", "EVT", "test1", "Sdata"))).To(Succeed())
+
+					Eventually(received).Should(Receive(Equal("data")))
+				})
+
+				It("Should report unsolicited errors", func() {
+					reported := make(chan *errors.DeepstreamError, 1)
+					loggedIn(func(opts *client.ClientOptions) error {
+						opts.OnError = func(err *errors.DeepstreamError) {
+							reported <- err
+						}
+						return nil
+					})
+
+					Expect(protocol.DeliverRaw(raw("X", "E", "MESSAGE_DENIED", "Sdenied"))).To(Succeed())
+
+					var reportedErr *errors.DeepstreamError
+					Eventually(reported).Should(Receive(&reportedErr))
+					Expect(errors.Is(reportedErr, errors.ErrMessageDenied)).To(BeTrue())
 				})
 			})
 		})
 	})
 	Describe("[Integration]", func() {
 		Describe("Client", func() {
+			var server *testing.FakeServer
+
+			BeforeEach(func() {
+				server = testing.NewFakeServer()
+				server.OnConnect(raw("C", "CH"))
+				server.Reply(raw("C", "CHR", server.URL), raw("C", "A"))
+				server.Reply(raw("A", "REQ", `{"password":"password","username":"userA"}`), raw("A", "A"))
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
 			Describe("Connection", func() {
 				It("Should create a client", func() {
-					client, err := client.New("localhost:6020")
+					client, err := client.Dial(server.URL, testOptions, func(opts *client.ClientOptions) error {
+						opts.HandshakeTimeout = 100 * time.Millisecond
+						return nil
+					})
 					Expect(err).NotTo(HaveOccurred())
 					Expect(client).NotTo(BeNil())
-					Expect(client.ConnectionState).To(Equal(interfaces.ConnectionStateAwaitingConnection))
+					defer client.Close()
+
+					Expect(server.ActiveConnections()).To(Equal(1))
+					Expect(server.WaitForMessage(raw("C", "CHR", server.URL), time.Second)).To(Succeed())
 				})
 			})
 
 			Describe("Authentication", func() {
 				It("Should send authentication message", func() {
-					client, err := client.New("localhost:6020")
+					client, err := client.Dial(server.URL, testOptions, func(opts *client.ClientOptions) error {
+						opts.HandshakeTimeout = 100 * time.Millisecond
+						return nil
+					})
 					Expect(err).NotTo(HaveOccurred())
+					defer client.Close()
+					Eventually(func() interfaces.ConnectionState {
+						return client.State()
+					}).Should(Equal(interfaces.ConnectionStateAwaitingAuthentication))
 
 					err = client.Login(map[string]interface{}{
 						"username": "userA",
@@ -133,7 +501,45 @@ var _ = Describe("Client Package", func() {
 					})
 					Expect(err).NotTo(HaveOccurred())
 
-					Expect(client.ConnectionState).To(Equal(interfaces.ConnectionStateOpen))
+					Expect(client.IsLogined()).To(BeTrue())
+				})
+			})
+
+			Describe("Malformed messages", func() {
+				It("Should skip and report them and keep reading", func() {
+					reported := make(chan *errors.DeepstreamError, 10)
+					client, err := client.Dial(server.URL, testOptions, func(opts *client.ClientOptions) error {
+						opts.HandshakeTimeout = 100 * time.Millisecond
+						opts.OnError = func(err *errors.DeepstreamError) {
+							reported <- err
+						}
+						return nil
+					})
+					Expect(err).NotTo(HaveOccurred())
+					defer client.Close()
+					Eventually(func() interfaces.ConnectionState {
+						return client.State()
+					}).Should(Equal(interfaces.ConnectionStateAwaitingAuthentication))
+					Expect(client.Login(map[string]interface{}{
+						"username": "userA",
+						"password": "password",
+					})).To(Succeed())
+
+					Expect(server.Send(raw("R", "P"))).To(Succeed())
+					var reportedErr *errors.DeepstreamError
+					Eventually(reported).Should(Receive(&reportedErr))
+					Expect(errors.Is(reportedErr, errors.ErrMessageParseError)).To(BeTrue())
+					Expect(reportedErr.Raw).To(Equal(raw("R", "P")))
+
+					Expect(server.Send(raw("C", "PI"))).To(Succeed())
+					Expect(server.WaitForMessage(raw("C", "PO"), time.Second)).To(Succeed())
+					Expect(client.State()).To(Equal(interfaces.ConnectionStateOpen))
+
+					// the other messages of the frame are still handled
+					server.ResetMessageCount()
+					Expect(server.Send(raw("E", "EVT") + interfaces.MessageSeparator + raw("C", "PI"))).To(Succeed())
+					Eventually(reported).Should(Receive())
+					Expect(server.WaitForMessage(raw("C", "PO"), time.Second)).To(Succeed())
 				})
 			})
 		})
@@ -0,0 +1,169 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"context"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/gorilla/websocket"
+)
+
+//Drain gracefully shuts the client down: it stops providing every RPC and waits
+//for the server to acknowledge it, waits for in-flight requests to be answered
+//and their handlers to return, discards all records, event subscriptions, listened
+//patterns and presence subscriptions and closes the connection with a close frame. If ctx is done first the connection
+//is closed right away and ctx.Err() is returned.
+func (c *Client) Drain(ctx context.Context) error {
+	c.providersMu.Lock()
+	c.isDraining = true
+	acks := map[string]chan struct{}{}
+	for name := range c.providers {
+		acks[name] = make(chan struct{})
+		c.unprovideAcks[name] = acks[name]
+	}
+	c.providers = map[string]*rpcProvider{}
+	c.providersMu.Unlock()
+
+	err := c.drain(ctx, acks)
+	c.Close()
+	return err
+}
+
+func (c *Client) drain(ctx context.Context, acks map[string]chan struct{}) error {
+	for name := range acks {
+		if err := c.sendRPCAction(interfaces.ActionUnsubscribe, name); err != nil {
+			return err
+		}
+	}
+	for _, ack := range acks {
+		select {
+		case <-ack:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	c.providersMu.Lock()
+	active := make([]*RPCRequest, 0, len(c.activeRequests))
+	for _, req := range c.activeRequests {
+		active = append(active, req)
+	}
+	c.providersMu.Unlock()
+	for _, req := range active {
+		select {
+		case <-req.state.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := c.discardAll(); err != nil {
+		return err
+	}
+	return c.closeGracefully(ctx)
+}
+
+// discardAll tells the server to stop sending updates for every record, event,
+// listened pattern and presence
+func (c *Client) discardAll() error {
+	c.recordsMu.Lock()
+	records := c.records
+	c.records = map[string]*Record{}
+	c.recordsMu.Unlock()
+	for name := range records {
+		if err := c.sendRecordAction(interfaces.ActionUnsubscribe, name); err != nil {
+			return err
+		}
+	}
+
+	c.eventsMu.Lock()
+	events := c.events
+	c.events = map[string][]*eventSubscription{}
+	c.eventsMu.Unlock()
+	for name := range events {
+		if err := c.sendEventAction(interfaces.ActionUnsubscribe, name); err != nil {
+			return err
+		}
+	}
+
+	for _, topic := range []string{interfaces.TopicEvent, interfaces.TopicRecord} {
+		for _, pattern := range c.listenedPatterns(topic) {
+			if !c.removeListener(topic, pattern) {
+				continue
+			}
+			if err := c.sendListenAction(topic, interfaces.ActionUnlisten, pattern); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.presenceMu.Lock()
+	presence := len(c.presence) > 0
+	c.presence = nil
+	c.presenceMu.Unlock()
+	if presence {
+		return c.sendPresenceAction(interfaces.ActionUnsubscribe, interfaces.ActionUnsubscribe)
+	}
+	return nil
+}
+
+// closeGracefully sends a close frame and waits for the server to close the connection
+func (c *Client) closeGracefully(ctx context.Context) error {
+	if !c.IsConnected() {
+		return nil
+	}
+
+	c.mu.Lock()
+	conn := c.Conn
+	readDone := c.readDone
+	c.mu.Unlock()
+	if conn == nil {
+		// protocols other than websocket have no close frame
+		return nil
+	}
+
+	deadline := c.clock().Now().Add(c.Options.HandshakeTimeout)
+	closeMsg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	c.mu.Lock()
+	err := conn.WriteControl(websocket.CloseMessage, closeMsg, deadline)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if readDone == nil {
+		return nil
+	}
+	select {
+	case <-readDone:
+	case <-c.clock().After(c.Options.HandshakeTimeout):
+		c.log(interfaces.LogLevelWarn, "Drain: server did not close the connection in time")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (c *Client) ackUnprovide(name string) {
+	c.providersMu.Lock()
+	defer c.providersMu.Unlock()
+
+	if ack, ok := c.unprovideAcks[name]; ok {
+		close(ack)
+		delete(c.unprovideAcks, name)
+	}
+}
+
+//IsDraining returns true once Drain has been called
+func (c *Client) IsDraining() bool {
+	c.providersMu.Lock()
+	defer c.providersMu.Unlock()
+
+	return c.isDraining
+}
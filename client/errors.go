@@ -0,0 +1,70 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+var errNotConnected = errors.NewDeepstreamError(interfaces.TopicConnection, errors.ErrIsClosed.Event, "Not Connected!", "")
+
+// deepstreamError converts an error message such as R|E|RECORD_NOT_FOUND|name
+// into a DeepstreamError
+func deepstreamError(msg *message.Message) *errors.DeepstreamError {
+	if len(msg.RawData) == 0 {
+		return errors.NewDeepstreamError(msg.Topic, "", "", msg.Raw)
+	}
+	reason := strings.Join(msg.RawData[1:], " ")
+	// auth errors carry typed data, e.g. A|E|INVALID_AUTH_DATA|Sinvalid authentication data
+	if msg.Topic == interfaces.TopicAuth && len(msg.RawData) == 2 {
+		if data, err := message.ParseTyped(msg.RawData[1]); err == nil && data != nil {
+			reason = fmt.Sprint(data)
+		}
+	}
+	return errors.NewDeepstreamError(msg.Topic, msg.RawData[0], reason, msg.Raw)
+}
+
+// unexpectedAction describes act, received instead of the expected action. Error
+// messages sent by the server are converted, anything else is unsolicited.
+func unexpectedAction(topic, expected string, act interfaces.Action) error {
+	reason := fmt.Sprintf("Expected %s, got %s", expected, message.Readable(act))
+	msg := message.MessageOf(act)
+	if msg == nil {
+		return errors.NewDeepstreamError(topic, errors.ErrUnsolicitedMessage.Event, reason, "")
+	}
+	if msg.Action == interfaces.ActionError {
+		return deepstreamError(msg)
+	}
+	return errors.NewDeepstreamError(topic, errors.ErrUnsolicitedMessage.Event, reason, msg.Raw)
+}
+
+//ErrorCallback is called with an error sent by the server
+type ErrorCallback func(err *errors.DeepstreamError)
+
+// reportError hands err to ClientOptions.OnError
+func (c *Client) reportError(err *errors.DeepstreamError) {
+	if c.Options.OnError != nil {
+		c.Options.OnError(err)
+		return
+	}
+	c.log(interfaces.LogLevelError, "deepstream error", interfaces.LogField{Key: "topic", Value: err.Topic}, interfaces.LogField{Key: "event", Value: err.Event}, errField(err))
+}
+
+// errorSubject returns the name of the record, event or RPC an error message
+// such as E|E|MESSAGE_DENIED|name is about
+func errorSubject(msg *message.Message) string {
+	if len(msg.RawData) < 2 {
+		return ""
+	}
+	return msg.RawData[1]
+}
@@ -0,0 +1,209 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+type eventSubscription struct {
+	id       int
+	callback func(data interface{})
+	onError  ErrorCallback
+}
+
+//SubscribeEvent registers a callback fired every time the event is emitted.
+//The server is only told about the first local subscription to an event.
+//It returns an id for UnsubscribeEvent.
+func (c *Client) SubscribeEvent(name string, callback func(data interface{})) (int, error) {
+	return c.SubscribeEventWithErrors(name, callback, nil)
+}
+
+//SubscribeEventWithErrors is like SubscribeEvent, onError is fired with the errors
+//the server sends about the event, e.g. MESSAGE_DENIED, instead of ClientOptions.OnError
+func (c *Client) SubscribeEventWithErrors(name string, callback func(data interface{}), onError ErrorCallback) (int, error) {
+	c.eventsMu.Lock()
+	c.lastEventSubID++
+	sub := &eventSubscription{
+		id:       c.lastEventSubID,
+		callback: callback,
+		onError:  onError,
+	}
+	first := len(c.events[name]) == 0
+	c.events[name] = append(c.events[name], sub)
+	c.eventsMu.Unlock()
+
+	if first {
+		if err := c.sendEventAction(interfaces.ActionSubscribe, name); err != nil {
+			c.removeEventSubscription(name, sub.id)
+			return 0, err
+		}
+	}
+	return sub.id, nil
+}
+
+//SubscribeEventTyped is like SubscribeEventWithErrors, fn must be a func(Data):
+//event data is decoded into Data, data that can't be decoded is reported to
+//onError as a MESSAGE_PARSE_ERROR, or to ClientOptions.OnError if onError is nil.
+func (c *Client) SubscribeEventTyped(name string, fn interface{}, onError ErrorCallback) (int, error) {
+	fnValue := reflect.ValueOf(fn)
+	fnType := fnValue.Type()
+	if fnType.Kind() != reflect.Func || fnType.NumIn() != 1 || fnType.NumOut() != 0 {
+		return 0, fmt.Errorf("Event callback must be a func(Data), got %s", fnType)
+	}
+	dataType := fnType.In(0)
+
+	return c.SubscribeEventWithErrors(name, func(data interface{}) {
+		in := reflect.New(dataType)
+		if err := decodeData(data, in.Interface()); err != nil {
+			parseErr := errors.NewDeepstreamError(interfaces.TopicEvent, errors.ErrMessageParseError.Event, err.Error(), name)
+			if onError != nil {
+				onError(parseErr)
+				return
+			}
+			c.reportError(parseErr)
+			return
+		}
+		fnValue.Call([]reflect.Value{in.Elem()})
+	}, onError)
+}
+
+//UnsubscribeEvent removes the subscription with the given id. The server is
+//only told once the last local subscription to the event is removed.
+func (c *Client) UnsubscribeEvent(name string, id int) error {
+	removed, last := c.removeEventSubscription(name, id)
+	if !removed {
+		return fmt.Errorf("Not subscribed to event %s with id %d", name, id)
+	}
+	if !last {
+		return nil
+	}
+	return c.sendEventAction(interfaces.ActionUnsubscribe, name)
+}
+
+func (c *Client) removeEventSubscription(name string, id int) (removed bool, last bool) {
+	c.eventsMu.Lock()
+	defer c.eventsMu.Unlock()
+
+	subs := c.events[name]
+	for i, sub := range subs {
+		if sub.id == id {
+			subs = append(subs[:i], subs[i+1:]...)
+			removed = true
+			break
+		}
+	}
+	if len(subs) == 0 {
+		delete(c.events, name)
+	} else {
+		c.events[name] = subs
+	}
+	return removed, removed && len(subs) == 0
+}
+
+//EmitEvent publishes data to every subscriber of the event, including the local ones
+func (c *Client) EmitEvent(name string, data interface{}) error {
+	normalized, err := encodeData(data)
+	if err != nil {
+		return err
+	}
+	typed, err := message.ConvertTyped(normalized)
+	if err != nil {
+		return err
+	}
+	if err := c.sendEventAction(interfaces.ActionEvent, name, typed); err != nil {
+		return err
+	}
+
+	parsed, err := message.ParseTyped(typed)
+	if err != nil {
+		return err
+	}
+	c.notifyEvent(name, parsed)
+	return nil
+}
+
+func (c *Client) sendEventAction(action string, data ...string) error {
+	msg := &message.Message{
+		Topic:   interfaces.TopicEvent,
+		Action:  action,
+		RawData: data,
+	}
+
+	var act interfaces.Action
+	var err error
+	switch action {
+	case interfaces.ActionSubscribe:
+		act, err = message.NewSubscribeAction(msg)
+	case interfaces.ActionUnsubscribe:
+		act, err = message.NewUnsubscribeAction(msg)
+	case interfaces.ActionEvent:
+		act, err = message.NewEventAction(msg)
+	default:
+		return fmt.Errorf("Unsupported event action %s", action)
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendAction(act)
+}
+
+func (c *Client) handleEvent(msg *message.Message) {
+	switch msg.Action {
+	case interfaces.ActionEvent:
+		if len(msg.RawData) < 1 {
+			c.log(interfaces.LogLevelWarn, "handleEvent: malformed message", messageFields(msg)...)
+			return
+		}
+		var data interface{}
+		if len(msg.RawData) > 1 {
+			var err error
+			if data, err = message.ParseTyped(msg.RawData[1]); err != nil {
+				c.log(interfaces.LogLevelWarn, "handleEvent: invalid data", messageFields(msg, errField(err))...)
+				return
+			}
+		}
+		c.notifyEvent(msg.RawData[0], data)
+	case interfaces.ActionSubscriptionForPatternFound, interfaces.ActionSubscriptionForPatternRemoved:
+		c.handleListen(msg)
+	case interfaces.ActionError:
+		c.notifyEventError(errorSubject(msg), deepstreamError(msg))
+	}
+}
+
+func (c *Client) notifyEvent(name string, data interface{}) {
+	c.eventsMu.Lock()
+	subs := append([]*eventSubscription{}, c.events[name]...)
+	c.eventsMu.Unlock()
+
+	for _, sub := range subs {
+		sub.callback(message.DeepCopy(data))
+	}
+}
+
+func (c *Client) notifyEventError(name string, err *errors.DeepstreamError) {
+	c.eventsMu.Lock()
+	subs := append([]*eventSubscription{}, c.events[name]...)
+	c.eventsMu.Unlock()
+
+	handled := false
+	for _, sub := range subs {
+		if sub.onError != nil {
+			sub.onError(err)
+			handled = true
+		}
+	}
+	if !handled {
+		c.reportError(err)
+	}
+}
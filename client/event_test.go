@@ -0,0 +1,89 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Events", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var cli *client.Client
+		var clientErrors chan *errors.DeepstreamError
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			clientErrors = make(chan *errors.DeepstreamError, 10)
+			cli = loggedInClient(protocol, func(opts *client.ClientOptions) error {
+				opts.OnError = func(err *errors.DeepstreamError) {
+					clientErrors <- err
+				}
+				return nil
+			})
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		Describe("SubscribeEventTyped", func() {
+			It("Should decode the event data", func() {
+				received := make(chan boundPet, 1)
+				_, err := cli.SubscribeEventTyped("pets", func(pet boundPet) {
+					received <- pet
+				}, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol).To(HaveLastSentMessage("E|S|pets"))
+
+				Expect(protocol.DeliverRaw(raw("E", "EVT", "pets", `O{"name":"Max"}`))).To(Succeed())
+				Eventually(received).Should(Receive(Equal(boundPet{Name: "Max"})))
+			})
+
+			It("Should report data that can't be decoded to onError", func() {
+				onError := make(chan *errors.DeepstreamError, 1)
+				_, err := cli.SubscribeEventTyped("pets", func(pet boundPet) {
+					Fail("undecodable data must not reach the callback")
+				}, func(err *errors.DeepstreamError) {
+					onError <- err
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(protocol.DeliverRaw(raw("E", "EVT", "pets", "SMax"))).To(Succeed())
+				var parseErr *errors.DeepstreamError
+				Eventually(onError).Should(Receive(&parseErr))
+				Expect(errors.Is(parseErr, errors.ErrMessageParseError)).To(BeTrue())
+				Expect(parseErr.Topic).To(Equal("E"))
+				Expect(clientErrors).NotTo(Receive())
+			})
+
+			It("Should report data that can't be decoded to OnError without onError", func() {
+				_, err := cli.SubscribeEventTyped("pets", func(pet boundPet) {}, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(protocol.DeliverRaw(raw("E", "EVT", "pets", "SMax"))).To(Succeed())
+				var parseErr *errors.DeepstreamError
+				Eventually(clientErrors).Should(Receive(&parseErr))
+				Expect(errors.Is(parseErr, errors.ErrMessageParseError)).To(BeTrue())
+			})
+
+			It("Should fail for callbacks that don't take the data", func() {
+				_, err := cli.SubscribeEventTyped("pets", func() {}, nil)
+				Expect(err).To(HaveOccurred())
+				_, err = cli.SubscribeEventTyped("pets", "callback", nil)
+				Expect(err).To(HaveOccurred())
+				Expect(protocol).To(HaveSentMessages(0))
+			})
+		})
+	})
+})
@@ -0,0 +1,220 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"fmt"
+	"sync"
+)
+
+//ListCallback is fired with the affected entry and its index in the list
+type ListCallback func(entry string, index int)
+
+//List is a record whose data is an ordered array of record names
+type List struct {
+	Name string
+
+	record    *Record
+	subID     int
+	mu        sync.Mutex
+	entries   []string
+	onAdded   []ListCallback
+	onRemoved []ListCallback
+	onMoved   []ListCallback
+}
+
+//GetList creates or reads the list with the given name and waits for its entries
+func (c *Client) GetList(name string) (*List, error) {
+	rec, err := c.GetRecord(name)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &List{
+		Name:    name,
+		record:  rec,
+		entries: toEntries(rec.Get()),
+	}
+	l.subID = rec.Subscribe("", l.afterChange)
+	return l, nil
+}
+
+func toEntries(data interface{}) []string {
+	items, ok := data.([]interface{})
+	if !ok {
+		return []string{}
+	}
+
+	entries := make([]string, 0, len(items))
+	for _, item := range items {
+		if entry, ok := item.(string); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+//Entries returns the record names in the list
+func (l *List) Entries() []string {
+	return toEntries(l.record.Get())
+}
+
+//IsEmpty returns true if the list has no entries
+func (l *List) IsEmpty() bool {
+	return len(l.Entries()) == 0
+}
+
+//SetEntries replaces all entries in the list
+func (l *List) SetEntries(entries []string) error {
+	return l.record.Set(entries)
+}
+
+//AddEntry inserts entry at index, or appends it if no index is given
+func (l *List) AddEntry(entry string, index ...int) error {
+	entries := l.Entries()
+	position := len(entries)
+	if len(index) > 0 {
+		position = index[0]
+	}
+	if position < 0 || position > len(entries) {
+		return fmt.Errorf("Index %d is out of range for list %s", position, l.Name)
+	}
+
+	entries = append(entries, "")
+	copy(entries[position+1:], entries[position:])
+	entries[position] = entry
+	return l.SetEntries(entries)
+}
+
+//RemoveEntry removes every occurrence of entry, or only the one at index if given
+func (l *List) RemoveEntry(entry string, index ...int) error {
+	entries := l.Entries()
+	remaining := make([]string, 0, len(entries))
+	for i, current := range entries {
+		if current == entry && (len(index) == 0 || index[0] == i) {
+			continue
+		}
+		remaining = append(remaining, current)
+	}
+	return l.SetEntries(remaining)
+}
+
+//OnEntryAdded registers a callback fired when an entry is added to the list
+func (l *List) OnEntryAdded(callback ListCallback) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.onAdded = append(l.onAdded, callback)
+}
+
+//OnEntryRemoved registers a callback fired when an entry is removed from the list
+func (l *List) OnEntryRemoved(callback ListCallback) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.onRemoved = append(l.onRemoved, callback)
+}
+
+//OnEntryMoved registers a callback fired when an entry changes position in the list
+func (l *List) OnEntryMoved(callback ListCallback) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.onMoved = append(l.onMoved, callback)
+}
+
+//OnError registers a callback fired with the errors the server sends about the
+//list. It returns an id for RemoveErrorCallback.
+func (l *List) OnError(callback ErrorCallback) int {
+	return l.record.OnError(callback)
+}
+
+//RemoveErrorCallback removes the error callback with the given id
+func (l *List) RemoveErrorCallback(id int) {
+	l.record.Unsubscribe(id)
+}
+
+//Discard stops receiving updates for the list
+func (l *List) Discard() error {
+	l.record.Unsubscribe(l.subID)
+	return l.record.Discard()
+}
+
+//Delete removes the list from the server
+func (l *List) Delete() error {
+	return l.record.Delete()
+}
+
+func (l *List) afterChange(data interface{}) {
+	after := toEntries(data)
+
+	l.mu.Lock()
+	before := l.entries
+	l.entries = after
+	onAdded := append([]ListCallback{}, l.onAdded...)
+	onRemoved := append([]ListCallback{}, l.onRemoved...)
+	onMoved := append([]ListCallback{}, l.onMoved...)
+	l.mu.Unlock()
+
+	added, removed, moved := diffEntries(before, after)
+	fireListCallbacks(onAdded, added)
+	fireListCallbacks(onRemoved, removed)
+	fireListCallbacks(onMoved, moved)
+}
+
+type listChange struct {
+	entry string
+	index int
+}
+
+func fireListCallbacks(callbacks []ListCallback, changes []listChange) {
+	for _, change := range changes {
+		for _, callback := range callbacks {
+			callback(change.entry, change.index)
+		}
+	}
+}
+
+// diffEntries compares the positions of each entry before and after a change.
+// Occurrences of an entry are matched in order, so duplicates are supported.
+func diffEntries(before, after []string) (added, removed, moved []listChange) {
+	beforePositions := entryPositions(before)
+	afterPositions := entryPositions(after)
+
+	seen := map[string]int{}
+	for index, entry := range after {
+		occurrence := seen[entry]
+		seen[entry]++
+
+		previous := beforePositions[entry]
+		if occurrence >= len(previous) {
+			added = append(added, listChange{entry, index})
+		} else if previous[occurrence] != index {
+			moved = append(moved, listChange{entry, index})
+		}
+	}
+
+	seen = map[string]int{}
+	for index, entry := range before {
+		occurrence := seen[entry]
+		seen[entry]++
+
+		if occurrence >= len(afterPositions[entry]) {
+			removed = append(removed, listChange{entry, index})
+		}
+	}
+	return added, removed, moved
+}
+
+func entryPositions(entries []string) map[string][]int {
+	positions := map[string][]int{}
+	for index, entry := range entries {
+		positions[entry] = append(positions[entry], index)
+	}
+	return positions
+}
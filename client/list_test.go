@@ -0,0 +1,154 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type listChange struct {
+	entry string
+	index int
+}
+
+var _ = Describe("List", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var cli *client.Client
+		var list *client.List
+		var added, removed, moved []listChange
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			cli = loggedInClient(protocol)
+
+			lists := make(chan *client.List, 1)
+			go func() {
+				defer GinkgoRecover()
+				l, err := cli.GetList("todos")
+				Expect(err).NotTo(HaveOccurred())
+				lists <- l
+			}()
+			_, err := protocol.WaitForSent(`^R\|CR\|todos$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(protocol.DeliverRaw(raw("R", "R", "todos", "1", `["a","b","c"]`))).To(Succeed())
+			Eventually(lists).Should(Receive(&list))
+			protocol.ResetSent()
+
+			// local writes fire the callbacks before returning
+			added, removed, moved = nil, nil, nil
+			list.OnEntryAdded(func(entry string, index int) { added = append(added, listChange{entry, index}) })
+			list.OnEntryRemoved(func(entry string, index int) { removed = append(removed, listChange{entry, index}) })
+			list.OnEntryMoved(func(entry string, index int) { moved = append(moved, listChange{entry, index}) })
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		Describe("GetList", func() {
+			It("Should read the entries of the list", func() {
+				Expect(list.Entries()).To(Equal([]string{"a", "b", "c"}))
+				Expect(list.IsEmpty()).To(BeFalse())
+			})
+		})
+
+		Describe("AddEntry", func() {
+			It("Should append entries", func() {
+				Expect(list.AddEntry("d")).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage(`R|U|todos|2|["a","b","c","d"]`))
+				Expect(list.Entries()).To(Equal([]string{"a", "b", "c", "d"}))
+				Expect(added).To(Equal([]listChange{{"d", 3}}))
+				Expect(moved).To(BeEmpty())
+			})
+
+			It("Should insert entries at an index", func() {
+				Expect(list.AddEntry("x", 1)).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage(`R|U|todos|2|["a","x","b","c"]`))
+				Expect(added).To(Equal([]listChange{{"x", 1}}))
+				Expect(moved).To(Equal([]listChange{{"b", 2}, {"c", 3}}))
+				Expect(removed).To(BeEmpty())
+			})
+
+			It("Should fail on indexes out of range", func() {
+				Expect(list.AddEntry("x", 4)).NotTo(Succeed())
+				Expect(list.AddEntry("x", -1)).NotTo(Succeed())
+				Expect(protocol).To(HaveSentMessages(0))
+			})
+
+			It("Should add duplicate entries", func() {
+				Expect(list.AddEntry("a")).To(Succeed())
+				Expect(list.Entries()).To(Equal([]string{"a", "b", "c", "a"}))
+				Expect(added).To(Equal([]listChange{{"a", 3}}))
+				Expect(moved).To(BeEmpty())
+			})
+		})
+
+		Describe("RemoveEntry", func() {
+			It("Should remove entries", func() {
+				Expect(list.RemoveEntry("a")).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage(`R|U|todos|2|["b","c"]`))
+				Expect(removed).To(Equal([]listChange{{"a", 0}}))
+				Expect(moved).To(Equal([]listChange{{"b", 0}, {"c", 1}}))
+				Expect(added).To(BeEmpty())
+			})
+
+			It("Should remove every occurrence of an entry", func() {
+				Expect(list.SetEntries([]string{"a", "b", "a"})).To(Succeed())
+				removed = nil
+				Expect(list.RemoveEntry("a")).To(Succeed())
+				Expect(list.Entries()).To(Equal([]string{"b"}))
+				Expect(removed).To(Equal([]listChange{{"a", 0}, {"a", 2}}))
+			})
+
+			It("Should only remove the occurrence at the index", func() {
+				Expect(list.SetEntries([]string{"a", "b", "a"})).To(Succeed())
+				removed, moved = nil, nil
+				Expect(list.RemoveEntry("a", 2)).To(Succeed())
+				Expect(list.Entries()).To(Equal([]string{"a", "b"}))
+				// occurrences are matched in order, the last one is gone
+				Expect(removed).To(Equal([]listChange{{"a", 2}}))
+				Expect(moved).To(BeEmpty())
+			})
+		})
+
+		Describe("SetEntries", func() {
+			It("Should report moved entries", func() {
+				Expect(list.SetEntries([]string{"c", "b", "a"})).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage(`R|U|todos|2|["c","b","a"]`))
+				Expect(moved).To(Equal([]listChange{{"c", 0}, {"a", 2}}))
+				Expect(added).To(BeEmpty())
+				Expect(removed).To(BeEmpty())
+			})
+
+			It("Should report the changes made by the server", func() {
+				changes := make(chan listChange, 4)
+				list.OnEntryAdded(func(entry string, index int) { changes <- listChange{entry, index} })
+				list.OnEntryRemoved(func(entry string, index int) { changes <- listChange{entry, -1} })
+
+				Expect(protocol.DeliverRaw(raw("R", "U", "todos", "2", `["a","c","d"]`))).To(Succeed())
+				Eventually(changes).Should(Receive(Equal(listChange{"d", 2})))
+				Eventually(changes).Should(Receive(Equal(listChange{"b", -1})))
+				Expect(list.Entries()).To(Equal([]string{"a", "c", "d"}))
+			})
+		})
+
+		Describe("Discard", func() {
+			It("Should stop reporting changes", func() {
+				Expect(list.Discard()).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage("R|US|todos"))
+			})
+		})
+	})
+})
@@ -0,0 +1,151 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+//ListenCallback is called when another client subscribes to a name matching a
+//listened pattern, or with isSubscribed false once nobody is subscribed to it
+//anymore. Returning true for a new match tells the server this client provides
+//it, false rejects it so the server may ask another listener. The return value
+//is ignored for removed matches.
+type ListenCallback func(match string, isSubscribed bool) bool
+
+//ListenEvents calls callback every time a client subscribes to, or unsubscribes
+//from, an event whose name matches pattern
+func (c *Client) ListenEvents(pattern string, callback ListenCallback) error {
+	return c.listen(interfaces.TopicEvent, pattern, callback)
+}
+
+//UnlistenEvents stops listening to events matching pattern
+func (c *Client) UnlistenEvents(pattern string) error {
+	return c.unlisten(interfaces.TopicEvent, pattern)
+}
+
+//ListenRecords calls callback every time a client subscribes to, or discards,
+//a record whose name matches pattern
+func (c *Client) ListenRecords(pattern string, callback ListenCallback) error {
+	return c.listen(interfaces.TopicRecord, pattern, callback)
+}
+
+//UnlistenRecords stops listening to records matching pattern
+func (c *Client) UnlistenRecords(pattern string) error {
+	return c.unlisten(interfaces.TopicRecord, pattern)
+}
+
+func (c *Client) listen(topic, pattern string, callback ListenCallback) error {
+	c.listenersMu.Lock()
+	if _, ok := c.listeners[topic][pattern]; ok {
+		c.listenersMu.Unlock()
+		return fmt.Errorf("Already listening to %s", pattern)
+	}
+	if c.listeners[topic] == nil {
+		c.listeners[topic] = map[string]ListenCallback{}
+	}
+	c.listeners[topic][pattern] = callback
+	c.listenersMu.Unlock()
+
+	if err := c.sendListenAction(topic, interfaces.ActionListen, pattern); err != nil {
+		c.removeListener(topic, pattern)
+		return err
+	}
+	return nil
+}
+
+func (c *Client) unlisten(topic, pattern string) error {
+	if !c.removeListener(topic, pattern) {
+		return fmt.Errorf("Not listening to %s", pattern)
+	}
+	return c.sendListenAction(topic, interfaces.ActionUnlisten, pattern)
+}
+
+func (c *Client) removeListener(topic, pattern string) bool {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+
+	if _, ok := c.listeners[topic][pattern]; !ok {
+		return false
+	}
+	delete(c.listeners[topic], pattern)
+	return true
+}
+
+// listenedPatterns returns the patterns listened to within topic, sorted
+func (c *Client) listenedPatterns(topic string) []string {
+	c.listenersMu.Lock()
+	patterns := make([]string, 0, len(c.listeners[topic]))
+	for pattern := range c.listeners[topic] {
+		patterns = append(patterns, pattern)
+	}
+	c.listenersMu.Unlock()
+
+	sort.Strings(patterns)
+	return patterns
+}
+
+func (c *Client) sendListenAction(topic, action string, data ...string) error {
+	msg := &message.Message{
+		Topic:   topic,
+		Action:  action,
+		RawData: data,
+	}
+
+	var act interfaces.Action
+	var err error
+	switch action {
+	case interfaces.ActionListen:
+		act, err = message.NewListenAction(msg)
+	case interfaces.ActionUnlisten:
+		act, err = message.NewUnlistenAction(msg)
+	case interfaces.ActionListenAccept:
+		act, err = message.NewListenAcceptAction(msg)
+	case interfaces.ActionListenReject:
+		act, err = message.NewListenRejectAction(msg)
+	default:
+		return fmt.Errorf("Unsupported listen action %s", action)
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendAction(act)
+}
+
+// handleListen hands E|SP|pattern|match or E|SR|pattern|match to the listener
+// of pattern, answering found matches with E|LA or E|LR
+func (c *Client) handleListen(msg *message.Message) {
+	if len(msg.RawData) < 2 {
+		c.log(interfaces.LogLevelWarn, "handleListen: malformed message", messageFields(msg)...)
+		return
+	}
+	pattern, match := msg.RawData[0], msg.RawData[1]
+
+	c.listenersMu.Lock()
+	callback, ok := c.listeners[msg.Topic][pattern]
+	c.listenersMu.Unlock()
+	if !ok {
+		return
+	}
+
+	if msg.Action == interfaces.ActionSubscriptionForPatternRemoved {
+		callback(match, false)
+		return
+	}
+	action := interfaces.ActionListenReject
+	if callback(match, true) {
+		action = interfaces.ActionListenAccept
+	}
+	if err := c.sendListenAction(msg.Topic, action, pattern, match); err != nil {
+		c.log(interfaces.LogLevelWarn, "handleListen: could not answer", messageFields(msg, errField(err))...)
+	}
+}
@@ -0,0 +1,107 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type listenMatch struct {
+	match        string
+	isSubscribed bool
+}
+
+var _ = Describe("Listen", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var cli *client.Client
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			cli = loggedInClient(protocol)
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		Describe("ListenEvents", func() {
+			It("Should accept the matches the callback provides", func() {
+				matches := make(chan listenMatch, 2)
+				Expect(cli.ListenEvents("event/.*", func(match string, isSubscribed bool) bool {
+					matches <- listenMatch{match, isSubscribed}
+					return true
+				})).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage("E|L|event/.*"))
+
+				Expect(protocol.DeliverRaw(raw("E", "A", "L", "event/.*"), raw("E", "SP", "event/.*", "event/a"))).To(Succeed())
+				Eventually(matches).Should(Receive(Equal(listenMatch{"event/a", true})))
+				_, err := protocol.WaitForSent(`^E\|LA\|event/\.\*\|event/a$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(protocol.DeliverRaw(raw("E", "SR", "event/.*", "event/a"))).To(Succeed())
+				Eventually(matches).Should(Receive(Equal(listenMatch{"event/a", false})))
+				Consistently(func() int { return len(protocol.Sent()) }, 50*time.Millisecond).Should(Equal(2))
+			})
+
+			It("Should reject the matches the callback doesn't provide", func() {
+				Expect(cli.ListenEvents("event/.*", func(string, bool) bool { return false })).To(Succeed())
+				Expect(protocol.DeliverRaw(raw("E", "SP", "event/.*", "event/a"))).To(Succeed())
+				_, err := protocol.WaitForSent(`^E\|LR\|event/\.\*\|event/a$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Should only listen once to a pattern", func() {
+				Expect(cli.ListenEvents("event/.*", func(string, bool) bool { return true })).To(Succeed())
+				Expect(cli.ListenEvents("event/.*", func(string, bool) bool { return true })).NotTo(Succeed())
+				Expect(protocol).To(HaveSentMessages(1))
+			})
+		})
+
+		Describe("UnlistenEvents", func() {
+			It("Should stop listening to the pattern", func() {
+				matches := make(chan listenMatch, 1)
+				Expect(cli.ListenEvents("event/.*", func(match string, isSubscribed bool) bool {
+					matches <- listenMatch{match, isSubscribed}
+					return true
+				})).To(Succeed())
+				Expect(cli.UnlistenEvents("event/.*")).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage("E|UL|event/.*"))
+				Expect(cli.UnlistenEvents("event/.*")).NotTo(Succeed())
+
+				Expect(protocol.DeliverRaw(raw("E", "SP", "event/.*", "event/a"))).To(Succeed())
+				Consistently(matches, 50*time.Millisecond).ShouldNot(Receive())
+			})
+		})
+
+		Describe("ListenRecords", func() {
+			It("Should accept the records the callback provides", func() {
+				matches := make(chan listenMatch, 1)
+				Expect(cli.ListenRecords("user/.*", func(match string, isSubscribed bool) bool {
+					matches <- listenMatch{match, isSubscribed}
+					return true
+				})).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage("R|L|user/.*"))
+
+				Expect(protocol.DeliverRaw(raw("R", "SP", "user/.*", "user/Lisa"))).To(Succeed())
+				Eventually(matches).Should(Receive(Equal(listenMatch{"user/Lisa", true})))
+				_, err := protocol.WaitForSent(`^R\|LA\|user/\.\*\|user/Lisa$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cli.UnlistenRecords("user/.*")).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage("R|UL|user/.*"))
+			})
+		})
+	})
+})
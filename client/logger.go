@@ -0,0 +1,100 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+var levelNames = map[int]string{
+	interfaces.LogLevelDebug: "DEBUG",
+	interfaces.LogLevelInfo:  "INFO",
+	interfaces.LogLevelWarn:  "WARN",
+	interfaces.LogLevelError: "ERROR",
+}
+
+//StdLogger writes logs at or above Level with a standard library logger,
+//fields are appended as key=value
+type StdLogger struct {
+	Logger *log.Logger
+	Level  int
+}
+
+//NewStdLogger returns a logger writing the logs at or above level to logger
+func NewStdLogger(logger *log.Logger, level int) *StdLogger {
+	return &StdLogger{Logger: logger, Level: level}
+}
+
+//Log writes msg if level is at or above l.Level
+func (l *StdLogger) Log(level int, msg string, fields ...interfaces.LogField) {
+	if level < l.Level || l.Level >= interfaces.LogLevelOff {
+		return
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[%s] %s", levelNames[level], msg)
+	for _, field := range fields {
+		fmt.Fprintf(&buf, " %s=%v", field.Key, field.Value)
+	}
+	l.Logger.Println(buf.String())
+}
+
+//LoggerFunc adapts a function, e.g. a closure over a logrus or zap logger, to Logger
+type LoggerFunc func(level int, msg string, fields ...interfaces.LogField)
+
+//Log calls f
+func (f LoggerFunc) Log(level int, msg string, fields ...interfaces.LogField) {
+	f(level, msg, fields...)
+}
+
+//NopLogger discards every log
+type NopLogger struct{}
+
+//Log does nothing
+func (NopLogger) Log(level int, msg string, fields ...interfaces.LogField) {}
+
+//WithLogger sets the logger of the client, use NopLogger to silence it
+func WithLogger(logger interfaces.Logger) ClientOption {
+	return func(opts *ClientOptions) error {
+		opts.Logger = logger
+		return nil
+	}
+}
+
+var defaultLogger = NewStdLogger(log.New(os.Stderr, "", log.LstdFlags), interfaces.LogLevelInfo)
+
+// log writes msg with the url and state of the client
+func (c *Client) log(level int, msg string, fields ...interfaces.LogField) {
+	logger := c.Options.Logger
+	if logger == nil {
+		logger = defaultLogger
+	}
+	fields = append([]interfaces.LogField{
+		{Key: "url", Value: c.URL},
+		{Key: "state", Value: c.State()},
+	}, fields...)
+	logger.Log(level, msg, fields...)
+}
+
+// messageFields describes msg for the logs
+func messageFields(msg *message.Message, fields ...interfaces.LogField) []interfaces.LogField {
+	return append([]interfaces.LogField{
+		{Key: "topic", Value: msg.Topic},
+		{Key: "action", Value: msg.Action},
+		{Key: "message", Value: msg.String()},
+	}, fields...)
+}
+
+func errField(err error) interfaces.LogField {
+	return interfaces.LogField{Key: "error", Value: err}
+}
@@ -0,0 +1,46 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+//go:build go1.21
+// +build go1.21
+
+// log/slog requires Go 1.21, older toolchains build the client without SlogLogger
+
+package client
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+)
+
+//SlogLogger writes logs with a log/slog logger, fields become attributes
+type SlogLogger struct {
+	Logger *slog.Logger
+}
+
+//NewSlogLogger returns a logger writing to logger
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{Logger: logger}
+}
+
+var slogLevels = map[int]slog.Level{
+	interfaces.LogLevelDebug: slog.LevelDebug,
+	interfaces.LogLevelInfo:  slog.LevelInfo,
+	interfaces.LogLevelWarn:  slog.LevelWarn,
+	interfaces.LogLevelError: slog.LevelError,
+}
+
+//Log writes msg with fields as attributes
+func (l *SlogLogger) Log(level int, msg string, fields ...interfaces.LogField) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
+	}
+	l.Logger.LogAttrs(context.Background(), slogLevels[level], msg, attrs...)
+}
@@ -0,0 +1,44 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+//go:build go1.21
+// +build go1.21
+
+package client_test
+
+import (
+	"bytes"
+	"log/slog"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SlogLogger", func() {
+	Describe("[Unit]", func() {
+		It("Should write the logs with their level and fields as attributes", func() {
+			var buf bytes.Buffer
+			handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				Level: slog.LevelInfo,
+				ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+					if attr.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return attr
+				},
+			})
+			logger := client.NewSlogLogger(slog.New(handler))
+
+			logger.Log(interfaces.LogLevelDebug, "hidden")
+			logger.Log(interfaces.LogLevelWarn, "reconnecting", interfaces.LogField{Key: "attempt", Value: 2})
+			logger.Log(interfaces.LogLevelError, "failed", interfaces.LogField{Key: "rpc", Value: "toUppercase"})
+			Expect(buf.String()).To(Equal("level=WARN msg=reconnecting attempt=2\nlevel=ERROR msg=failed rpc=toUppercase\n"))
+		})
+	})
+})
@@ -0,0 +1,84 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"bytes"
+	"log"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type logEntry struct {
+	level  int
+	msg    string
+	fields []interfaces.LogField
+}
+
+var _ = Describe("Loggers", func() {
+	Describe("[Unit]", func() {
+		Describe("StdLogger", func() {
+			It("Should write the logs at or above its level with their fields", func() {
+				var buf bytes.Buffer
+				logger := client.NewStdLogger(log.New(&buf, "", 0), interfaces.LogLevelWarn)
+
+				logger.Log(interfaces.LogLevelInfo, "connected")
+				logger.Log(interfaces.LogLevelWarn, "reconnecting", interfaces.LogField{Key: "attempt", Value: 2})
+				logger.Log(interfaces.LogLevelError, "failed")
+				Expect(buf.String()).To(Equal("[WARN] reconnecting attempt=2\n[ERROR] failed\n"))
+			})
+
+			It("Should write nothing when off", func() {
+				var buf bytes.Buffer
+				logger := client.NewStdLogger(log.New(&buf, "", 0), interfaces.LogLevelOff)
+
+				logger.Log(interfaces.LogLevelError, "failed")
+				Expect(buf.String()).To(BeEmpty())
+			})
+		})
+
+		Describe("LoggerFunc", func() {
+			It("Should receive the logs of the client with its url and state", func() {
+				entries := make(chan logEntry, 100)
+				logger := client.LoggerFunc(func(level int, msg string, fields ...interfaces.LogField) {
+					entries <- logEntry{level, msg, fields}
+				})
+				protocol := testing.NewMockProtocol()
+				cli := loggedInClient(protocol, client.WithLogger(logger))
+				defer cli.Close()
+
+				// an invalid answer to a has request is logged
+				Expect(protocol.DeliverRaw(raw("R", "H", "user/Lisa", "X"))).To(Succeed())
+				// skip the logs of the login
+				var entry logEntry
+				Eventually(func() string {
+					select {
+					case entry = <-entries:
+					default:
+					}
+					return entry.msg
+				}).Should(Equal("handleRecord: invalid data"))
+				Expect(entry.level).To(Equal(interfaces.LogLevelWarn))
+				Expect(entry.fields[0]).To(Equal(interfaces.LogField{Key: "url", Value: cli.URL}))
+				Expect(entry.fields[1].Key).To(Equal("state"))
+			})
+		})
+
+		Describe("NopLogger", func() {
+			It("Should discard the logs", func() {
+				Expect(func() {
+					client.NopLogger{}.Log(interfaces.LogLevelError, "failed", interfaces.LogField{Key: "error", Value: nil})
+				}).NotTo(Panic())
+			})
+		})
+	})
+})
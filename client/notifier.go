@@ -0,0 +1,90 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+)
+
+// singleNotifier tracks requests the server answers once per name, e.g. record
+// has or snapshot requests: concurrent requests for the same name share a single
+// message to the server and its response
+type singleNotifier struct {
+	mu      sync.Mutex
+	pending map[string][]chan notifierResult
+}
+
+type notifierResult struct {
+	data interface{}
+	err  error
+}
+
+func newSingleNotifier() *singleNotifier {
+	return &singleNotifier{pending: map[string][]chan notifierResult{}}
+}
+
+// request waits up to timeout for the response for name, send is only called
+// when no other request for name is pending
+func (n *singleNotifier) request(c *Client, name string, timeout time.Duration, send func() error) (interface{}, error) {
+	result := make(chan notifierResult, 1)
+	n.mu.Lock()
+	first := len(n.pending[name]) == 0
+	n.pending[name] = append(n.pending[name], result)
+	n.mu.Unlock()
+
+	if first {
+		if err := send(); err != nil {
+			n.resolve(name, nil, err)
+		}
+	}
+
+	timer := c.clock().NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-result:
+		return res.data, res.err
+	case <-timer.C():
+		n.remove(name, result)
+		return nil, errors.ErrResponseTimeout
+	}
+}
+
+// resolve hands the response for name to every pending request and returns
+// false if there was none
+func (n *singleNotifier) resolve(name string, data interface{}, err error) bool {
+	n.mu.Lock()
+	pending := n.pending[name]
+	delete(n.pending, name)
+	n.mu.Unlock()
+
+	for _, result := range pending {
+		result <- notifierResult{data: data, err: err}
+	}
+	return len(pending) > 0
+}
+
+func (n *singleNotifier) remove(name string, result chan notifierResult) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	pending := n.pending[name]
+	for i, r := range pending {
+		if r == result {
+			pending = append(pending[:i], pending[i+1:]...)
+			break
+		}
+	}
+	if len(pending) == 0 {
+		delete(n.pending, name)
+	} else {
+		n.pending[name] = pending
+	}
+}
@@ -0,0 +1,133 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"fmt"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+//PresenceCallback is called with the username of a client logging in, or out
+//when loggedIn is false
+type PresenceCallback func(username string, loggedIn bool)
+
+type presenceSubscription struct {
+	id       int
+	callback PresenceCallback
+}
+
+//SubscribePresence registers a callback fired every time another client logs
+//in or out. The server is only told about the first local subscription.
+//It returns an id for UnsubscribePresence.
+func (c *Client) SubscribePresence(callback PresenceCallback) (int, error) {
+	c.presenceMu.Lock()
+	c.lastPresenceID++
+	sub := &presenceSubscription{id: c.lastPresenceID, callback: callback}
+	first := len(c.presence) == 0
+	c.presence = append(c.presence, sub)
+	c.presenceMu.Unlock()
+
+	if first {
+		if err := c.sendPresenceAction(interfaces.ActionSubscribe, interfaces.ActionSubscribe); err != nil {
+			c.removePresenceSubscription(sub.id)
+			return 0, err
+		}
+	}
+	return sub.id, nil
+}
+
+//UnsubscribePresence removes the subscription with the given id. The server is
+//only told once the last local subscription is removed.
+func (c *Client) UnsubscribePresence(id int) error {
+	removed, last := c.removePresenceSubscription(id)
+	if !removed {
+		return fmt.Errorf("Not subscribed to presence with id %d", id)
+	}
+	if !last {
+		return nil
+	}
+	return c.sendPresenceAction(interfaces.ActionUnsubscribe, interfaces.ActionUnsubscribe)
+}
+
+func (c *Client) removePresenceSubscription(id int) (removed bool, last bool) {
+	c.presenceMu.Lock()
+	defer c.presenceMu.Unlock()
+
+	for i, sub := range c.presence {
+		if sub.id == id {
+			c.presence = append(c.presence[:i], c.presence[i+1:]...)
+			return true, len(c.presence) == 0
+		}
+	}
+	return false, false
+}
+
+//QueryPresence returns the usernames of the other clients logged in to the server.
+//It fails with ErrResponseTimeout if the server doesn't answer within PresenceQueryTimeout.
+func (c *Client) QueryPresence() ([]string, error) {
+	data, err := c.presenceQueries.request(c, "", c.Options.PresenceQueryTimeout, func() error {
+		return c.sendPresenceAction(interfaces.ActionQuery, interfaces.ActionQuery)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data.([]string), nil
+}
+
+func (c *Client) sendPresenceAction(action string, data ...string) error {
+	msg := &message.Message{
+		Topic:   interfaces.TopicPresence,
+		Action:  action,
+		RawData: data,
+	}
+
+	var act interfaces.Action
+	var err error
+	switch action {
+	case interfaces.ActionSubscribe:
+		act, err = message.NewSubscribeAction(msg)
+	case interfaces.ActionUnsubscribe:
+		act, err = message.NewUnsubscribeAction(msg)
+	case interfaces.ActionQuery:
+		act, err = message.NewQueryAction(msg)
+	default:
+		return fmt.Errorf("Unsupported presence action %s", action)
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendAction(act)
+}
+
+func (c *Client) handlePresence(msg *message.Message) {
+	switch msg.Action {
+	case interfaces.ActionPresenceJoin, interfaces.ActionPresenceLeave:
+		if len(msg.RawData) < 1 {
+			c.log(interfaces.LogLevelWarn, "handlePresence: malformed message", messageFields(msg)...)
+			return
+		}
+		c.notifyPresence(msg.RawData[0], msg.Action == interfaces.ActionPresenceJoin)
+	case interfaces.ActionQuery:
+		usernames := append([]string{}, msg.RawData...)
+		c.presenceQueries.resolve("", usernames, nil)
+	case interfaces.ActionError:
+		c.reportError(deepstreamError(msg))
+	}
+}
+
+func (c *Client) notifyPresence(username string, loggedIn bool) {
+	c.presenceMu.Lock()
+	subs := append([]*presenceSubscription{}, c.presence...)
+	c.presenceMu.Unlock()
+
+	for _, sub := range subs {
+		sub.callback(username, loggedIn)
+	}
+}
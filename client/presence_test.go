@@ -0,0 +1,125 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type presenceChange struct {
+	username string
+	loggedIn bool
+}
+
+var _ = Describe("Presence", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var clock *testing.FakeClock
+		var cli *client.Client
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			clock = testing.NewFakeClock()
+			cli = loggedInClient(protocol, client.WithClock(clock))
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		Describe("SubscribePresence", func() {
+			It("Should notify the clients logging in and out", func() {
+				changes := make(chan presenceChange, 2)
+				_, err := cli.SubscribePresence(func(username string, loggedIn bool) {
+					changes <- presenceChange{username, loggedIn}
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol).To(HaveLastSentMessage("U|S|S"))
+
+				Expect(protocol.DeliverRaw(raw("U", "A", "S", "U"), raw("U", "PNJ", "Homer"), raw("U", "PNL", "Marge"))).To(Succeed())
+				Eventually(changes).Should(Receive(Equal(presenceChange{"Homer", true})))
+				Eventually(changes).Should(Receive(Equal(presenceChange{"Marge", false})))
+			})
+
+			It("Should only tell the server about the first and last subscriptions", func() {
+				first, err := cli.SubscribePresence(func(string, bool) {})
+				Expect(err).NotTo(HaveOccurred())
+				changes := make(chan presenceChange, 1)
+				second, err := cli.SubscribePresence(func(username string, loggedIn bool) {
+					changes <- presenceChange{username, loggedIn}
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol).To(HaveSentMessages(1))
+
+				Expect(cli.UnsubscribePresence(first)).To(Succeed())
+				Expect(protocol).To(HaveSentMessages(1))
+				Expect(protocol.DeliverRaw(raw("U", "PNJ", "Homer"))).To(Succeed())
+				Eventually(changes).Should(Receive(Equal(presenceChange{"Homer", true})))
+
+				Expect(cli.UnsubscribePresence(second)).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage("U|US|US"))
+				Expect(cli.UnsubscribePresence(second)).NotTo(Succeed())
+			})
+		})
+
+		Describe("QueryPresence", func() {
+			It("Should list the connected clients", func() {
+				results := make(chan []string, 2)
+				for i := 0; i < 2; i++ {
+					go func() {
+						defer GinkgoRecover()
+						usernames, err := cli.QueryPresence()
+						Expect(err).NotTo(HaveOccurred())
+						results <- usernames
+					}()
+				}
+				Expect(clock.WaitForTimers(2, time.Second)).To(Succeed())
+				// concurrent queries share the request
+				Expect(protocol).To(HaveSentMessages(1))
+				Expect(protocol).To(HaveLastSentMessage("U|Q|Q"))
+
+				Expect(protocol.DeliverRaw(raw("U", "Q", "Homer", "Marge"))).To(Succeed())
+				Eventually(results).Should(Receive(Equal([]string{"Homer", "Marge"})))
+				Eventually(results).Should(Receive(Equal([]string{"Homer", "Marge"})))
+			})
+
+			It("Should list no clients", func() {
+				result := make(chan []string, 1)
+				go func() {
+					defer GinkgoRecover()
+					usernames, err := cli.QueryPresence()
+					Expect(err).NotTo(HaveOccurred())
+					result <- usernames
+				}()
+				_, err := protocol.WaitForSent(`^U\|Q\|Q$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(protocol.DeliverRaw(raw("U", "Q"))).To(Succeed())
+				Eventually(result).Should(Receive(BeEmpty()))
+			})
+
+			It("Should time out when the server doesn't answer", func() {
+				result := make(chan error, 1)
+				go func() {
+					_, err := cli.QueryPresence()
+					result <- err
+				}()
+				Expect(clock.WaitForTimers(1, time.Second)).To(Succeed())
+				clock.Advance(cli.Options.PresenceQueryTimeout)
+				Eventually(result).Should(Receive(MatchError(errors.ErrResponseTimeout)))
+			})
+		})
+	})
+})
@@ -0,0 +1,670 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+// writeSuccessConfig asks the server to acknowledge a record write with R|WA
+const writeSuccessConfig = `{"writeSuccess":true}`
+
+var errVersionConflict = errors.NewDeepstreamError(interfaces.TopicRecord, errors.ErrVersionExists.Event, "record version conflict", "")
+
+//Record is a document kept in sync with deepstream.io
+type Record struct {
+	Name string
+
+	client        *Client
+	mu            sync.RWMutex
+	version       int
+	data          interface{}
+	isReady       bool
+	ready         chan struct{}
+	subscriptions []*recordSubscription
+	errorSubs     []*recordErrorSubscription
+	lastSubID     int
+	updateMu      sync.Mutex
+	pendingWrites map[int]chan error
+	refs          int
+}
+
+type recordSubscription struct {
+	id       int
+	path     string
+	callback func(data interface{})
+}
+
+type recordErrorSubscription struct {
+	id       int
+	callback ErrorCallback
+}
+
+func newRecord(c *Client, name string) *Record {
+	return &Record{
+		Name:          name,
+		client:        c,
+		data:          map[string]interface{}{},
+		ready:         make(chan struct{}),
+		pendingWrites: map[int]chan error{},
+	}
+}
+
+//GetRecord creates or reads the record with the given name and waits for its data.
+//Records are shared: every call returns the same instance and must be paired with a Discard.
+func (c *Client) GetRecord(name string) (*Record, error) {
+	c.recordsMu.Lock()
+	if rec, ok := c.records[name]; ok {
+		rec.refs++
+		c.recordsMu.Unlock()
+		if err := rec.whenReady(); err != nil {
+			c.discardUnread(rec)
+			return nil, err
+		}
+		return rec, nil
+	}
+	rec := newRecord(c, name)
+	rec.refs = 1
+	c.records[name] = rec
+	c.recordsMu.Unlock()
+
+	if err := c.sendRecordAction(interfaces.ActionCreateOrRead, name); err != nil {
+		c.removeRecord(rec)
+		return nil, err
+	}
+
+	if err := rec.whenReady(); err != nil {
+		c.discardUnread(rec)
+		return nil, err
+	}
+	return rec, nil
+}
+
+// discardUnread releases a reference to a record whose data never came, the
+// server still subscribed the client to it when it was the last one
+func (c *Client) discardUnread(rec *Record) {
+	if err := rec.Discard(); err != nil {
+		c.log(interfaces.LogLevelWarn, "GetRecord: could not unsubscribe", interfaces.LogField{Key: "record", Value: rec.Name}, errField(err))
+	}
+}
+
+func (c *Client) removeRecord(rec *Record) {
+	c.recordsMu.Lock()
+	defer c.recordsMu.Unlock()
+
+	if c.records[rec.Name] == rec {
+		delete(c.records, rec.Name)
+	}
+}
+
+// releaseRecord drops a reference to rec and returns true if it was the last one
+func (c *Client) releaseRecord(rec *Record) bool {
+	c.recordsMu.Lock()
+	defer c.recordsMu.Unlock()
+
+	if c.records[rec.Name] != rec {
+		return false
+	}
+	rec.refs--
+	if rec.refs > 0 {
+		return false
+	}
+	delete(c.records, rec.Name)
+	return true
+}
+
+func (c *Client) sendRecordAction(action string, data ...string) error {
+	msg := &message.Message{
+		Topic:   interfaces.TopicRecord,
+		Action:  action,
+		RawData: data,
+	}
+
+	var act interfaces.Action
+	var err error
+	switch action {
+	case interfaces.ActionCreateOrRead:
+		act, err = message.NewCreateOrReadAction(msg)
+	case interfaces.ActionUpdate:
+		act, err = message.NewUpdateAction(msg)
+	case interfaces.ActionPatch:
+		act, err = message.NewPathAction(msg)
+	case interfaces.ActionUnsubscribe:
+		act, err = message.NewUnsubscribeAction(msg)
+	case interfaces.ActionDelete:
+		act, err = message.NewDeleteAction(msg)
+	case interfaces.ActionHas:
+		act, err = message.NewHasAction(msg)
+	case interfaces.ActionSnapshot:
+		act, err = message.NewSnapshotAction(msg)
+	default:
+		return fmt.Errorf("Unsupported record action %s", action)
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendAction(act)
+}
+
+func (c *Client) handleRecord(msg *message.Message) {
+	if len(msg.RawData) < 2 {
+		c.log(interfaces.LogLevelWarn, "handleRecord: malformed message", messageFields(msg)...)
+		return
+	}
+
+	switch msg.Action {
+	case interfaces.ActionAck:
+		if msg.RawData[0] == interfaces.ActionDelete {
+			if rec := c.lookupRecord(msg.RawData[1]); rec != nil {
+				c.removeRecord(rec)
+			}
+		}
+		return
+	case interfaces.ActionError:
+		c.handleRecordError(msg)
+		return
+	case interfaces.ActionWriteAcknowledgement:
+		c.handleWriteAck(msg)
+		return
+	case interfaces.ActionHas:
+		c.handleHas(msg)
+		return
+	case interfaces.ActionSubscriptionForPatternFound, interfaces.ActionSubscriptionForPatternRemoved:
+		c.handleListen(msg)
+		return
+	case interfaces.ActionRead:
+		c.resolveSnapshot(msg)
+	}
+
+	rec := c.lookupRecord(msg.RawData[0])
+	if rec == nil {
+		return
+	}
+	version, err := strconv.Atoi(msg.RawData[1])
+	if err != nil {
+		c.log(interfaces.LogLevelWarn, "handleRecord: invalid version", messageFields(msg)...)
+		return
+	}
+
+	switch msg.Action {
+	case interfaces.ActionRead, interfaces.ActionUpdate:
+		if len(msg.RawData) < 3 {
+			c.log(interfaces.LogLevelWarn, "handleRecord: malformed message", messageFields(msg)...)
+			return
+		}
+		var data interface{}
+		if err := json.Unmarshal([]byte(msg.RawData[2]), &data); err != nil {
+			c.log(interfaces.LogLevelWarn, "handleRecord: invalid data", messageFields(msg, errField(err))...)
+			return
+		}
+		rec.apply(version, "", data)
+	case interfaces.ActionPatch:
+		if len(msg.RawData) < 4 {
+			c.log(interfaces.LogLevelWarn, "handleRecord: malformed message", messageFields(msg)...)
+			return
+		}
+		value, err := message.ParseTyped(msg.RawData[3])
+		if err != nil {
+			c.log(interfaces.LogLevelWarn, "handleRecord: invalid data", messageFields(msg, errField(err))...)
+			return
+		}
+		if err := rec.apply(version, msg.RawData[2], value); err != nil {
+			c.log(interfaces.LogLevelWarn, "handleRecord: invalid path", messageFields(msg, errField(err))...)
+		}
+	}
+}
+
+func (c *Client) handleRecordError(msg *message.Message) {
+	if msg.RawData[0] == interfaces.ActionHas || msg.RawData[0] == interfaces.ActionSnapshot {
+		if !c.handleQueryError(msg) {
+			c.reportError(deepstreamError(msg))
+		}
+		return
+	}
+	if msg.RawData[0] != errors.ErrVersionExists.Event || len(msg.RawData) < 4 {
+		err := deepstreamError(msg)
+		if rec := c.lookupRecord(errorSubject(msg)); rec == nil || !rec.notifyError(err) {
+			c.reportError(err)
+		}
+		return
+	}
+
+	rec := c.lookupRecord(msg.RawData[1])
+	if rec == nil {
+		return
+	}
+	version, err := strconv.Atoi(msg.RawData[2])
+	if err != nil {
+		c.log(interfaces.LogLevelWarn, "handleRecord: invalid version", messageFields(msg)...)
+		return
+	}
+	var data interface{}
+	if err := json.Unmarshal([]byte(msg.RawData[3]), &data); err != nil {
+		c.log(interfaces.LogLevelWarn, "handleRecord: invalid data", messageFields(msg, errField(err))...)
+		return
+	}
+
+	// the remote version wins, pending updates are retried on top of it
+	rec.apply(version, "", data)
+	rec.resolveWrites(func(v int) bool { return v <= version }, errVersionConflict)
+}
+
+func (c *Client) handleWriteAck(msg *message.Message) {
+	if len(msg.RawData) < 3 {
+		c.log(interfaces.LogLevelWarn, "handleRecord: malformed message", messageFields(msg)...)
+		return
+	}
+
+	rec := c.lookupRecord(msg.RawData[0])
+	if rec == nil {
+		return
+	}
+	var versions []int
+	if err := json.Unmarshal([]byte(msg.RawData[1]), &versions); err != nil {
+		c.log(interfaces.LogLevelWarn, "handleRecord: invalid versions", messageFields(msg)...)
+		return
+	}
+	var writeErr error
+	if reason, err := message.ParseTyped(msg.RawData[2]); err != nil {
+		writeErr = err
+	} else if reason != nil {
+		writeErr = errors.NewDeepstreamError(interfaces.TopicRecord, errors.ErrRecordUpdateError.Event, fmt.Sprintf("Record %s could not be written: %v", rec.Name, reason), msg.Raw)
+	}
+
+	acked := map[int]bool{}
+	for _, v := range versions {
+		acked[v] = true
+	}
+	rec.resolveWrites(func(v int) bool { return acked[v] }, writeErr)
+}
+
+func (c *Client) lookupRecord(name string) *Record {
+	c.recordsMu.Lock()
+	defer c.recordsMu.Unlock()
+
+	return c.records[name]
+}
+
+func (r *Record) whenReady() error {
+	select {
+	case <-r.ready:
+		return nil
+	case <-r.client.clock().After(r.client.Options.RecordReadTimeout):
+		return errors.NewDeepstreamError(interfaces.TopicRecord, errors.ErrResponseTimeout.Event, fmt.Sprintf("Record %s could not be read within %s", r.Name, r.client.Options.RecordReadTimeout), "")
+	}
+}
+
+// apply stores value at path (the whole record if path is empty) and
+// notifies the subscriptions whose value changed
+func (r *Record) apply(version int, path string, value interface{}) error {
+	r.mu.Lock()
+	old := r.data
+	if path == "" {
+		r.data = value
+	} else {
+		data, err := message.SetPath(old, path, value)
+		if err != nil {
+			r.mu.Unlock()
+			return err
+		}
+		r.data = data
+	}
+	r.version = version
+	if !r.isReady {
+		r.isReady = true
+		close(r.ready)
+	}
+	current := r.data
+	subscriptions := append([]*recordSubscription{}, r.subscriptions...)
+	r.mu.Unlock()
+
+	notifySubscriptions(subscriptions, old, current)
+	return nil
+}
+
+func notifySubscriptions(subscriptions []*recordSubscription, old, current interface{}) {
+	for _, sub := range subscriptions {
+		before := message.GetPath(old, sub.path)
+		after := message.GetPath(current, sub.path)
+		if !reflect.DeepEqual(before, after) {
+			sub.callback(message.DeepCopy(after))
+		}
+	}
+}
+
+//Version returns the current version of the record
+func (r *Record) Version() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.version
+}
+
+//Get returns a copy of the whole record data
+func (r *Record) Get() interface{} {
+	return r.GetPath("")
+}
+
+//GetPath returns a copy of the value stored at path, e.g. "pets[0].name"
+func (r *Record) GetPath(path string) interface{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return message.DeepCopy(message.GetPath(r.data, path))
+}
+
+//Set replaces the whole record data. Structs are encoded using their ds or json
+//tags and only the paths that changed since the last known value are sent.
+func (r *Record) Set(data interface{}) error {
+	return r.set("", data)
+}
+
+//SetPath replaces the value stored at path, leaving the rest of the record untouched
+func (r *Record) SetPath(path string, value interface{}) error {
+	return r.set(path, value)
+}
+
+//SetWithAck is like Set but waits for the server to acknowledge the write was
+//stored, failing with the storage or cache error the server reports
+func (r *Record) SetWithAck(data interface{}) error {
+	return r.setWithAck("", data)
+}
+
+//SetPathWithAck is like SetPath but waits for the server to acknowledge the write
+func (r *Record) SetPathWithAck(path string, value interface{}) error {
+	return r.setWithAck(path, value)
+}
+
+func (r *Record) setWithAck(path string, value interface{}) error {
+	versions, ack, err := r.write(path, value, true)
+	written := map[int]bool{}
+	for _, v := range versions {
+		written[v] = true
+	}
+	// forget the writes still pending once done waiting
+	defer r.resolveWrites(func(v int) bool { return written[v] }, nil)
+	if err != nil {
+		return err
+	}
+
+	timeout := r.client.clock().After(r.client.Options.RecordWriteAckTimeout)
+	for range versions {
+		select {
+		case err = <-ack:
+			if err != nil {
+				return err
+			}
+		case <-timeout:
+			return errors.NewDeepstreamError(interfaces.TopicRecord, errors.ErrAckTimeout.Event, fmt.Sprintf("Write of record %s was not acknowledged within %s", r.Name, r.client.Options.RecordWriteAckTimeout), "")
+		}
+	}
+	return nil
+}
+
+func (r *Record) set(path string, value interface{}) error {
+	_, _, err := r.write(path, value, false)
+	return err
+}
+
+// write sends value and returns the versions written. With writeAck the server is
+// asked to acknowledge the writes, which are resolved on the returned channel.
+func (r *Record) write(path string, value interface{}, writeAck bool) ([]int, chan error, error) {
+	normalized, err := encodeData(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.writeData(path, normalized, path == "" && isStruct(value), writeAck)
+}
+
+// writeData sends data already normalized by encodeData, with diff only the
+// paths that changed are sent
+func (r *Record) writeData(path string, normalized interface{}, diff bool, writeAck bool) ([]int, chan error, error) {
+	var err error
+	r.mu.Lock()
+	old := r.data
+	patches := []recordPatch{{path: path, value: normalized}}
+	if diff {
+		if changed, ok := diffData(old, normalized, ""); ok {
+			patches = changed
+		}
+	}
+	var ack chan error
+	if writeAck {
+		ack = make(chan error, len(patches))
+	}
+	var versions []int
+	for _, patch := range patches {
+		if writeAck {
+			r.pendingWrites[r.version+1] = ack
+		}
+		if err = r.sendPatch(patch, writeAck); err != nil {
+			delete(r.pendingWrites, r.version+1)
+			break
+		}
+		versions = append(versions, r.version)
+	}
+	current := r.data
+	subscriptions := append([]*recordSubscription{}, r.subscriptions...)
+	r.mu.Unlock()
+
+	notifySubscriptions(subscriptions, old, current)
+	return versions, ack, err
+}
+
+// sendPatch sends a single update for the record and applies it locally,
+// it must be called with r.mu held
+func (r *Record) sendPatch(patch recordPatch, writeAck bool) error {
+	version := strconv.Itoa(r.version + 1)
+	var config []string
+	if writeAck {
+		config = []string{writeSuccessConfig}
+	}
+	if patch.path == "" {
+		raw, err := json.Marshal(patch.value)
+		if err != nil {
+			return err
+		}
+		data := append([]string{r.Name, version, string(raw)}, config...)
+		if err := r.client.sendRecordAction(interfaces.ActionUpdate, data...); err != nil {
+			return err
+		}
+		r.data = patch.value
+	} else {
+		typed, err := message.ConvertTyped(patch.value)
+		if err != nil {
+			return err
+		}
+		patched, err := message.SetPath(r.data, patch.path, patch.value)
+		if err != nil {
+			return err
+		}
+		data := append([]string{r.Name, version, patch.path, typed}, config...)
+		if err := r.client.sendRecordAction(interfaces.ActionPatch, data...); err != nil {
+			return err
+		}
+		r.data = patched
+	}
+	r.version++
+	return nil
+}
+
+func isStruct(value interface{}) bool {
+	t := reflect.TypeOf(value)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t != nil && t.Kind() == reflect.Struct
+}
+
+//Update atomically replaces the record data with the result of fn applied to the
+//latest known data. If the record was changed concurrently, fn is applied again
+//to the newer data, up to RecordUpdateAttempts times.
+func (r *Record) Update(fn func(current interface{}) (interface{}, error)) error {
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
+
+	for attempt := 0; attempt < r.client.Options.RecordUpdateAttempts; attempt++ {
+		version, ack, err := r.tryUpdate(fn)
+		if err == errVersionConflict {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		select {
+		case err = <-ack:
+		case <-r.client.clock().After(r.client.Options.RecordWriteAckTimeout):
+			r.resolveWrites(func(v int) bool { return v == version }, nil)
+			return errors.NewDeepstreamError(interfaces.TopicRecord, errors.ErrAckTimeout.Event, fmt.Sprintf("Update of record %s was not acknowledged within %s", r.Name, r.client.Options.RecordWriteAckTimeout), "")
+		}
+		if err != errVersionConflict {
+			return err
+		}
+	}
+	return errors.NewDeepstreamError(interfaces.TopicRecord, errors.ErrVersionExists.Event, fmt.Sprintf("Record %s could not be updated after %d attempts", r.Name, r.client.Options.RecordUpdateAttempts), "")
+}
+
+func (r *Record) tryUpdate(fn func(current interface{}) (interface{}, error)) (int, chan error, error) {
+	r.mu.RLock()
+	base := r.version
+	current := message.DeepCopy(r.data)
+	r.mu.RUnlock()
+
+	value, err := fn(current)
+	if err != nil {
+		return 0, nil, err
+	}
+	normalized, err := encodeData(value)
+	if err != nil {
+		return 0, nil, err
+	}
+	raw, err := json.Marshal(normalized)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	r.mu.Lock()
+	if r.version != base {
+		r.mu.Unlock()
+		return 0, nil, errVersionConflict
+	}
+	version := base + 1
+	ack := make(chan error, 1)
+	r.pendingWrites[version] = ack
+	err = r.client.sendRecordAction(interfaces.ActionUpdate, r.Name, strconv.Itoa(version), string(raw), writeSuccessConfig)
+	if err != nil {
+		delete(r.pendingWrites, version)
+		r.mu.Unlock()
+		return 0, nil, err
+	}
+	old := r.data
+	r.data = normalized
+	r.version = version
+	subscriptions := append([]*recordSubscription{}, r.subscriptions...)
+	r.mu.Unlock()
+
+	notifySubscriptions(subscriptions, old, normalized)
+	return version, ack, nil
+}
+
+// resolveWrites completes the pending writes whose version matches
+func (r *Record) resolveWrites(match func(version int) bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for version, ack := range r.pendingWrites {
+		if match(version) {
+			ack <- err
+			delete(r.pendingWrites, version)
+		}
+	}
+}
+
+//Subscribe registers a callback fired whenever the value at path changes.
+//An empty path subscribes to the whole record. It returns an id for Unsubscribe.
+func (r *Record) Subscribe(path string, callback func(data interface{})) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastSubID++
+	r.subscriptions = append(r.subscriptions, &recordSubscription{
+		id:       r.lastSubID,
+		path:     path,
+		callback: callback,
+	})
+	return r.lastSubID
+}
+
+//Unsubscribe removes the subscription or error callback with the given id
+func (r *Record) Unsubscribe(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, sub := range r.subscriptions {
+		if sub.id == id {
+			r.subscriptions = append(r.subscriptions[:i], r.subscriptions[i+1:]...)
+			return
+		}
+	}
+	for i, sub := range r.errorSubs {
+		if sub.id == id {
+			r.errorSubs = append(r.errorSubs[:i], r.errorSubs[i+1:]...)
+			return
+		}
+	}
+}
+
+//OnError registers a callback fired with the errors the server sends about the
+//record, e.g. MESSAGE_DENIED, instead of ClientOptions.OnError. It returns an id
+//for Unsubscribe.
+func (r *Record) OnError(callback ErrorCallback) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastSubID++
+	r.errorSubs = append(r.errorSubs, &recordErrorSubscription{
+		id:       r.lastSubID,
+		callback: callback,
+	})
+	return r.lastSubID
+}
+
+// notifyError fires the error callbacks, returning false if there are none
+func (r *Record) notifyError(err *errors.DeepstreamError) bool {
+	r.mu.RLock()
+	subs := append([]*recordErrorSubscription{}, r.errorSubs...)
+	r.mu.RUnlock()
+
+	for _, sub := range subs {
+		sub.callback(err)
+	}
+	return len(subs) > 0
+}
+
+//Discard releases this reference to the record. Updates stop being received
+//once every reference obtained with GetRecord has been discarded.
+func (r *Record) Discard() error {
+	if !r.client.releaseRecord(r) {
+		return nil
+	}
+	return r.client.sendRecordAction(interfaces.ActionUnsubscribe, r.Name)
+}
+
+//Delete removes the record from the server
+func (r *Record) Delete() error {
+	return r.client.sendRecordAction(interfaces.ActionDelete, r.Name)
+}
@@ -0,0 +1,218 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Record", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var clock *testing.FakeClock
+		var cli *client.Client
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			clock = testing.NewFakeClock()
+			cli = loggedInClient(protocol, client.WithClock(clock))
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		Describe("GetRecord", func() {
+			It("Should unsubscribe from records whose data never came", func() {
+				result := make(chan error, 1)
+				go func() {
+					_, err := cli.GetRecord("user/Lisa")
+					result <- err
+				}()
+				_, err := protocol.WaitForSent(`^R\|CR\|user/Lisa$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(clock.WaitForTimers(1, time.Second)).To(Succeed())
+				clock.Advance(cli.Options.RecordReadTimeout)
+				Eventually(result).Should(Receive(MatchError(errors.ErrResponseTimeout)))
+				Expect(protocol).To(HaveLastSentMessage("R|US|user/Lisa"))
+			})
+
+			It("Should share records until every reference is discarded", func() {
+				rec := readRecord(cli, protocol, "user/Lisa", 1, `{"name":"Lisa"}`)
+				same, err := cli.GetRecord("user/Lisa")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(same).To(BeIdenticalTo(rec))
+				Expect(protocol).To(HaveSentMessages(0))
+
+				Expect(rec.Discard()).To(Succeed())
+				Expect(protocol).To(HaveSentMessages(0))
+				Expect(same.Discard()).To(Succeed())
+				Expect(protocol).To(HaveLastSentMessage("R|US|user/Lisa"))
+
+				// discarding again is a no-op
+				Expect(same.Discard()).To(Succeed())
+				Expect(protocol).To(HaveSentMessages(1))
+			})
+		})
+
+		Describe("Update", func() {
+			var rec *client.Record
+
+			BeforeEach(func() {
+				rec = readRecord(cli, protocol, "counter", 1, `{"count":1}`)
+			})
+
+			increment := func(current interface{}) (interface{}, error) {
+				data := current.(map[string]interface{})
+				data["count"] = data["count"].(float64) + 1
+				return data, nil
+			}
+
+			It("Should retry on top of the remote data when the version changed", func() {
+				result := make(chan error, 1)
+				go func() {
+					result <- rec.Update(increment)
+				}()
+				_, err := protocol.WaitForSent(`^R\|U\|counter\|2\|`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol).To(HaveLastSentMessage(`R|U|counter|2|{"count":2}|{"writeSuccess":true}`))
+
+				Expect(protocol.DeliverRaw(raw("R", "E", "VERSION_EXISTS", "counter", "2", `{"count":10}`))).To(Succeed())
+				_, err = protocol.WaitForSent(`^R\|U\|counter\|3\|`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol).To(HaveLastSentMessage(`R|U|counter|3|{"count":11}|{"writeSuccess":true}`))
+
+				Expect(protocol.DeliverRaw(raw("R", "WA", "counter", "[3]", "L"))).To(Succeed())
+				Eventually(result).Should(Receive(BeNil()))
+				Expect(rec.Get()).To(Equal(map[string]interface{}{"count": 11.0}))
+				Expect(rec.Version()).To(Equal(3))
+			})
+
+			It("Should give up after RecordUpdateAttempts conflicts", func() {
+				cli.Options.RecordUpdateAttempts = 2
+				result := make(chan error, 1)
+				go func() {
+					result <- rec.Update(increment)
+				}()
+				for version := 2; version <= 3; version++ {
+					_, err := protocol.WaitForSent(`^R\|U\|counter\|`+strconv.Itoa(version)+`\|`, time.Second)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(protocol.DeliverRaw(raw("R", "E", "VERSION_EXISTS", "counter", strconv.Itoa(version), `{"count":10}`))).To(Succeed())
+				}
+
+				var err error
+				Eventually(result).Should(Receive(&err))
+				Expect(errors.Is(err, errors.ErrVersionExists)).To(BeTrue())
+				Expect(protocol).To(HaveSentMessages(2))
+			})
+
+			It("Should fail with the error of fn without sending anything", func() {
+				err := rec.Update(func(current interface{}) (interface{}, error) {
+					return nil, errors.ErrRecordNotFound
+				})
+				Expect(err).To(MatchError(errors.ErrRecordNotFound))
+				Expect(protocol).To(HaveSentMessages(0))
+			})
+		})
+
+		Describe("SetWithAck", func() {
+			var rec *client.Record
+
+			BeforeEach(func() {
+				rec = readRecord(cli, protocol, "user/Lisa", 1, `{"name":"Lisa","age":8}`)
+			})
+
+			setWithAck := func(data interface{}) chan error {
+				result := make(chan error, 1)
+				go func() {
+					result <- rec.SetWithAck(data)
+				}()
+				return result
+			}
+
+			It("Should wait for the server to acknowledge the write", func() {
+				result := setWithAck(map[string]interface{}{"name": "Bart"})
+				_, err := protocol.WaitForSent(`^R\|U\|user/Lisa\|2\|`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol).To(HaveLastSentMessage(`R|U|user/Lisa|2|{"name":"Bart"}|{"writeSuccess":true}`))
+				Consistently(result, 50*time.Millisecond).ShouldNot(Receive())
+
+				Expect(protocol.DeliverRaw(raw("R", "WA", "user/Lisa", "[2]", "L"))).To(Succeed())
+				Eventually(result).Should(Receive(BeNil()))
+				Expect(rec.Get()).To(Equal(map[string]interface{}{"name": "Bart"}))
+			})
+
+			It("Should wait for every path written for a struct", func() {
+				type person struct {
+					Name string `json:"name"`
+					Age  int    `json:"age"`
+				}
+				result := setWithAck(person{Name: "Bart", Age: 10})
+				_, err := protocol.WaitForSent(`^R\|P\|user/Lisa\|3\|`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol).To(HaveSentMessages(2))
+
+				Expect(protocol.DeliverRaw(raw("R", "WA", "user/Lisa", "[2]", "L"))).To(Succeed())
+				Consistently(result, 50*time.Millisecond).ShouldNot(Receive())
+				Expect(protocol.DeliverRaw(raw("R", "WA", "user/Lisa", "[3]", "L"))).To(Succeed())
+				Eventually(result).Should(Receive(BeNil()))
+			})
+
+			It("Should fail with the error the server acknowledges", func() {
+				result := setWithAck(map[string]interface{}{"name": "Bart"})
+				_, err := protocol.WaitForSent(`^R\|U\|user/Lisa\|2\|`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(protocol.DeliverRaw(raw("R", "WA", "user/Lisa", "[2]", "Sstorage unavailable"))).To(Succeed())
+				var writeErr error
+				Eventually(result).Should(Receive(&writeErr))
+				Expect(errors.Is(writeErr, errors.ErrRecordUpdateError)).To(BeTrue())
+				Expect(writeErr.Error()).To(ContainSubstring("storage unavailable"))
+			})
+
+			It("Should time out when the write isn't acknowledged", func() {
+				// the read of the record left its timeout on the clock
+				timers := clock.Timers()
+				result := setWithAck(map[string]interface{}{"name": "Bart"})
+				Expect(clock.WaitForTimers(timers+1, time.Second)).To(Succeed())
+				clock.Advance(cli.Options.RecordWriteAckTimeout)
+
+				var writeErr error
+				Eventually(result).Should(Receive(&writeErr))
+				Expect(errors.Is(writeErr, errors.ErrAckTimeout)).To(BeTrue())
+			})
+		})
+
+		Describe("SetPathWithAck", func() {
+			It("Should wait for the server to acknowledge the write", func() {
+				rec := readRecord(cli, protocol, "user/Lisa", 1, `{"name":"Lisa"}`)
+				result := make(chan error, 1)
+				go func() {
+					result <- rec.SetPathWithAck("name", "Bart")
+				}()
+				_, err := protocol.WaitForSent(`^R\|P\|user/Lisa\|2\|`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol).To(HaveLastSentMessage(`R|P|user/Lisa|2|name|SBart|{"writeSuccess":true}`))
+				Consistently(result, 50*time.Millisecond).ShouldNot(Receive())
+
+				Expect(protocol.DeliverRaw(raw("R", "WA", "user/Lisa", "[2]", "L"))).To(Succeed())
+				Eventually(result).Should(Receive(BeNil()))
+				Expect(rec.Get()).To(Equal(map[string]interface{}{"name": "Bart"}))
+			})
+		})
+	})
+})
@@ -0,0 +1,49 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"io"
+	"strings"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+//WithRecorder records every frame sent and received by the client, and the
+//connections being opened and closed, to w as JSON lines with their time, e.g.
+//to a file created with os.Create. The recording can be read with
+//message.ReadFrames and played back with testing.NewReplayProtocol. The
+//credentials of the logins are redacted, see message.RedactFrame.
+func WithRecorder(w io.Writer) ClientOption {
+	return func(opts *ClientOptions) error {
+		opts.Recorder = message.NewFrameWriter(w)
+		return nil
+	}
+}
+
+// record writes a frame if the client has a recorder, a failing recorder
+// doesn't fail the connection
+func (c *Client) record(direction, data string) {
+	if c.Options.Recorder == nil {
+		return
+	}
+	frame := message.Frame{Time: c.clock().Now(), Direction: direction, Data: data}
+	if err := c.Options.Recorder.Write(frame); err != nil {
+		c.log(interfaces.LogLevelWarn, "Recorder: frame could not be recorded", errField(err))
+	}
+}
+
+// rawFrame rebuilds the frame of actions received from a protocol
+func rawFrame(actions []interfaces.Action) string {
+	raw := make([]string, 0, len(actions))
+	for _, action := range actions {
+		raw = append(raw, action.ToAction())
+	}
+	return strings.Join(raw, "")
+}
@@ -0,0 +1,228 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+type rpcResult struct {
+	data interface{}
+	err  error
+}
+
+type rpcCall struct {
+	name   string
+	once   sync.Once
+	acked  chan struct{}
+	result chan rpcResult
+}
+
+func (call *rpcCall) ack() {
+	call.once.Do(func() { close(call.acked) })
+}
+
+// finish delivers the result of the call, ignoring duplicated responses
+func (call *rpcCall) finish(res rpcResult) {
+	call.ack()
+	select {
+	case call.result <- res:
+	default:
+	}
+}
+
+// randomUID returns a correlation id unique enough to tell requests apart
+func randomUID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + "-" + strconv.FormatInt(rand.Int63(), 36)
+}
+
+//WithUIDs sets the generator of the correlation ids of RPC requests and
+//streams, e.g. testing.SequentialUIDs so that the RPCs of a recorded session
+//are replayed with the ids they were recorded with
+func WithUIDs(newUID func() string) ClientOption {
+	return func(opts *ClientOptions) error {
+		opts.NewUID = newUID
+		return nil
+	}
+}
+
+func (c *Client) newUID() string {
+	if c.Options.NewUID == nil {
+		return randomUID()
+	}
+	return c.Options.NewUID()
+}
+
+//Make requests the RPC with the given name and waits for its result
+func (c *Client) Make(name string, data interface{}) (interface{}, error) {
+	return c.MakeContext(context.Background(), name, data)
+}
+
+//MakeContext requests the RPC with the given name and waits for its result until
+//ctx is done. It fails with ErrAckTimeout or ErrResponseTimeout if the request
+//isn't acknowledged within RPCAckTimeout or answered within RPCResponseTimeout,
+//ErrNoRPCProvider if nobody provides the RPC and *RPCProviderError if the provider
+//responded with an error. The DeepstreamErrors returned are on the RPC topic.
+func (c *Client) MakeContext(ctx context.Context, name string, data interface{}) (interface{}, error) {
+	invoker := chainRequesterInterceptors(c.Options.RPCRequesterInterceptors, c.makeContext)
+	return invoker(ctx, name, data)
+}
+
+func (c *Client) makeContext(ctx context.Context, name string, data interface{}) (interface{}, error) {
+	normalized, err := encodeData(data)
+	if err != nil {
+		return nil, err
+	}
+	typed, err := message.ConvertTyped(normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	cid := c.newUID()
+	call := &rpcCall{
+		name:   name,
+		acked:  make(chan struct{}),
+		result: make(chan rpcResult, 1),
+	}
+	c.rpcsMu.Lock()
+	c.rpcs[cid] = call
+	c.rpcsMu.Unlock()
+	defer func() {
+		c.rpcsMu.Lock()
+		delete(c.rpcs, cid)
+		c.rpcsMu.Unlock()
+	}()
+
+	if err := c.sendRPCAction(interfaces.ActionRequest, name, cid, typed); err != nil {
+		return nil, err
+	}
+
+	ackTimer := c.clock().NewTimer(c.Options.RPCAckTimeout)
+	defer ackTimer.Stop()
+	responseTimer := c.clock().NewTimer(c.Options.RPCResponseTimeout)
+	defer responseTimer.Stop()
+
+	acked := call.acked
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-acked:
+			ackTimer.Stop()
+			acked = nil
+		case <-ackTimer.C():
+			return nil, errors.NewDeepstreamError(interfaces.TopicRPC, errors.ErrAckTimeout.Event, fmt.Sprintf("RPC %s was not acknowledged in time", name), "")
+		case <-responseTimer.C():
+			return nil, errors.NewDeepstreamError(interfaces.TopicRPC, errors.ErrResponseTimeout.Event, fmt.Sprintf("RPC %s was not answered in time", name), "")
+		case res := <-call.result:
+			return res.data, res.err
+		}
+	}
+}
+
+func (c *Client) sendRPCAction(action string, data ...string) error {
+	msg := &message.Message{
+		Topic:   interfaces.TopicRPC,
+		Action:  action,
+		RawData: data,
+	}
+
+	var act interfaces.Action
+	var err error
+	switch action {
+	case interfaces.ActionRequest:
+		act, err = message.NewRPCRequestAction(msg)
+	case interfaces.ActionResponse:
+		act, err = message.NewRPCResponseAction(msg)
+	case interfaces.ActionRejection:
+		act, err = message.NewRPCRejectionAction(msg)
+	case interfaces.ActionError:
+		act, err = message.NewErrorAction(msg)
+	case interfaces.ActionAck:
+		act, err = message.NewAckAction(msg)
+	case interfaces.ActionSubscribe:
+		act, err = message.NewSubscribeAction(msg)
+	case interfaces.ActionUnsubscribe:
+		act, err = message.NewUnsubscribeAction(msg)
+	default:
+		return fmt.Errorf("Unsupported RPC action %s", action)
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendAction(act)
+}
+
+func (c *Client) lookupRPC(cid string) *rpcCall {
+	c.rpcsMu.Lock()
+	defer c.rpcsMu.Unlock()
+
+	return c.rpcs[cid]
+}
+
+func (c *Client) handleRPC(msg *message.Message) {
+	switch msg.Action {
+	case interfaces.ActionRequest:
+		c.handleRPCRequest(msg)
+	case interfaces.ActionAck:
+		if len(msg.RawData) == 2 && msg.RawData[0] == interfaces.ActionUnsubscribe {
+			c.ackUnprovide(msg.RawData[1])
+			return
+		}
+		if len(msg.RawData) < 3 || msg.RawData[0] != interfaces.ActionRequest {
+			return
+		}
+		if call := c.lookupRPC(msg.RawData[2]); call != nil {
+			call.ack()
+		}
+	case interfaces.ActionResponse:
+		if len(msg.RawData) < 2 {
+			c.log(interfaces.LogLevelWarn, "handleRPC: malformed message", messageFields(msg)...)
+			return
+		}
+		call := c.lookupRPC(msg.RawData[1])
+		if call == nil {
+			return
+		}
+		var data interface{}
+		var err error
+		if len(msg.RawData) > 2 {
+			data, err = message.ParseTyped(msg.RawData[2])
+		}
+		call.finish(rpcResult{data: data, err: err})
+	case interfaces.ActionError:
+		var call *rpcCall
+		if len(msg.RawData) > 2 {
+			call = c.lookupRPC(msg.RawData[2])
+		}
+		if call == nil {
+			c.notifyProviderError(errorSubject(msg), deepstreamError(msg))
+			return
+		}
+		call.finish(rpcResult{err: rpcError(msg, call.name)})
+	}
+}
+
+// rpcError turns a P|E message into a DeepstreamError if it carries one of the
+// spec events, or into the error message of the provider otherwise
+func rpcError(msg *message.Message, name string) error {
+	reason := msg.RawData[0]
+	if errors.IsKnownEvent(reason) {
+		return errors.NewDeepstreamError(interfaces.TopicRPC, reason, fmt.Sprintf("RPC %s failed", name), msg.Raw)
+	}
+	return &errors.RPCProviderError{Name: name, Message: reason}
+}
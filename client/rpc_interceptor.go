@@ -0,0 +1,64 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+)
+
+//RPCProviderInterceptor wraps the handling of a provided RPC request. It may answer
+//the request itself, e.g. to deny it, or call next to continue the chain. Handlers can
+//answer asynchronously: wait on req.Context() and check req.Response() to observe
+//the answer.
+type RPCProviderInterceptor func(req *RPCRequest, next RPCHandler)
+
+//RPCInvoker sends an RPC request and waits for its result
+type RPCInvoker func(ctx context.Context, name string, data interface{}) (interface{}, error)
+
+//RPCRequesterInterceptor wraps an RPC request made with Make, MakeContext or MakeTyped.
+//It must call invoker to actually send the request.
+type RPCRequesterInterceptor func(ctx context.Context, name string, data interface{}, invoker RPCInvoker) (interface{}, error)
+
+func chainProviderInterceptors(interceptors []RPCProviderInterceptor, handler RPCHandler) RPCHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(req *RPCRequest) {
+			interceptor(req, next)
+		}
+	}
+	return handler
+}
+
+func chainRequesterInterceptors(interceptors []RPCRequesterInterceptor, invoker RPCInvoker) RPCInvoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, name string, data interface{}) (interface{}, error) {
+			return interceptor(ctx, name, data, next)
+		}
+	}
+	return invoker
+}
+
+//RPCDeadline answers requests with a RESPONSE_TIMEOUT error if the handler
+//doesn't answer them within timeout
+func RPCDeadline(timeout time.Duration) RPCProviderInterceptor {
+	return func(req *RPCRequest, next RPCHandler) {
+		ctx, cancel := req.client.withTimeout(req.Context(), timeout)
+		go func() {
+			defer cancel()
+			<-ctx.Done()
+			if ctx.Err() == context.DeadlineExceeded {
+				req.Error(errors.ErrResponseTimeout.Event)
+			}
+		}()
+		next(req.WithContext(ctx))
+	}
+}
@@ -0,0 +1,157 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RPC interceptors", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var clock *testing.FakeClock
+		var cli *client.Client
+		var mu sync.Mutex
+		var calls []string
+
+		called := func(name string) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, name)
+		}
+		trace := func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string{}, calls...)
+		}
+		providerInterceptor := func(name string) client.RPCProviderInterceptor {
+			return func(req *client.RPCRequest, next client.RPCHandler) {
+				called(name + " before")
+				next(req)
+				called(name + " after")
+			}
+		}
+		requesterInterceptor := func(name string) client.RPCRequesterInterceptor {
+			return func(ctx context.Context, rpc string, data interface{}, invoker client.RPCInvoker) (interface{}, error) {
+				called(name + " before")
+				defer called(name + " after")
+				return invoker(ctx, rpc, data)
+			}
+		}
+		connect := func(options ...client.ClientOption) {
+			options = append(options, client.WithClock(clock), client.WithUIDs(testing.SequentialUIDs()))
+			cli = loggedInClient(protocol, options...)
+		}
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			clock = testing.NewFakeClock()
+			calls = nil
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		It("Should wrap providers with the client interceptors, then the provider ones", func() {
+			connect(func(opts *client.ClientOptions) error {
+				opts.RPCProviderInterceptors = []client.RPCProviderInterceptor{providerInterceptor("first"), providerInterceptor("second")}
+				return nil
+			})
+			Expect(cli.Provide("toUppercase", func(req *client.RPCRequest) {
+				called("handler")
+				req.Send("ABC")
+			}, client.WithInterceptors(providerInterceptor("provider")))).To(Succeed())
+
+			Expect(protocol.DeliverRaw(raw("P", "REQ", "toUppercase", "1", "Sabc"))).To(Succeed())
+			_, err := protocol.WaitForSent(`^P\|RES\|toUppercase\|1\|SABC$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(trace).Should(Equal([]string{
+				"first before", "second before", "provider before", "handler", "provider after", "second after", "first after",
+			}))
+		})
+
+		It("Should let provider interceptors answer the request", func() {
+			connect()
+			Expect(cli.Provide("toUppercase", func(req *client.RPCRequest) {
+				called("handler")
+			}, client.WithInterceptors(func(req *client.RPCRequest, next client.RPCHandler) {
+				req.Reject()
+			}))).To(Succeed())
+
+			Expect(protocol.DeliverRaw(raw("P", "REQ", "toUppercase", "1", "Sabc"))).To(Succeed())
+			_, err := protocol.WaitForSent(`^P\|REJ\|toUppercase\|1$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trace()).To(BeEmpty())
+		})
+
+		It("Should wrap requests with the requester interceptors in order", func() {
+			connect(func(opts *client.ClientOptions) error {
+				opts.RPCRequesterInterceptors = []client.RPCRequesterInterceptor{requesterInterceptor("first"), requesterInterceptor("second")}
+				return nil
+			})
+
+			result := make(chan interface{}, 1)
+			go func() {
+				defer GinkgoRecover()
+				data, err := cli.Make("toUppercase", "abc")
+				Expect(err).NotTo(HaveOccurred())
+				result <- data
+			}()
+			_, err := protocol.WaitForSent(`^P\|REQ\|toUppercase\|1\|Sabc$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trace()).To(Equal([]string{"first before", "second before"}))
+
+			Expect(protocol.DeliverRaw(raw("P", "RES", "toUppercase", "1", "SABC"))).To(Succeed())
+			Eventually(result).Should(Receive(Equal("ABC")))
+			Expect(trace()).To(Equal([]string{"first before", "second before", "second after", "first after"}))
+		})
+
+		Describe("RPCDeadline", func() {
+			It("Should answer with RESPONSE_TIMEOUT when the handler doesn't answer in time", func() {
+				connect()
+				Expect(cli.Provide("toUppercase", func(req *client.RPCRequest) {
+					called("handler")
+				}, client.WithInterceptors(client.RPCDeadline(time.Second)))).To(Succeed())
+
+				Expect(protocol.DeliverRaw(raw("P", "REQ", "toUppercase", "1", "Sabc"))).To(Succeed())
+				Eventually(trace).Should(Equal([]string{"handler"}))
+				Expect(clock.WaitForTimers(1, time.Second)).To(Succeed())
+				clock.Advance(999 * time.Millisecond)
+				// the subscription and the ack of the request
+				Consistently(func() int { return len(protocol.Sent()) }, 50*time.Millisecond).Should(Equal(2))
+
+				clock.Advance(time.Millisecond)
+				_, err := protocol.WaitForSent(`^P\|E\|RESPONSE_TIMEOUT\|toUppercase\|1$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Should leave the requests answered in time alone", func() {
+				connect()
+				Expect(cli.Provide("toUppercase", func(req *client.RPCRequest) {
+					req.Send("ABC")
+				}, client.WithInterceptors(client.RPCDeadline(time.Second)))).To(Succeed())
+
+				Expect(protocol.DeliverRaw(raw("P", "REQ", "toUppercase", "1", "Sabc"))).To(Succeed())
+				_, err := protocol.WaitForSent(`^P\|RES\|toUppercase\|1\|SABC$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				clock.Advance(time.Second)
+				Consistently(func() int { return len(protocol.Sent()) }, 50*time.Millisecond).Should(Equal(3))
+				Expect(protocol).NotTo(HaveSentMessage("P|E|RESPONSE_TIMEOUT|toUppercase|1"))
+			})
+		})
+	})
+})
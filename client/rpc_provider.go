@@ -0,0 +1,343 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+//RPCHandler is called for every request of a provided RPC and must answer it
+//with Send, Error or Reject
+type RPCHandler func(req *RPCRequest)
+
+//RPCRequest is a request received for a provided RPC
+type RPCRequest struct {
+	Name          string
+	CorrelationID string
+	Data          interface{}
+
+	client *Client
+	ctx    context.Context
+	state  *rpcRequestState
+}
+
+type rpcRequestState struct {
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	response *RPCResponse
+	// done is closed once the handler returned and the request is answered or
+	// timed out
+	done chan struct{}
+}
+
+//RPCResponse describes how a provided RPC request was answered
+type RPCResponse struct {
+	Data     interface{}
+	Error    string
+	Rejected bool
+}
+
+//Context returns a context that is done once the request is answered or the
+//server stops waiting for a response
+func (r *RPCRequest) Context() context.Context {
+	return r.ctx
+}
+
+//WithContext returns a copy of the request using ctx, which should be derived
+//from the request context. Answering either of them answers both.
+func (r *RPCRequest) WithContext(ctx context.Context) *RPCRequest {
+	copied := *r
+	copied.ctx = ctx
+	return &copied
+}
+
+//Response returns how the request was answered, or nil if it wasn't yet
+func (r *RPCRequest) Response() *RPCResponse {
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+
+	return r.state.response
+}
+
+//Send responds to the request with data
+func (r *RPCRequest) Send(data interface{}) error {
+	normalized, err := encodeData(data)
+	if err != nil {
+		return err
+	}
+	typed, err := message.ConvertTyped(normalized)
+	if err != nil {
+		return err
+	}
+	return r.respond(&RPCResponse{Data: normalized}, interfaces.ActionResponse, r.Name, r.CorrelationID, typed)
+}
+
+//Error responds to the request with an error message
+func (r *RPCRequest) Error(reason string) error {
+	return r.respond(&RPCResponse{Error: reason}, interfaces.ActionError, reason, r.Name, r.CorrelationID)
+}
+
+//Reject tells the server this provider won't handle the request, so it's
+//routed to another provider
+func (r *RPCRequest) Reject() error {
+	return r.respond(&RPCResponse{Rejected: true}, interfaces.ActionRejection, r.Name, r.CorrelationID)
+}
+
+func (r *RPCRequest) respond(response *RPCResponse, action string, data ...string) error {
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+
+	if r.state.response != nil {
+		return fmt.Errorf("RPC %s with id %s has already been responded to", r.Name, r.CorrelationID)
+	}
+	r.state.response = response
+	r.state.cancel()
+	return r.client.sendRPCAction(action, data...)
+}
+
+//ProviderOption is a function on the options for a provided RPC.
+type ProviderOption func(*ProviderOptions) error
+
+//ProviderOptions configures how the requests of a provided RPC are handled
+type ProviderOptions struct {
+	// MaxInFlight specifies how many requests are handled concurrently,
+	// default to 0 which means no limit
+	MaxInFlight int
+	// MaxQueued specifies how many requests wait for a free slot once
+	// MaxInFlight is reached, further requests are rejected so the server
+	// routes them to another provider, default to 0
+	MaxQueued int
+	// Interceptors wrap the handler, after the client's RPCProviderInterceptors
+	Interceptors []RPCProviderInterceptor
+	// OnError is called with the errors the server sends about the RPC,
+	// e.g. MESSAGE_DENIED when providing it, instead of ClientOptions.OnError
+	OnError ErrorCallback
+}
+
+//WithMaxInFlight limits how many requests are handled concurrently
+func WithMaxInFlight(n int) ProviderOption {
+	return func(opts *ProviderOptions) error {
+		if n < 0 {
+			return fmt.Errorf("MaxInFlight must not be negative, got %d", n)
+		}
+		opts.MaxInFlight = n
+		return nil
+	}
+}
+
+//WithMaxQueued limits how many requests wait for a free slot before being rejected
+func WithMaxQueued(n int) ProviderOption {
+	return func(opts *ProviderOptions) error {
+		if n < 0 {
+			return fmt.Errorf("MaxQueued must not be negative, got %d", n)
+		}
+		opts.MaxQueued = n
+		return nil
+	}
+}
+
+//WithInterceptors wraps the handler of the provided RPC with interceptors
+func WithInterceptors(interceptors ...RPCProviderInterceptor) ProviderOption {
+	return func(opts *ProviderOptions) error {
+		opts.Interceptors = append(opts.Interceptors, interceptors...)
+		return nil
+	}
+}
+
+//WithErrorHandler handles the errors the server sends about the provided RPC
+func WithErrorHandler(onError ErrorCallback) ProviderOption {
+	return func(opts *ProviderOptions) error {
+		opts.OnError = onError
+		return nil
+	}
+}
+
+type rpcProvider struct {
+	handler RPCHandler
+	options ProviderOptions
+	slots   chan struct{}
+	mu      sync.Mutex
+	pending int
+}
+
+// admit reserves room for a request, returning false if the provider is saturated
+func (p *rpcProvider) admit() bool {
+	if p.options.MaxInFlight == 0 {
+		return true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pending >= p.options.MaxInFlight+p.options.MaxQueued {
+		return false
+	}
+	p.pending++
+	return true
+}
+
+// run calls the handler once a slot is free and holds the slot until the
+// handler returned and the request is answered or times out. Requests timing
+// out while waiting for a slot are answered with RESPONSE_TIMEOUT.
+func (p *rpcProvider) run(req *RPCRequest) {
+	if p.options.MaxInFlight == 0 {
+		p.handle(req)
+		<-req.ctx.Done()
+		return
+	}
+	defer func() {
+		p.mu.Lock()
+		p.pending--
+		p.mu.Unlock()
+	}()
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-req.ctx.Done():
+		if req.Response() == nil {
+			if err := req.Error(errors.ErrResponseTimeout.Event); err != nil {
+				req.client.log(interfaces.LogLevelWarn, "handleRPC: timeout response failed", interfaces.LogField{Key: "rpc", Value: req.Name}, errField(err))
+			}
+		}
+		return
+	}
+	defer func() { <-p.slots }()
+
+	p.handle(req)
+	<-req.ctx.Done()
+}
+
+// handle calls the handler, a panicking handler answers the request with an
+// error instead of crashing the client
+func (p *rpcProvider) handle(req *RPCRequest) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		req.client.log(interfaces.LogLevelError, "handleRPC: provider panicked", interfaces.LogField{Key: "rpc", Value: req.Name}, interfaces.LogField{Key: "panic", Value: recovered})
+		if req.Response() == nil {
+			if err := req.Error(fmt.Sprint(recovered)); err != nil {
+				req.client.log(interfaces.LogLevelWarn, "handleRPC: error response failed", interfaces.LogField{Key: "rpc", Value: req.Name}, errField(err))
+			}
+		}
+	}()
+
+	p.handler(req)
+}
+
+//Provide registers handler as the provider of the RPC with the given name
+func (c *Client) Provide(name string, handler RPCHandler, options ...ProviderOption) error {
+	provider := &rpcProvider{}
+	for _, opt := range options {
+		if err := opt(&provider.options); err != nil {
+			return err
+		}
+	}
+	interceptors := append(append([]RPCProviderInterceptor{}, c.Options.RPCProviderInterceptors...), provider.options.Interceptors...)
+	provider.handler = chainProviderInterceptors(interceptors, handler)
+	provider.slots = make(chan struct{}, provider.options.MaxInFlight)
+
+	c.providersMu.Lock()
+	if _, ok := c.providers[name]; ok {
+		c.providersMu.Unlock()
+		return fmt.Errorf("RPC %s is already provided", name)
+	}
+	c.providers[name] = provider
+	c.providersMu.Unlock()
+
+	if err := c.sendRPCAction(interfaces.ActionSubscribe, name); err != nil {
+		c.providersMu.Lock()
+		delete(c.providers, name)
+		c.providersMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+//Unprovide stops providing the RPC with the given name
+func (c *Client) Unprovide(name string) error {
+	c.providersMu.Lock()
+	if _, ok := c.providers[name]; !ok {
+		c.providersMu.Unlock()
+		return fmt.Errorf("RPC %s is not provided", name)
+	}
+	delete(c.providers, name)
+	c.providersMu.Unlock()
+
+	return c.sendRPCAction(interfaces.ActionUnsubscribe, name)
+}
+
+func (c *Client) handleRPCRequest(msg *message.Message) {
+	if len(msg.RawData) < 2 {
+		c.log(interfaces.LogLevelWarn, "handleRPC: malformed message", messageFields(msg)...)
+		return
+	}
+
+	ctx, cancel := c.withTimeout(context.Background(), c.Options.RPCResponseTimeout)
+	req := &RPCRequest{
+		Name:          msg.RawData[0],
+		CorrelationID: msg.RawData[1],
+		client:        c,
+		ctx:           ctx,
+		state:         &rpcRequestState{cancel: cancel, done: make(chan struct{})},
+	}
+
+	if len(msg.RawData) > 2 {
+		data, err := message.ParseTyped(msg.RawData[2])
+		if err != nil {
+			req.Error(err.Error())
+			return
+		}
+		req.Data = data
+	}
+
+	c.providersMu.Lock()
+	provider, ok := c.providers[req.Name]
+	admitted := ok && !c.isDraining && provider.admit()
+	if admitted {
+		c.activeRequests[req.state] = req
+	}
+	c.providersMu.Unlock()
+	if !admitted {
+		if err := req.Reject(); err != nil {
+			c.log(interfaces.LogLevelWarn, "handleRPC: reject failed", messageFields(msg, errField(err))...)
+		}
+		return
+	}
+
+	if err := c.sendRPCAction(interfaces.ActionAck, interfaces.ActionRequest, req.Name, req.CorrelationID); err != nil {
+		c.log(interfaces.LogLevelWarn, "handleRPC: ack failed", messageFields(msg, errField(err))...)
+	}
+
+	go func() {
+		provider.run(req)
+		c.providersMu.Lock()
+		delete(c.activeRequests, req.state)
+		c.providersMu.Unlock()
+		close(req.state.done)
+	}()
+}
+
+func (c *Client) notifyProviderError(name string, err *errors.DeepstreamError) {
+	c.providersMu.Lock()
+	provider := c.providers[name]
+	c.providersMu.Unlock()
+
+	if provider != nil && provider.options.OnError != nil {
+		provider.options.OnError(err)
+		return
+	}
+	c.reportError(err)
+}
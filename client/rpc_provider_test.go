@@ -0,0 +1,183 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RPC provider", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var clock *testing.FakeClock
+		var cli *client.Client
+		var requests chan *client.RPCRequest
+		var release chan struct{}
+
+		// provide answers requests and returns once released, the handler may
+		// outlive the spec so it uses its own channels and doesn't assert anything
+		provide := func(options ...client.ProviderOption) {
+			requests, release := requests, release
+			Expect(cli.Provide("toUppercase", func(req *client.RPCRequest) {
+				req.Send("done")
+				requests <- req
+				<-release
+			}, options...)).To(Succeed())
+		}
+
+		request := func(id string) {
+			Expect(protocol.DeliverRaw(raw("P", "REQ", "toUppercase", id, "Sabc"))).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			clock = testing.NewFakeClock()
+			cli = loggedInClient(protocol, client.WithClock(clock))
+			requests = make(chan *client.RPCRequest, 10)
+			release = make(chan struct{})
+		})
+
+		AfterEach(func() {
+			select {
+			case <-release:
+			default:
+				close(release)
+			}
+			cli.Close()
+		})
+
+		It("Should reject requests past MaxInFlight and MaxQueued", func() {
+			provide(client.WithMaxInFlight(1), client.WithMaxQueued(1))
+			request("1")
+			request("2")
+			request("3")
+
+			_, err := protocol.WaitForSent(`^P\|REJ\|toUppercase\|3$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(protocol).To(HaveSentMessage("P|A|REQ|toUppercase|1"))
+			Expect(protocol).To(HaveSentMessage("P|A|REQ|toUppercase|2"))
+			Eventually(requests).Should(Receive())
+			Consistently(requests, 50*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("Should hold the slot until the handler returns", func() {
+			provide(client.WithMaxInFlight(1), client.WithMaxQueued(1))
+			request("1")
+			request("2")
+
+			var first *client.RPCRequest
+			Eventually(requests).Should(Receive(&first))
+			Expect(first.Response()).NotTo(BeNil())
+			Consistently(requests, 50*time.Millisecond).ShouldNot(Receive())
+
+			release <- struct{}{}
+			Eventually(requests).Should(Receive())
+		})
+
+		It("Should answer requests that time out while queued", func() {
+			provide(client.WithMaxInFlight(1), client.WithMaxQueued(1))
+			request("1")
+			Eventually(requests).Should(Receive())
+			request("2")
+			_, err := protocol.WaitForSent(`^P\|A\|REQ\|toUppercase\|2$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+
+			// the timer of the first request stopped once it was answered
+			Expect(clock.WaitForTimers(1, time.Second)).To(Succeed())
+			clock.Advance(cli.Options.RPCResponseTimeout)
+			_, err = protocol.WaitForSent(`^P\|E\|RESPONSE_TIMEOUT\|toUppercase\|2$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).NotTo(Receive())
+		})
+
+		It("Should answer with an error when the handler panics", func() {
+			Expect(cli.Provide("toUppercase", func(req *client.RPCRequest) {
+				panic("boom")
+			})).To(Succeed())
+			request("1")
+
+			_, err := protocol.WaitForSent(`^P\|E\|boom\|toUppercase\|1$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+
+			// the client keeps serving requests
+			request("2")
+			_, err = protocol.WaitForSent(`^P\|E\|boom\|toUppercase\|2$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should answer with an error when a typed handler panics", func() {
+			Expect(cli.ProvideTyped("toUppercase", func(ctx context.Context, data string) (string, error) {
+				panic("boom")
+			})).To(Succeed())
+			request("1")
+
+			_, err := protocol.WaitForSent(`^P\|E\|boom\|toUppercase\|1$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should drain once the handlers of in-flight requests returned", func() {
+			provide()
+			request("1")
+			Eventually(requests).Should(Receive())
+
+			result := make(chan error, 1)
+			go func() {
+				result <- cli.Drain(context.Background())
+			}()
+			_, err := protocol.WaitForSent(`^P\|US\|toUppercase$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(protocol.DeliverRaw(raw("P", "A", "US", "toUppercase"))).To(Succeed())
+
+			// requests received while draining are routed to other providers
+			request("2")
+			_, err = protocol.WaitForSent(`^P\|REJ\|toUppercase\|2$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+
+			Consistently(result, 50*time.Millisecond).ShouldNot(Receive())
+			close(release)
+			Eventually(result).Should(Receive(BeNil()))
+		})
+
+		It("Should release every subscription when draining", func() {
+			readRecord(cli, protocol, "user/Lisa", 1, `{"name":"Lisa"}`)
+			_, err := cli.SubscribeEvent("news", func(data interface{}) {})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cli.ListenEvents("news/.*", func(string, bool) bool { return true })).To(Succeed())
+			Expect(cli.ListenRecords("user/.*", func(string, bool) bool { return true })).To(Succeed())
+			_, err = cli.SubscribePresence(func(string, bool) {})
+			Expect(err).NotTo(HaveOccurred())
+			protocol.ResetSent()
+
+			Expect(cli.Drain(context.Background())).To(Succeed())
+			Expect(protocol).To(HaveSentMessage("R|US|user/Lisa"))
+			Expect(protocol).To(HaveSentMessage("E|US|news"))
+			Expect(protocol).To(HaveSentMessage("E|UL|news/.*"))
+			Expect(protocol).To(HaveSentMessage("R|UL|user/.*"))
+			Expect(protocol).To(HaveSentMessage("U|US|US"))
+			Expect(protocol).To(HaveSentMessages(5))
+		})
+
+		It("Should stop draining when the context is done", func() {
+			provide()
+			request("1")
+			Eventually(requests).Should(Receive())
+			Expect(protocol.DeliverRaw(raw("P", "A", "US", "toUppercase"))).To(Succeed())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			Expect(cli.Drain(ctx)).To(MatchError(context.DeadlineExceeded))
+		})
+	})
+})
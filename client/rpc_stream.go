@@ -0,0 +1,187 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// streamEventPrefix prefixes the event carrying the chunks of a request, see MakeStream
+const streamEventPrefix = "rpc-stream/"
+
+// streamRequest wraps the request data of a streaming RPC with the event its
+// chunks must be emitted on
+type streamRequest struct {
+	Stream string      `ds:"stream"`
+	Data   interface{} `ds:"data"`
+}
+
+//RPCStreamHandler is called for every request of an RPC provided with ProvideStream.
+//It pushes chunks with stream.Send and must answer the request with req.Send,
+//req.Error or req.Reject once done.
+type RPCStreamHandler func(req *RPCRequest, stream *RPCStreamWriter)
+
+//RPCStreamWriter pushes the chunks of a streaming RPC response to its caller
+type RPCStreamWriter struct {
+	client *Client
+	req    *RPCRequest
+	event  string
+}
+
+//Send pushes chunk to the caller. It fails once the request is answered or
+//timed out.
+func (w *RPCStreamWriter) Send(chunk interface{}) error {
+	if err := w.req.Context().Err(); err != nil {
+		return err
+	}
+	if w.event == "" {
+		return fmt.Errorf("RPC %s with id %s wasn't requested as a stream", w.req.Name, w.req.CorrelationID)
+	}
+	return w.client.EmitEvent(w.event, chunk)
+}
+
+//ProvideStream registers handler as the provider of a streaming RPC with the given
+//name. Requests made with Make instead of MakeStream are handled too, but their
+//chunks can't be sent.
+func (c *Client) ProvideStream(name string, handler RPCStreamHandler, options ...ProviderOption) error {
+	return c.Provide(name, func(req *RPCRequest) {
+		writer := &RPCStreamWriter{client: c, req: req}
+		if envelope, ok := req.Data.(map[string]interface{}); ok {
+			if event, ok := envelope["stream"].(string); ok {
+				writer.event = event
+				req.Data = envelope["data"]
+			}
+		}
+		handler(req, writer)
+	}, options...)
+}
+
+//RPCStream is the response of a streaming RPC. Chunks must be read until the
+//channel is closed, or the stream closed, to release it.
+type RPCStream struct {
+	cancel   context.CancelFunc
+	chunks   chan interface{}
+	ready    chan struct{}
+	finished chan struct{}
+
+	mu    sync.Mutex
+	queue []interface{}
+	data  interface{}
+	err   error
+}
+
+//MakeStream requests the streaming RPC with the given name. Chunks are received on
+//an event named rpc-stream/ followed by the correlation id of the stream, subscribed
+//to for the duration of the request. Cancelling ctx or closing the stream
+//unsubscribes from it. The event isn't private: any client may subscribe or emit
+//to it, so chunks shouldn't carry secrets unless the server permissions restrict
+//the rpc-stream/ events.
+func (c *Client) MakeStream(ctx context.Context, name string, data interface{}) (*RPCStream, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream := &RPCStream{
+		cancel:   cancel,
+		chunks:   make(chan interface{}),
+		ready:    make(chan struct{}, 1),
+		finished: make(chan struct{}),
+	}
+
+	event := streamEventPrefix + c.newUID()
+	subID, err := c.SubscribeEvent(event, stream.push)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go stream.pump(ctx)
+	go func() {
+		result, err := c.MakeContext(ctx, name, &streamRequest{Stream: event, Data: data})
+		c.UnsubscribeEvent(event, subID)
+
+		stream.mu.Lock()
+		stream.data, stream.err = result, err
+		stream.mu.Unlock()
+		close(stream.finished)
+	}()
+	return stream, nil
+}
+
+//Chunks returns the chunks pushed by the provider, closed once the final response
+//is received and every chunk before it was read, or the stream is cancelled
+func (s *RPCStream) Chunks() <-chan interface{} {
+	return s.chunks
+}
+
+//Result waits for the final response of the RPC, failing like MakeContext
+func (s *RPCStream) Result() (interface{}, error) {
+	<-s.finished
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data, s.err
+}
+
+//Close cancels the request and stops receiving chunks
+func (s *RPCStream) Close() {
+	s.cancel()
+}
+
+// push queues a chunk without blocking the connection read loop
+func (s *RPCStream) push(chunk interface{}) {
+	s.mu.Lock()
+	s.queue = append(s.queue, chunk)
+	s.mu.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (s *RPCStream) pop() (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queue) == 0 {
+		return nil, false
+	}
+	chunk := s.queue[0]
+	s.queue = s.queue[1:]
+	return chunk, true
+}
+
+// pump delivers queued chunks in order until the response is received and the
+// queue is empty, or ctx is done
+func (s *RPCStream) pump(ctx context.Context) {
+	defer s.cancel()
+	defer close(s.chunks)
+
+	finished := s.finished
+	for {
+		if chunk, ok := s.pop(); ok {
+			select {
+			case s.chunks <- chunk:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		if finished == nil {
+			return
+		}
+		select {
+		case <-s.ready:
+		case <-finished:
+			finished = nil
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RPC streams", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var cli *client.Client
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			cli = loggedInClient(protocol, client.WithUIDs(testing.SequentialUIDs()))
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		Describe("MakeStream", func() {
+			// makeStream requests count, its chunks go to rpc-stream/1 and its id is 2
+			makeStream := func(ctx context.Context) *client.RPCStream {
+				stream, err := cli.MakeStream(ctx, "count", 3)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol).To(HaveSentMessage("E|S|rpc-stream/1"))
+				_, err = protocol.WaitForSent(`^P\|REQ\|count\|2\|O\{"data":3,"stream":"rpc-stream/1"\}$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				return stream
+			}
+
+			It("Should receive the chunks in order until the response", func() {
+				stream := makeStream(context.Background())
+				for _, chunk := range []string{"N1", "N2", "N3"} {
+					Expect(protocol.DeliverRaw(raw("E", "EVT", "rpc-stream/1", chunk))).To(Succeed())
+				}
+				Expect(protocol.DeliverRaw(raw("P", "RES", "count", "2", "Sdone"))).To(Succeed())
+
+				chunks := []interface{}{}
+				for chunk := range stream.Chunks() {
+					chunks = append(chunks, chunk)
+				}
+				Expect(chunks).To(Equal([]interface{}{1.0, 2.0, 3.0}))
+				Expect(stream.Result()).To(Equal("done"))
+				_, err := protocol.WaitForSent(`^E\|US\|rpc-stream/1$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Should end the stream with the error of the provider", func() {
+				stream := makeStream(context.Background())
+				Expect(protocol.DeliverRaw(raw("E", "EVT", "rpc-stream/1", "N1"))).To(Succeed())
+				Expect(protocol.DeliverRaw(raw("P", "E", "too many", "count", "2"))).To(Succeed())
+
+				Eventually(stream.Chunks()).Should(Receive(Equal(1.0)))
+				Eventually(stream.Chunks()).Should(BeClosed())
+				_, err := stream.Result()
+				Expect(err).To(MatchError("RPC count failed: too many"))
+			})
+
+			It("Should stop and unsubscribe once closed", func() {
+				stream := makeStream(context.Background())
+				stream.Close()
+
+				Eventually(stream.Chunks()).Should(BeClosed())
+				_, err := stream.Result()
+				Expect(err).To(Equal(context.Canceled))
+				_, err = protocol.WaitForSent(`^E\|US\|rpc-stream/1$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Should stop once the context is cancelled", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				stream := makeStream(ctx)
+				Expect(protocol.DeliverRaw(raw("E", "EVT", "rpc-stream/1", "N1"))).To(Succeed())
+				cancel()
+
+				Eventually(stream.Chunks()).Should(BeClosed())
+				_, err := stream.Result()
+				Expect(err).To(Equal(context.Canceled))
+				_, err = protocol.WaitForSent(`^E\|US\|rpc-stream/1$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Describe("ProvideStream", func() {
+			var errs chan error
+
+			BeforeEach(func() {
+				errs = make(chan error, 1)
+				Expect(cli.ProvideStream("count", func(req *client.RPCRequest, stream *client.RPCStreamWriter) {
+					count := int(req.Data.(float64))
+					for i := 1; i <= count; i++ {
+						if err := stream.Send(i); err != nil {
+							errs <- err
+							req.Error(err.Error())
+							return
+						}
+					}
+					req.Send("done")
+					errs <- stream.Send(count + 1)
+				})).To(Succeed())
+			})
+
+			It("Should send the chunks on the stream event before the response", func() {
+				Expect(protocol.DeliverRaw(raw("P", "REQ", "count", "1", `O{"data":2,"stream":"rpc-stream/a"}`))).To(Succeed())
+				_, err := protocol.WaitForSent(`^P\|RES\|count\|1\|Sdone$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+
+				sent := []string{}
+				for _, action := range protocol.Sent() {
+					sent = append(sent, action.Readable())
+				}
+				Expect(sent[len(sent)-3:]).To(Equal([]string{
+					"E|EVT|rpc-stream/a|N1", "E|EVT|rpc-stream/a|N2", "P|RES|count|1|Sdone",
+				}))
+				// the stream ends with the response
+				Eventually(errs).Should(Receive(HaveOccurred()))
+			})
+
+			It("Should fail to send chunks to requests made without a stream", func() {
+				Expect(protocol.DeliverRaw(raw("P", "REQ", "count", "1", "N2"))).To(Succeed())
+				_, err := protocol.WaitForSent(`^P\|E\|.*wasn't requested as a stream\|count\|1$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(errs).Should(Receive(MatchError(ContainSubstring("wasn't requested as a stream"))))
+			})
+		})
+	})
+})
@@ -0,0 +1,127 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RPC", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var clock *testing.FakeClock
+		var cli *client.Client
+
+		type rpcOutcome struct {
+			data interface{}
+			err  error
+		}
+
+		// request calls toUppercase with abc in the background, its id is 1
+		request := func(ctx context.Context) chan rpcOutcome {
+			outcome := make(chan rpcOutcome, 1)
+			go func() {
+				data, err := cli.MakeContext(ctx, "toUppercase", "abc")
+				outcome <- rpcOutcome{data, err}
+			}()
+			_, err := protocol.WaitForSent(`^P\|REQ\|toUppercase\|1\|Sabc$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			return outcome
+		}
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			clock = testing.NewFakeClock()
+			cli = loggedInClient(protocol, client.WithClock(clock), client.WithUIDs(testing.SequentialUIDs()))
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		It("Should return the response of the provider", func() {
+			outcome := request(context.Background())
+			Expect(protocol.DeliverRaw(raw("P", "A", "REQ", "toUppercase", "1"))).To(Succeed())
+			Expect(protocol.DeliverRaw(raw("P", "RES", "toUppercase", "1", "SABC"))).To(Succeed())
+			Eventually(outcome).Should(Receive(Equal(rpcOutcome{data: "ABC"})))
+		})
+
+		It("Should stop waiting once the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			outcome := request(ctx)
+			cancel()
+
+			var result rpcOutcome
+			Eventually(outcome).Should(Receive(&result))
+			Expect(result.err).To(Equal(context.Canceled))
+		})
+
+		It("Should fail when the request isn't acknowledged in time", func() {
+			outcome := request(context.Background())
+			Expect(clock.WaitForTimers(2, time.Second)).To(Succeed())
+			clock.Advance(cli.Options.RPCAckTimeout)
+
+			var result rpcOutcome
+			Eventually(outcome).Should(Receive(&result))
+			Expect(errors.Is(result.err, errors.ErrAckTimeout)).To(BeTrue())
+			Expect(errors.Is(result.err, &errors.DeepstreamError{Topic: interfaces.TopicRPC, Event: errors.ErrAckTimeout.Event})).To(BeTrue())
+		})
+
+		It("Should fail when the acknowledged request isn't answered in time", func() {
+			outcome := request(context.Background())
+			Expect(clock.WaitForTimers(2, time.Second)).To(Succeed())
+			Expect(protocol.DeliverRaw(raw("P", "A", "REQ", "toUppercase", "1"))).To(Succeed())
+			Eventually(clock.Timers).Should(Equal(1))
+			clock.Advance(cli.Options.RPCAckTimeout)
+			Consistently(outcome, 50*time.Millisecond).ShouldNot(Receive())
+
+			clock.Advance(cli.Options.RPCResponseTimeout)
+			var result rpcOutcome
+			Eventually(outcome).Should(Receive(&result))
+			Expect(errors.Is(result.err, errors.ErrResponseTimeout)).To(BeTrue())
+		})
+
+		It("Should fail when nobody provides the RPC", func() {
+			outcome := request(context.Background())
+			Expect(protocol.DeliverRaw(raw("P", "E", "NO_RPC_PROVIDER", "toUppercase", "1"))).To(Succeed())
+
+			var result rpcOutcome
+			Eventually(outcome).Should(Receive(&result))
+			Expect(errors.Is(result.err, errors.ErrNoRPCProvider)).To(BeTrue())
+		})
+
+		It("Should fail with the error sent by the provider", func() {
+			outcome := request(context.Background())
+			Expect(protocol.DeliverRaw(raw("P", "E", "invalid input", "toUppercase", "1"))).To(Succeed())
+
+			var result rpcOutcome
+			Eventually(outcome).Should(Receive(&result))
+			var providerErr *errors.RPCProviderError
+			Expect(errors.As(result.err, &providerErr)).To(BeTrue())
+			Expect(providerErr.Name).To(Equal("toUppercase"))
+			Expect(providerErr.Message).To(Equal("invalid input"))
+		})
+
+		It("Should fail with the error of the server event", func() {
+			outcome := request(context.Background())
+			Expect(protocol.DeliverRaw(raw("P", "E", "MESSAGE_DENIED", "toUppercase", "1"))).To(Succeed())
+
+			var result rpcOutcome
+			Eventually(outcome).Should(Receive(&result))
+			Expect(errors.Is(result.err, errors.ErrMessageDenied)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,78 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+//HasRecord tells whether the record with the given name exists without
+//subscribing to it. It fails with ErrResponseTimeout if the server doesn't
+//answer within RecordReadTimeout.
+func (c *Client) HasRecord(name string) (bool, error) {
+	data, err := c.hasRequests.request(c, name, c.Options.RecordReadTimeout, func() error {
+		return c.sendRecordAction(interfaces.ActionHas, name)
+	})
+	if err != nil {
+		return false, err
+	}
+	return data.(bool), nil
+}
+
+//SnapshotRecord returns the current data of the record with the given name
+//without subscribing to it. It fails with ErrRecordNotFound if the record
+//doesn't exist, or ErrResponseTimeout if the server doesn't answer within
+//RecordReadTimeout.
+func (c *Client) SnapshotRecord(name string) (interface{}, error) {
+	return c.snapshots.request(c, name, c.Options.RecordReadTimeout, func() error {
+		return c.sendRecordAction(interfaces.ActionSnapshot, name)
+	})
+}
+
+// handleHas answers the pending has requests with R|H|recordName|T
+func (c *Client) handleHas(msg *message.Message) {
+	exists, err := message.ParseTyped(msg.RawData[1])
+	if _, ok := exists.(bool); err != nil || !ok {
+		c.log(interfaces.LogLevelWarn, "handleRecord: invalid data", messageFields(msg, errField(err))...)
+		return
+	}
+	c.hasRequests.resolve(msg.RawData[0], exists, nil)
+}
+
+// resolveSnapshot answers the pending snapshots of the record read by R|R
+func (c *Client) resolveSnapshot(msg *message.Message) {
+	if len(msg.RawData) < 3 {
+		return
+	}
+	var data interface{}
+	if err := json.Unmarshal([]byte(msg.RawData[2]), &data); err != nil {
+		c.snapshots.resolve(msg.RawData[0], nil, err)
+		return
+	}
+	c.snapshots.resolve(msg.RawData[0], data, nil)
+}
+
+// handleQueryError fails the pending has requests or snapshots of a record with
+// R|E|SN|recordName|RECORD_NOT_FOUND, and returns false if there was none
+func (c *Client) handleQueryError(msg *message.Message) bool {
+	event, reason := "", ""
+	if len(msg.RawData) > 2 {
+		event = msg.RawData[2]
+		reason = strings.Join(msg.RawData[3:], " ")
+	}
+	err := errors.NewDeepstreamError(msg.Topic, event, reason, msg.Raw)
+	if msg.RawData[0] == interfaces.ActionHas {
+		return c.hasRequests.resolve(msg.RawData[1], nil, err)
+	}
+	return c.snapshots.resolve(msg.RawData[1], nil, err)
+}
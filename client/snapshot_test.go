@@ -0,0 +1,152 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client_test
+
+import (
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Record queries", func() {
+	Describe("[Unit]", func() {
+		var protocol *testing.MockProtocol
+		var clock *testing.FakeClock
+		var cli *client.Client
+
+		BeforeEach(func() {
+			protocol = testing.NewMockProtocol()
+			clock = testing.NewFakeClock()
+			cli = loggedInClient(protocol, client.WithClock(clock))
+		})
+
+		AfterEach(func() {
+			cli.Close()
+		})
+
+		Describe("HasRecord", func() {
+			It("Should tell whether the record exists", func() {
+				result := make(chan bool, 1)
+				go func() {
+					defer GinkgoRecover()
+					exists, err := cli.HasRecord("user/Lisa")
+					Expect(err).NotTo(HaveOccurred())
+					result <- exists
+				}()
+				_, err := protocol.WaitForSent(`^R\|H\|user/Lisa$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol.DeliverRaw(raw("R", "H", "user/Lisa", "T"))).To(Succeed())
+				Eventually(result).Should(Receive(BeTrue()))
+
+				go func() {
+					defer GinkgoRecover()
+					exists, err := cli.HasRecord("user/Bart")
+					Expect(err).NotTo(HaveOccurred())
+					result <- exists
+				}()
+				_, err = protocol.WaitForSent(`^R\|H\|user/Bart$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol.DeliverRaw(raw("R", "H", "user/Bart", "F"))).To(Succeed())
+				Eventually(result).Should(Receive(BeFalse()))
+			})
+
+			It("Should share the request between concurrent callers", func() {
+				result := make(chan bool, 2)
+				for i := 0; i < 2; i++ {
+					go func() {
+						defer GinkgoRecover()
+						exists, err := cli.HasRecord("user/Lisa")
+						Expect(err).NotTo(HaveOccurred())
+						result <- exists
+					}()
+				}
+				Expect(clock.WaitForTimers(2, time.Second)).To(Succeed())
+				Expect(protocol).To(HaveSentMessages(1))
+
+				Expect(protocol.DeliverRaw(raw("R", "H", "user/Lisa", "T"))).To(Succeed())
+				Eventually(result).Should(Receive(BeTrue()))
+				Eventually(result).Should(Receive(BeTrue()))
+			})
+
+			It("Should fail with the error sent by the server", func() {
+				result := make(chan error, 1)
+				go func() {
+					_, err := cli.HasRecord("user/Lisa")
+					result <- err
+				}()
+				_, err := protocol.WaitForSent(`^R\|H\|user/Lisa$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol.DeliverRaw(raw("R", "E", "H", "user/Lisa", "STORAGE_RETRIEVAL_TIMEOUT"))).To(Succeed())
+
+				var hasErr error
+				Eventually(result).Should(Receive(&hasErr))
+				Expect(errors.Is(hasErr, errors.ErrStorageRetrievalTimeout)).To(BeTrue())
+			})
+
+			It("Should time out when the server doesn't answer", func() {
+				result := make(chan error, 1)
+				go func() {
+					_, err := cli.HasRecord("user/Lisa")
+					result <- err
+				}()
+				Expect(clock.WaitForTimers(1, time.Second)).To(Succeed())
+				clock.Advance(cli.Options.RecordReadTimeout)
+				Eventually(result).Should(Receive(MatchError(errors.ErrResponseTimeout)))
+			})
+		})
+
+		Describe("SnapshotRecord", func() {
+			It("Should return the record data without subscribing to it", func() {
+				result := make(chan interface{}, 1)
+				go func() {
+					defer GinkgoRecover()
+					data, err := cli.SnapshotRecord("user/Lisa")
+					Expect(err).NotTo(HaveOccurred())
+					result <- data
+				}()
+				_, err := protocol.WaitForSent(`^R\|SN\|user/Lisa$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol.DeliverRaw(raw("R", "R", "user/Lisa", "3", `{"name":"Lisa"}`))).To(Succeed())
+
+				Eventually(result).Should(Receive(Equal(map[string]interface{}{"name": "Lisa"})))
+				Expect(protocol).To(HaveSentMessages(1))
+			})
+
+			It("Should fail when the record doesn't exist", func() {
+				result := make(chan error, 1)
+				go func() {
+					_, err := cli.SnapshotRecord("user/Lisa")
+					result <- err
+				}()
+				_, err := protocol.WaitForSent(`^R\|SN\|user/Lisa$`, time.Second)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(protocol.DeliverRaw(raw("R", "E", "SN", "user/Lisa", "RECORD_NOT_FOUND"))).To(Succeed())
+
+				var snapshotErr error
+				Eventually(result).Should(Receive(&snapshotErr))
+				Expect(errors.Is(snapshotErr, errors.ErrRecordNotFound)).To(BeTrue())
+			})
+
+			It("Should time out when the server doesn't answer", func() {
+				result := make(chan error, 1)
+				go func() {
+					_, err := cli.SnapshotRecord("user/Lisa")
+					result <- err
+				}()
+				Expect(clock.WaitForTimers(1, time.Second)).To(Succeed())
+				clock.Advance(cli.Options.RecordReadTimeout)
+				Eventually(result).Should(Receive(MatchError(errors.ErrResponseTimeout)))
+			})
+		})
+	})
+})
@@ -0,0 +1,70 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+//ProvideTyped registers fn as the provider of the RPC with the given name. fn must be
+//a func(context.Context, ReqType) (RespType, error): request data is decoded into
+//ReqType, the returned value is sent as the response and a returned error or a panic
+//is sent as an RPC error.
+func (c *Client) ProvideTyped(name string, fn interface{}, options ...ProviderOption) error {
+	handler, err := typedRPCHandler(fn)
+	if err != nil {
+		return err
+	}
+	return c.Provide(name, handler, options...)
+}
+
+func typedRPCHandler(fn interface{}) (RPCHandler, error) {
+	fnValue := reflect.ValueOf(fn)
+	fnType := fnValue.Type()
+	if fnType.Kind() != reflect.Func ||
+		fnType.NumIn() != 2 || fnType.In(0) != contextType ||
+		fnType.NumOut() != 2 || fnType.Out(1) != errorType {
+		return nil, fmt.Errorf("RPC provider must be a func(context.Context, Request) (Response, error), got %s", fnType)
+	}
+	reqType := fnType.In(1)
+
+	return func(req *RPCRequest) {
+		in := reflect.New(reqType)
+		if err := decodeData(req.Data, in.Interface()); err != nil {
+			req.Error(err.Error())
+			return
+		}
+
+		out := fnValue.Call([]reflect.Value{reflect.ValueOf(req.Context()), in.Elem()})
+		if err, _ := out[1].Interface().(error); err != nil {
+			req.Error(err.Error())
+			return
+		}
+		req.Send(out[0].Interface())
+	}, nil
+}
+
+//MakeTyped requests the RPC with the given name sending req, and decodes
+//the result into resp, which must be a pointer
+func (c *Client) MakeTyped(ctx context.Context, name string, req interface{}, resp interface{}) error {
+	data, err := c.MakeContext(ctx, name, req)
+	if err != nil {
+		return err
+	}
+	if resp == nil {
+		return nil
+	}
+	return decodeData(data, resp)
+}
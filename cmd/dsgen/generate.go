@@ -0,0 +1,108 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"text/template"
+)
+
+var stubsTemplate = template.Must(template.New("stubs").Parse(`// Code generated by dsgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	{{- if .RPCs}}
+	"context"
+	{{- end}}
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+)
+{{- if .RPCs}}
+{{- if not .Interface}}
+
+//Provider is implemented by the providers of the RPCs
+type Provider interface {
+{{- range .RPCs}}
+	{{.Method}}(ctx context.Context, req {{.Request}}) ({{.Response}}, error)
+{{- end}}
+}
+{{- end}}
+
+//RegisterProvider provides every RPC using p
+func RegisterProvider(c *client.Client, p {{if .Interface}}{{.Interface}}{{else}}Provider{{end}}, options ...client.ProviderOption) error {
+{{- range .RPCs}}
+	if err := c.ProvideTyped("{{.Name}}", p.{{.Method}}, options...); err != nil {
+		return err
+	}
+{{- end}}
+	return nil
+}
+{{- end}}
+{{- range .RPCs}}
+
+//{{.Method}} requests the {{.Name}} RPC
+func {{.Method}}(ctx context.Context, c *client.Client, req {{.Request}}) ({{.Response}}, error) {
+	var resp {{.Response}}
+	err := c.MakeTyped(ctx, "{{.Name}}", req, &resp)
+	return resp, err
+}
+{{- end}}
+{{- range .Events}}
+
+//Emit{{.Method}} emits the {{.Name}} event
+func Emit{{.Method}}(c *client.Client, data {{.Data}}) error {
+	return c.EmitEvent("{{.Name}}", data)
+}
+
+//Subscribe{{.Method}} subscribes to the {{.Name}} event, it returns an id for c.UnsubscribeEvent.
+//Data that can't be decoded and server errors about the event are reported to
+//onError, or to ClientOptions.OnError if onError is nil.
+func Subscribe{{.Method}}(c *client.Client, callback func(data {{.Data}}), onError client.ErrorCallback) (int, error) {
+	return c.SubscribeEventTyped("{{.Name}}", callback, onError)
+}
+{{- end}}
+{{- range .Records}}
+
+//{{.Method}}Record is a record whose name starts with {{.Prefix}}
+type {{.Method}}Record struct {
+	*client.Record
+}
+
+//Get{{.Method}}Record gets the {{.Prefix}}<id> record
+func Get{{.Method}}Record(c *client.Client, id string) (*{{.Method}}Record, error) {
+	rec, err := c.GetRecord("{{.Prefix}}" + id)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Method}}Record{rec}, nil
+}
+
+//Data decodes the record data
+func (r *{{.Method}}Record) Data() ({{.Data}}, error) {
+	var data {{.Data}}
+	err := r.GetTyped("", &data)
+	return data, err
+}
+
+//SetData sends the changes in data to the server
+func (r *{{.Method}}Record) SetData(data {{.Data}}) error {
+	return r.Set(&data)
+}
+{{- end}}
+`))
+
+//Generate renders the typed stubs for schema as formatted Go source
+func Generate(schema *Schema) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := stubsTemplate.Execute(&buf, schema); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
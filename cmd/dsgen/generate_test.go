@@ -0,0 +1,119 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package main
+
+import (
+	"go/parser"
+	"go/token"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dsgen", func() {
+	Describe("Schema", func() {
+		It("Should parse a YAML schema", func() {
+			schema, err := ParseYAMLSchema([]byte(`
+package: api
+rpcs:
+  - name: add-two
+    request: AddRequest
+    response: int
+events:
+  - name: user/joined
+    data: User
+records:
+  - prefix: user/
+    method: User
+    data: User
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schema.Package).To(Equal("api"))
+			Expect(schema.RPCs).To(Equal([]RPC{{Name: "add-two", Method: "AddTwo", Request: "AddRequest", Response: "int"}}))
+			Expect(schema.Events).To(Equal([]Event{{Name: "user/joined", Method: "UserJoined", Data: "User"}}))
+			Expect(schema.Records).To(Equal([]Record{{Prefix: "user/", Method: "User", Data: "User"}}))
+		})
+
+		It("Should fail on a YAML schema without package", func() {
+			_, err := ParseYAMLSchema([]byte("rpcs: []"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should parse a Go interface", func() {
+			schema, err := ParseGoInterface("svc.go", []byte(`package svc
+
+import "context"
+
+type Calc interface {
+	// dsgen:name math/add
+	Add(ctx context.Context, req []int) (int, error)
+	Echo(context.Context, string) (string, error)
+}
+`), "Calc")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schema.Package).To(Equal("svc"))
+			Expect(schema.Interface).To(Equal("Calc"))
+			Expect(schema.RPCs).To(Equal([]RPC{
+				{Name: "math/add", Method: "Add", Request: "[]int", Response: "int"},
+				{Name: "echo", Method: "Echo", Request: "string", Response: "string"},
+			}))
+		})
+
+		It("Should fail on methods that aren't RPCs", func() {
+			_, err := ParseGoInterface("svc.go", []byte(`package svc
+
+type Calc interface {
+	Add(a, b int) int
+}
+`), "Calc")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should fail on unknown interfaces", func() {
+			_, err := ParseGoInterface("svc.go", []byte("package svc\n"), "Calc")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Generate", func() {
+		It("Should generate stubs for RPCs, events and records", func() {
+			code, err := Generate(&Schema{
+				Package: "api",
+				RPCs:    []RPC{{Name: "add-two", Method: "AddTwo", Request: "AddRequest", Response: "int"}},
+				Events:  []Event{{Name: "user/joined", Method: "UserJoined", Data: "User"}},
+				Records: []Record{{Prefix: "user/", Method: "User", Data: "User"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			file, err := parser.ParseFile(token.NewFileSet(), "api_ds.go", code, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(file.Name.Name).To(Equal("api"))
+			for _, name := range []string{"Provider", "RegisterProvider", "AddTwo", "EmitUserJoined", "SubscribeUserJoined", "UserRecord", "GetUserRecord"} {
+				Expect(file.Scope.Lookup(name)).NotTo(BeNil(), name)
+			}
+			Expect(string(code)).To(ContainSubstring(`c.ProvideTyped("add-two", p.AddTwo, options...)`))
+			Expect(string(code)).To(ContainSubstring(`c.SubscribeEventTyped("user/joined", callback, onError)`))
+			Expect(string(code)).NotTo(ContainSubstring(`"log"`))
+		})
+
+		It("Should use the parsed interface for providers", func() {
+			code, err := Generate(&Schema{
+				Package:   "svc",
+				Interface: "Calc",
+				RPCs:      []RPC{{Name: "echo", Method: "Echo", Request: "string", Response: "string"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			file, err := parser.ParseFile(token.NewFileSet(), "svc_ds.go", code, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(file.Scope.Lookup("Provider")).To(BeNil())
+			Expect(string(code)).To(ContainSubstring("p Calc"))
+			Expect(string(code)).NotTo(ContainSubstring(`"log"`))
+		})
+	})
+})
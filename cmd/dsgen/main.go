@@ -0,0 +1,62 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+// dsgen generates typed RPC, event and record stubs on top of the client.
+//
+//	dsgen -schema api.yml -out api_ds.go
+//	dsgen -go service.go -interface Service -out service_ds.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "", "YAML schema of RPCs, events and records")
+	goPath := flag.String("go", "", "Go file declaring the RPC interface")
+	iface := flag.String("interface", "", "name of the RPC interface in the Go file")
+	out := flag.String("out", "", "output file, default to stdout")
+	flag.Parse()
+
+	if err := run(*schemaPath, *goPath, *iface, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "dsgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaPath, goPath, iface, out string) error {
+	var schema *Schema
+	var err error
+	switch {
+	case schemaPath != "":
+		schema, err = LoadYAMLSchema(schemaPath)
+	case goPath != "" && iface != "":
+		var src []byte
+		if src, err = ioutil.ReadFile(goPath); err == nil {
+			schema, err = ParseGoInterface(goPath, src, iface)
+		}
+	default:
+		flag.Usage()
+		return fmt.Errorf("either -schema or -go and -interface must be given")
+	}
+	if err != nil {
+		return err
+	}
+
+	code, err := Generate(schema)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return ioutil.WriteFile(out, code, 0644)
+}
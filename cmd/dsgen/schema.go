@@ -0,0 +1,198 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/ioutil"
+	"strings"
+	"unicode"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+//Schema describes the RPCs, events and records shared between services
+type Schema struct {
+	// Interface is the existing Go interface implemented by providers, if the
+	// schema was read from one; otherwise a Provider interface is generated
+	Interface string `yaml:"-"`
+
+	Package string   `yaml:"package"`
+	RPCs    []RPC    `yaml:"rpcs"`
+	Events  []Event  `yaml:"events"`
+	Records []Record `yaml:"records"`
+}
+
+//RPC describes a remote procedure and its payloads
+type RPC struct {
+	Name     string `yaml:"name"`
+	Method   string `yaml:"method"`
+	Request  string `yaml:"request"`
+	Response string `yaml:"response"`
+}
+
+//Event describes an event and its payload
+type Event struct {
+	Name   string `yaml:"name"`
+	Method string `yaml:"method"`
+	Data   string `yaml:"data"`
+}
+
+//Record describes records whose names start with Prefix and their data
+type Record struct {
+	Prefix string `yaml:"prefix"`
+	Method string `yaml:"method"`
+	Data   string `yaml:"data"`
+}
+
+//LoadYAMLSchema reads a schema from a YAML file
+func LoadYAMLSchema(path string) (*Schema, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseYAMLSchema(raw)
+}
+
+//ParseYAMLSchema parses a YAML schema
+func ParseYAMLSchema(raw []byte) (*Schema, error) {
+	schema := &Schema{}
+	if err := yaml.Unmarshal(raw, schema); err != nil {
+		return nil, err
+	}
+	return schema, schema.normalize()
+}
+
+//ParseGoInterface builds a schema from the methods of a Go interface, each of them
+//being an RPC of the form Method(context.Context, Request) (Response, error).
+//The RPC name defaults to the method name in lower camel case and can be set
+//with a "dsgen:name <name>" comment on the method.
+func ParseGoInterface(filename string, src []byte, iface string) (*Schema, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{Package: file.Name.Name, Interface: iface}
+	found := false
+	ast.Inspect(file, func(node ast.Node) bool {
+		spec, ok := node.(*ast.TypeSpec)
+		if !ok || spec.Name.Name != iface {
+			return true
+		}
+		it, ok := spec.Type.(*ast.InterfaceType)
+		if !ok {
+			return false
+		}
+		found = true
+		for _, method := range it.Methods.List {
+			fn, ok := method.Type.(*ast.FuncType)
+			if !ok || len(method.Names) != 1 {
+				continue
+			}
+			if fn.Params.NumFields() != 2 || fn.Results.NumFields() != 2 {
+				err = fmt.Errorf("%s.%s must be func(context.Context, Request) (Response, error)", iface, method.Names[0].Name)
+				return false
+			}
+			schema.RPCs = append(schema.RPCs, RPC{
+				Name:     commentName(method.Doc),
+				Method:   method.Names[0].Name,
+				Request:  exprString(fset, fn.Params.List[len(fn.Params.List)-1].Type),
+				Response: exprString(fset, fn.Results.List[0].Type),
+			})
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("interface %s not found in %s", iface, filename)
+	}
+	return schema, schema.normalize()
+}
+
+func commentName(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		if strings.HasPrefix(line, "dsgen:name ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "dsgen:name "))
+		}
+	}
+	return ""
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, expr)
+	return buf.String()
+}
+
+// normalize fills in default method and RPC names and validates the schema
+func (s *Schema) normalize() error {
+	if s.Package == "" {
+		return fmt.Errorf("schema has no package")
+	}
+	for i := range s.RPCs {
+		rpc := &s.RPCs[i]
+		if rpc.Name == "" && rpc.Method != "" {
+			rpc.Name = lowerFirst(rpc.Method)
+		}
+		if rpc.Name == "" || rpc.Request == "" || rpc.Response == "" {
+			return fmt.Errorf("rpc %q needs a name, a request and a response type", rpc.Name)
+		}
+		if rpc.Method == "" {
+			rpc.Method = goName(rpc.Name)
+		}
+	}
+	for i := range s.Events {
+		event := &s.Events[i]
+		if event.Name == "" || event.Data == "" {
+			return fmt.Errorf("event %q needs a name and a data type", event.Name)
+		}
+		if event.Method == "" {
+			event.Method = goName(event.Name)
+		}
+	}
+	for i := range s.Records {
+		record := &s.Records[i]
+		if record.Prefix == "" || record.Data == "" {
+			return fmt.Errorf("record %q needs a prefix and a data type", record.Prefix)
+		}
+		if record.Method == "" {
+			record.Method = goName(record.Prefix)
+		}
+	}
+	return nil
+}
+
+// goName turns a deepstream name such as "user/login-attempt" into "UserLoginAttempt"
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, "")
+}
+
+func lowerFirst(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -0,0 +1,17 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package errors
+
+import "errors"
+
+var (
+	//ErrUnknownDataType error
+	ErrUnknownDataType = errors.New("Typed value could not be parsed since its type prefix is not part of the deepstream.io spec")
+	//ErrPathIndexOutOfRange error
+	ErrPathIndexOutOfRange = errors.New("Record path can't be set since one of its array indexes is past the end of the array")
+)
@@ -0,0 +1,184 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+//DeepstreamError is an error reported by the server, or detected by the client,
+//identified by one of the EVENT values of the deepstream.io spec. Use Is with the
+//Err* sentinels to branch on the failure cause.
+type DeepstreamError struct {
+	// Topic of the message that failed, empty if not topic specific
+	Topic string
+	// Event is the EVENT value of the spec, e.g. MESSAGE_DENIED
+	Event string
+	// Message describes the error, e.g. the name of the record or RPC involved
+	Message string
+	// Raw is the message received from the server, if any
+	Raw string
+}
+
+//NewDeepstreamError creates an error for event, message defaults to the
+//description of the event sentinel
+func NewDeepstreamError(topic, event, message, raw string) *DeepstreamError {
+	if message == "" {
+		if sentinel, ok := sentinels[event]; ok {
+			message = sentinel.Message
+		}
+	}
+	return &DeepstreamError{
+		Topic:   topic,
+		Event:   event,
+		Message: message,
+		Raw:     raw,
+	}
+}
+
+func (e *DeepstreamError) Error() string {
+	if e.Message == "" {
+		return e.Event
+	}
+	return fmt.Sprintf("%s: %s", e.Event, e.Message)
+}
+
+//Is reports whether target is a DeepstreamError with the same event and, if
+//target has a topic, the same topic
+func (e *DeepstreamError) Is(target error) bool {
+	t, ok := target.(*DeepstreamError)
+	if !ok {
+		return false
+	}
+	return t.Event == e.Event && (t.Topic == "" || t.Topic == e.Topic)
+}
+
+//IsKnownEvent returns true if event is one of the EVENT values of the spec
+func IsKnownEvent(event string) bool {
+	_, ok := sentinels[event]
+	return ok
+}
+
+//Is reports whether any error in err's chain matches target, see errors.Is
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+//As finds the first error in err's chain that matches target, see errors.As
+func As(err error, target interface{}) bool {
+	return errors.As(err, target)
+}
+
+func sentinel(event, message string) *DeepstreamError {
+	return &DeepstreamError{Event: event, Message: message}
+}
+
+var (
+	//ErrConnectionError error
+	ErrConnectionError = sentinel("CONNECTION_ERROR", "The connection to the server failed")
+	//ErrInvalidAuthMsg error
+	ErrInvalidAuthMsg = sentinel("INVALID_AUTH_MSG", "The authentication message could not be parsed")
+	//ErrInvalidAuthData error
+	ErrInvalidAuthData = sentinel("INVALID_AUTH_DATA", "The authentication data was rejected")
+	//ErrAuthError error
+	ErrAuthError = sentinel("AUTH_ERROR", "The authentication failed")
+	//ErrTooManyAuthAttempts error
+	ErrTooManyAuthAttempts = sentinel("TOO_MANY_AUTH_ATTEMPTS", "Too many failed authentication attempts")
+	//ErrNotAuthenticated error
+	ErrNotAuthenticated = sentinel("NOT_AUTHENTICATED", "The client is not authenticated")
+	//ErrMessagePermissionError error
+	ErrMessagePermissionError = sentinel("MESSAGE_PERMISSION_ERROR", "The permissions of the message could not be checked")
+	//ErrMessageParseError error
+	ErrMessageParseError = sentinel("MESSAGE_PARSE_ERROR", "The message could not be parsed")
+	//ErrMaximumMessageSizeExceeded error
+	ErrMaximumMessageSizeExceeded = sentinel("MAXIMUM_MESSAGE_SIZE_EXCEEDED", "The message exceeds the maximum size")
+	//ErrMessageDenied error
+	ErrMessageDenied = sentinel("MESSAGE_DENIED", "The message was denied by the permissions")
+	//ErrInvalidMessageData error
+	ErrInvalidMessageData = sentinel("INVALID_MESSAGE_DATA", "The message data is invalid")
+	//ErrUnknownTopic error
+	ErrUnknownTopic = sentinel("UNKNOWN_TOPIC", "The message topic is unknown")
+	//ErrUnknownActionEvent error, not to be confused with ErrUnknownAction raised by the parser
+	ErrUnknownActionEvent = sentinel("UNKNOWN_ACTION", "The message action is unknown")
+	//ErrMultipleSubscriptions error
+	ErrMultipleSubscriptions = sentinel("MULTIPLE_SUBSCRIPTIONS", "Already subscribed")
+	//ErrNotSubscribed error
+	ErrNotSubscribed = sentinel("NOT_SUBSCRIBED", "Not subscribed")
+	//ErrListenerExists error
+	ErrListenerExists = sentinel("LISTENER_EXISTS", "A listener for the pattern already exists")
+	//ErrNotListening error
+	ErrNotListening = sentinel("NOT_LISTENING", "Not listening to the pattern")
+	//ErrIsClosed error
+	ErrIsClosed = sentinel("IS_CLOSED", "The connection is closed")
+	//ErrAckTimeout error
+	ErrAckTimeout = sentinel("ACK_TIMEOUT", "The message was not acknowledged in time")
+	//ErrResponseTimeout error
+	ErrResponseTimeout = sentinel("RESPONSE_TIMEOUT", "The response was not received in time")
+	//ErrDeleteTimeout error
+	ErrDeleteTimeout = sentinel("DELETE_TIMEOUT", "The deletion was not acknowledged in time")
+	//ErrUnsolicitedMessage error
+	ErrUnsolicitedMessage = sentinel("UNSOLICITED_MESSAGE", "An unexpected message was received")
+	//ErrMultipleAck error
+	ErrMultipleAck = sentinel("MULTIPLE_ACK", "The message was acknowledged more than once")
+	//ErrMultipleResponse error
+	ErrMultipleResponse = sentinel("MULTIPLE_RESPONSE", "The request was responded to more than once")
+	//ErrNoRPCProvider error
+	ErrNoRPCProvider = sentinel("NO_RPC_PROVIDER", "There is no provider for the requested RPC")
+	//ErrRecordLoadError error
+	ErrRecordLoadError = sentinel("RECORD_LOAD_ERROR", "The record could not be loaded")
+	//ErrRecordCreateError error
+	ErrRecordCreateError = sentinel("RECORD_CREATE_ERROR", "The record could not be created")
+	//ErrRecordUpdateError error
+	ErrRecordUpdateError = sentinel("RECORD_UPDATE_ERROR", "The record could not be updated")
+	//ErrRecordDeleteError error
+	ErrRecordDeleteError = sentinel("RECORD_DELETE_ERROR", "The record could not be deleted")
+	//ErrRecordSnapshotError error
+	ErrRecordSnapshotError = sentinel("RECORD_SNAPSHOT_ERROR", "The record snapshot could not be retrieved")
+	//ErrRecordNotFound error
+	ErrRecordNotFound = sentinel("RECORD_NOT_FOUND", "The record was not found")
+	//ErrCacheRetrievalTimeout error
+	ErrCacheRetrievalTimeout = sentinel("CACHE_RETRIEVAL_TIMEOUT", "The cache did not respond in time")
+	//ErrStorageRetrievalTimeout error
+	ErrStorageRetrievalTimeout = sentinel("STORAGE_RETRIEVAL_TIMEOUT", "The storage did not respond in time")
+	//ErrClosedSocketInteraction error
+	ErrClosedSocketInteraction = sentinel("CLOSED_SOCKET_INTERACTION", "The socket is closed")
+	//ErrClientDisconnected error
+	ErrClientDisconnected = sentinel("CLIENT_DISCONNECTED", "The client disconnected")
+	//ErrInvalidMessage error
+	ErrInvalidMessage = sentinel("INVALID_MESSAGE", "The message is invalid")
+	//ErrVersionExists error
+	ErrVersionExists = sentinel("VERSION_EXISTS", "The record version already exists")
+	//ErrInvalidVersion error
+	ErrInvalidVersion = sentinel("INVALID_VERSION", "The record version is invalid")
+	//ErrPluginError error
+	ErrPluginError = sentinel("PLUGIN_ERROR", "A server plugin failed")
+	//ErrUnknownCallee error
+	ErrUnknownCallee = sentinel("UNKNOWN_CALLEE", "The callee of the message is unknown")
+)
+
+var sentinels = map[string]*DeepstreamError{}
+
+func init() {
+	for _, err := range []*DeepstreamError{
+		ErrConnectionError, ErrInvalidAuthMsg, ErrInvalidAuthData, ErrAuthError,
+		ErrTooManyAuthAttempts, ErrNotAuthenticated, ErrMessagePermissionError,
+		ErrMessageParseError, ErrMaximumMessageSizeExceeded, ErrMessageDenied,
+		ErrInvalidMessageData, ErrUnknownTopic, ErrUnknownActionEvent,
+		ErrMultipleSubscriptions, ErrNotSubscribed, ErrListenerExists, ErrNotListening,
+		ErrIsClosed, ErrAckTimeout, ErrResponseTimeout, ErrDeleteTimeout,
+		ErrUnsolicitedMessage, ErrMultipleAck, ErrMultipleResponse, ErrNoRPCProvider,
+		ErrRecordLoadError, ErrRecordCreateError, ErrRecordUpdateError,
+		ErrRecordDeleteError, ErrRecordSnapshotError, ErrRecordNotFound,
+		ErrCacheRetrievalTimeout, ErrStorageRetrievalTimeout, ErrClosedSocketInteraction,
+		ErrClientDisconnected, ErrInvalidMessage, ErrVersionExists, ErrInvalidVersion,
+		ErrPluginError, ErrUnknownCallee,
+	} {
+		sentinels[err.Event] = err
+	}
+}
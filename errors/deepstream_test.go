@@ -0,0 +1,53 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package errors_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+)
+
+var _ = Describe("DeepstreamError", func() {
+	It("Should match sentinels by event", func() {
+		err := errors.NewDeepstreamError("R", "RECORD_NOT_FOUND", "user/1", "R|E|RECORD_NOT_FOUND|user/1")
+		Expect(errors.Is(err, errors.ErrRecordNotFound)).To(BeTrue())
+		Expect(errors.Is(err, errors.ErrMessageDenied)).To(BeFalse())
+		Expect(err.Error()).To(Equal("RECORD_NOT_FOUND: user/1"))
+	})
+
+	It("Should match topic specific sentinels by topic", func() {
+		rpcAckTimeout := &errors.DeepstreamError{Topic: "P", Event: errors.ErrAckTimeout.Event}
+		err := errors.NewDeepstreamError("P", "ACK_TIMEOUT", "", "")
+		Expect(errors.Is(err, rpcAckTimeout)).To(BeTrue())
+		Expect(errors.Is(err, errors.ErrAckTimeout)).To(BeTrue())
+		Expect(errors.Is(errors.NewDeepstreamError("R", "ACK_TIMEOUT", "", ""), rpcAckTimeout)).To(BeFalse())
+	})
+
+	It("Should default the message to the event description", func() {
+		err := errors.NewDeepstreamError("E", "MESSAGE_DENIED", "", "")
+		Expect(err.Message).To(Equal(errors.ErrMessageDenied.Message))
+	})
+
+	It("Should match wrapped errors", func() {
+		err := fmt.Errorf("subscribing: %w", errors.NewDeepstreamError("E", "MESSAGE_DENIED", "news", ""))
+		Expect(errors.Is(err, errors.ErrMessageDenied)).To(BeTrue())
+
+		var dsErr *errors.DeepstreamError
+		Expect(errors.As(err, &dsErr)).To(BeTrue())
+		Expect(dsErr.Topic).To(Equal("E"))
+	})
+
+	It("Should know the events of the spec", func() {
+		Expect(errors.IsKnownEvent("VERSION_EXISTS")).To(BeTrue())
+		Expect(errors.IsKnownEvent("something went wrong")).To(BeFalse())
+	})
+})
@@ -12,4 +12,8 @@ import "errors"
 var (
 	//ErrEmptyRawMessage error
 	ErrEmptyRawMessage = errors.New("Message can't be parsed since it's empty and does not conform to the deepstream.io spec")
+	//ErrMalformedMessage error
+	ErrMalformedMessage = errors.New("Message can't be parsed since it lacks a topic or an action and does not conform to the deepstream.io spec")
+	//ErrMissingMessageData error
+	ErrMissingMessageData = errors.New("Message can't be understood since it lacks data its action requires by the deepstream.io spec")
 )
@@ -0,0 +1,20 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package errors
+
+import "fmt"
+
+//RPCProviderError is returned when the provider of an RPC responds with an error
+type RPCProviderError struct {
+	Name    string
+	Message string
+}
+
+func (e *RPCProviderError) Error() string {
+	return fmt.Sprintf("RPC %s failed: %s", e.Name, e.Message)
+}
@@ -1,986 +1,1052 @@
-package main
-
-import "github.com/DATA-DOG/godog"
-
-func theTestServerIsReady() error {
-	return godog.ErrPending
-}
-
-func theServerHasActiveConnections(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theClientIsInitialised() error {
-	return godog.ErrPending
-}
-
-func theClientsConnectionStateIs(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theClientIsInitialisedWithASmallHeartbeatInterval() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageCA() error {
-	return godog.ErrPending
-}
-
-func theClientLogsInWithUsernameAndPassword(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageAA() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageCPI() error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageCPO() error {
-	return godog.ErrPending
-}
-
-func twoSecondsLater() error {
-	return godog.ErrPending
-}
-
-func theClientThrowsAErrorWithMessage(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theSecondTestServerIsReady() error {
-	return godog.ErrPending
-}
-
-func theSecondServerHasActiveConnections(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageCCH() error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsCCHRFIRST_SERVER_URL() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageCREJ() error {
-	return godog.ErrPending
-}
-
-func theServerHasReceivedMessages(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageCREDSECOND_SERVER_URL() error {
-	return godog.ErrPending
-}
-
-func someTimePasses() error {
-	return godog.ErrPending
-}
-
-func theClientIsOnTheSecondServer() error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsAREQXXXYYY(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theLastLoginWasSuccessful() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageAEINVALID_AUTH_DATASinvalidAuthenticationData() error {
-	return godog.ErrPending
-}
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
 
-func theLastLoginFailedWithErrorMessage(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageAETOO_MANY_AUTH_ATTEMPTSStooManyAuthenticationAttempts() error {
-	return godog.ErrPending
-}
-
-func theServerResetsItsMessageCount() error {
-	return godog.ErrPending
-}
-
-func theClientSubscribesToAnEventNamed(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageEAStest(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageEStest(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsEStest(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theClientListensToEventsMatching(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsELeventPrefix() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageEALeventPrefix() error {
-	return godog.ErrPending
-}
-
-func theConnectionToTheServerIsLost() error {
-	return godog.ErrPending
-}
-
-func theClientPublishesAnEventNamedWithData(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theServerDidNotRecieveAnyMessages() error {
-	return godog.ErrPending
-}
-
-func theConnectionToTheServerIsReestablished() error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageELeventPrefix() error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageEEVTtestSyetAnotherValue(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theClientUnlistensToEventsMatching(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsEULeventPrefix() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageESPeventPrefixeventPrefixfoundAMatch() error {
-	return godog.ErrPending
-}
-
-func theClientWillBeNotifiedOfNewEventMatch(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageESReventPrefixeventPrefixfoundAMatch() error {
-	return godog.ErrPending
-}
-
-func theClientWillBeNotifiedOfEventMatchRemoval(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageEAULeventPrefix() error {
-	return godog.ErrPending
-}
-
-func theClientUnsubscribesFromAnEventNamed(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageEUStest(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageEEVTtestSsomeValue(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theClientReceivedTheEventWithData(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageEEVTtestSanotherValue(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageEAUStest(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageIOnlyHaveOnePart() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageBR() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRXXX() error {
-	return godog.ErrPending
-}
-
-func theClientSubscribesToPresenceEvents() error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageUSS() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageUASU() error {
-	return godog.ErrPending
-}
-
-func theClientQueriesForConnectedClients() error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageUQQ() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageUQ() error {
-	return godog.ErrPending
-}
-
-func theClientIsNotifiedThatNoClientsAreConnected() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageUQHomerMargeBart() error {
-	return godog.ErrPending
-}
-
-func theClientIsNotifiedThatClientsAreConnected(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageUPNJHomer() error {
-	return godog.ErrPending
-}
-
-func theClientIsNotifiedThatClientLoggedIn(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageUPNLBart() error {
-	return godog.ErrPending
-}
-
-func theClientIsNotifiedThatClientLoggedOut(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theClientUnsubscribesToPresenceEvents() error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageUUSUS() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageUAUSU() error {
-	return godog.ErrPending
-}
-
-func theClientIsNotNotifiedThatClientLoggedIn(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theClientCreatesARecordNamed(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRASmergeRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRRmergeRecordValue(arg1 int, arg2 string, arg3 int) error {
-	return godog.ErrPending
-}
-
-func theClientSetsTheRecordKeyTo(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageREVERSION_EXISTSmergeRecordValue(arg1 int, arg2 string, arg3 int) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRUmergeRecordValue(arg1 int, arg2 string, arg3 int) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRUmergeRecordValue(arg1 int, arg2 string, arg3 int) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRCRconnectionRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRASconnectionRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRRconnectionRecordJohnRufflesDog(arg1 int, arg2, arg3, arg4, arg5, arg6 string, arg7 int) error {
-	return godog.ErrPending
-}
-
-func theClientListensToARecordMatching(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRLrecordPrefix() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRALrecordPrefix() error {
-	return godog.ErrPending
-}
-
-func theClientSetsTheRecordPetsNameTo(arg1 string, arg2 int, arg3 string) error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageRCRconnectionRecord() error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageRLrecordPrefix() error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessageRPconnectionRecordPetsNameSMax(arg1, arg2 int) error {
-	return godog.ErrPending
-}
-
-func theClientChecksIfTheServerHasTheRecord(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRHexistingRecordT() error {
-	return godog.ErrPending
-}
-
-func theClientIsToldTheRecordExists(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRHnonExistentRecordF() error {
-	return godog.ErrPending
-}
-
-func theClientIsToldTheRecordDoesntExist(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRAShasRecord() error {
-	return godog.ErrPending
-}
-
-func theServerDidntReceiveTheMessageRHhasRecord() error {
-	return godog.ErrPending
-}
-
-func theClientUnlistensToARecordMatching(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRULrecordPrefix() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRSPrecordPrefixrecordPrefixfoundAMatch() error {
-	return godog.ErrPending
-}
-
-func theClientWillBeNotifiedOfNewRecordMatch(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRSRrecordPrefixrecordPrefixfoundAMatch() error {
-	return godog.ErrPending
-}
-
-func theClientWillBeNotifiedOfRecordMatchRemoval(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRAULrecordPrefix() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRASdoubleRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRRdoubleRecordJohnRufflesDog(arg1 int, arg2, arg3, arg4, arg5, arg6 string, arg7 int) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRCRdoubleRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRASsnapshotRecord() error {
-	return godog.ErrPending
-}
-
-func theClientRequestsASnapshotForTheRecord(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theClientHasNoResponseForTheSnapshotOfRecord(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRESNsnapshotRecordRECORD_NOT_FOUND() error {
-	return godog.ErrPending
-}
-
-func theClientIsToldTheRecordEncounteredAnErrorRetrievingSnapshot(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRRsnapshotRecordJohn(arg1 int, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theClientIsProvidedTheSnapshotForRecordWithDataJohn(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRCRsubscribeRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRASsubscribeRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRRsubscribeRecordSmithRuffusDog(arg1 int, arg2, arg3, arg4, arg5, arg6 string, arg7 int) error {
-	return godog.ErrPending
-}
-
-func theClientSubscribesToTheEntireRecordChanges(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theClientWillNotBeNotifiedOfTheRecordChange() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRUsubscribeRecordSmithRuffusDog(arg1 int, arg2, arg3, arg4, arg5, arg6 string, arg7 int) error {
-	return godog.ErrPending
-}
-
-func theClientWillBeNotifiedOfTheRecordChange() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRPsubscribeRecordPetsNameSRuffusTheSecond(arg1, arg2 int) error {
-	return godog.ErrPending
-}
-
-func theClientWillBeNotifiedOfThePartialRecordChange() error {
-	return godog.ErrPending
-}
-
-func theClientUnsubscribesToTheEntireRecordChanges(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theClientSubscribesToForTheRecord(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRPsubscribeRecordNameSJohnSmith(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRUsubscribeRecordJohnSmithRuffusDog(arg1 int, arg2, arg3 string, arg4 int, arg5, arg6, arg7, arg8 string, arg9 int) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRPsubscribeRecordPetsAgeN(arg1, arg2, arg3 int) error {
-	return godog.ErrPending
-}
-
-func theClientWillBeNotifiedOfTheSecondRecordChange() error {
-	return godog.ErrPending
-}
-
-func theClientUnsubscribesToForTheRecord(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRASunhappyRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRRunhappyRecord(arg1 int, arg2, arg3 string) error {
-	return godog.ErrPending
-}
-
-func theClientSetsTheRecordTo(arg1, arg2, arg3 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRUunhappyRecord(arg1 int, arg2, arg3 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRECACHE_RETRIEVAL_TIMEOUTunhappyRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRESTORAGE_RETRIEVAL_TIMEOUTunhappyRecord() error {
-	return godog.ErrPending
-}
-
-func theClientDiscardsTheRecordNamed(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRUSunhappyRecord() error {
-	return godog.ErrPending
-}
-
-func theClientDeletesTheRecordNamed(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRDunhappyRecord() error {
-	return godog.ErrPending
-}
-
-func theClientRequiresWriteAcknowledgementOnRecord(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRCRhappyRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRAShappyRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRRhappyRecordJohnRufflesDog(arg1 int, arg2, arg3, arg4, arg5, arg6 string, arg7 int) error {
-	return godog.ErrPending
-}
-
-func theClientRecordDataIsJohnRufflesDog(arg1, arg2, arg3, arg4, arg5, arg6 string, arg7 int) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRPhappyRecordPetsNameSMaxTrue(arg1, arg2 int, arg3 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRWAhappyRecordL(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theClientIsNotifiedThatTheRecordWasWrittenWithoutError(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theClientSetsTheRecordToSomeValue(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRUhappyRecordSomeValueTrue(arg1 int, arg2, arg3 string) error {
-	return godog.ErrPending
-}
-
-func theClientRecordDataIsSomeValue(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theClientSetsTheRecordToNewErrorData(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRUhappyRecordNewErrorDataTrue(arg1 int, arg2, arg3 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRWAhappyRecordSErrorWritingRecordToStorage(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theClientIsNotifiedThatTheRecordWasWrittenWithError(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theClientSetsTheRecordValidDataTo(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRPhappyRecordValidDataSdifferentDataTrue(arg1 int, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRWAhappyRecordSErrorWritingRecordToCache(arg1 int) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRPhappyRecordValidDataSsomeDataTrue(arg1 int, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageREVERSION_EXISTShappyRecordNewErrorDataTrue(arg1 int, arg2, arg3 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRPhappyRecordPetsAgeN(arg1, arg2, arg3 int) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRUhappyRecordSmithRuffusDog(arg1 int, arg2, arg3, arg4, arg5, arg6 string, arg7 int) error {
-	return godog.ErrPending
-}
-
-func theClientRecordDataIsSmithRuffusDog(arg1, arg2, arg3, arg4, arg5, arg6 string, arg7 int) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRPhappyRecordPetsNameSMax(arg1, arg2 int) error {
-	return godog.ErrPending
-}
-
-func theClientSetsTheRecordToSmithRuffusDog(arg1, arg2, arg3, arg4, arg5, arg6 string, arg7 int) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRUhappyRecordSmithRuffusDog(arg1 int, arg2, arg3, arg4, arg5, arg6 string, arg7 int) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRUShappyRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRAUShappyRecord() error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsRDhappyRecord() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessageRADhappyRecord() error {
-	return godog.ErrPending
-}
-
-func theClientProvidesARPCCalled(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsPStoUppercase() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessagePAStoUppercase() error {
-	return godog.ErrPending
-}
-
-func theServerReceivedTheMessagePStoUppercase() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessagePREQtoUppercaseUIDSabc() error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsPAREQtoUppercaseUID() error {
-	return godog.ErrPending
-}
-
-func theClientRecievesARequestForARPCCalledWithData(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theClientRespondsToTheRPCWithData(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsPREStoUppercaseUIDSABC() error {
-	return godog.ErrPending
-}
-
-func theClientRespondsToTheRPCWithTheError(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsPEAnErrorOccuredtoUppercaseUID() error {
-	return godog.ErrPending
-}
-
-func theClientRejectsTheRPC(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsPREJtoUppercaseUID() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessagePREQunSupportedUIDSabc() error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsPREJunSupportedUID() error {
-	return godog.ErrPending
-}
-
-func theClientStopsProvidingARPCCalled(arg1 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsPUStoUppercase() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessagePAUStoUppercase() error {
-	return godog.ErrPending
-}
-
-func theClientRequestsRPCWithData(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theLastMessageTheServerRecievedIsPREQtoUppercaseUIDSabc() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessagePAREQtoUppercaseUID() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessagePREStoUppercaseUIDSABC() error {
-	return godog.ErrPending
-}
-
-func theClientRecievesASuccessfulRPCCallbackForWithData(arg1, arg2 string) error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessagePAREQtoUpperCaseUID() error {
-	return godog.ErrPending
-}
-
-func theServerSendsTheMessagePERPCErrorMessagetoUppercaseUID() error {
-	return godog.ErrPending
-}
+package main
 
-func theClientRecievesAnErrorRPCCallbackForWithTheMessage(arg1, arg2 string) error {
-	return godog.ErrPending
-}
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DATA-DOG/godog"
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+	dstesting "github.com/ga-con/deepstream.io-client-go/testing"
+	"github.com/ga-con/deepstream.io-client-go/testing/matchers"
+)
+
+// stepTimeout bounds how long a step waits for the client or the server to catch up
+const stepTimeout = time.Second
+
+// acceptance holds the state of a scenario: the fake servers, the client under
+// test and what it reported so far
+type acceptance struct {
+	server *dstesting.FakeServer
+	second *dstesting.FakeServer
+	active *dstesting.FakeServer
+	client *client.Client
+	clock  *dstesting.FakeClock
+
+	mu          sync.Mutex
+	uid         string
+	errors      []*errors.DeepstreamError
+	login       chan error
+	events      map[string][]interface{}
+	eventSubs   map[string]int
+	records     map[string]*client.Record
+	recordReady map[string]chan error
+	writeAck    map[string]bool
+	writes      map[string]chan error
+	changes     int
+	recordSubs  map[string]int
+	requests    map[string]*client.RPCRequest
+	requested   chan *client.RPCRequest
+	results     map[string]chan rpcResult
+	presence    []presenceChange
+	presenceSub int
+	query       chan queryResult
+	hasResults  map[string]chan recordQuery
+	snapshots   map[string]chan recordQuery
+	listened    []listenMatch
+}
+
+type presenceChange struct {
+	username string
+	loggedIn bool
+}
+
+type queryResult struct {
+	usernames []string
+	err       error
+}
+
+type listenMatch struct {
+	topic        string
+	match        string
+	isSubscribed bool
+}
+
+type recordQuery struct {
+	data interface{}
+	err  error
+}
+
+type rpcResult struct {
+	data interface{}
+	err  error
+}
+
+func newAcceptance() *acceptance {
+	return &acceptance{
+		clock:       dstesting.NewFakeClock(),
+		events:      map[string][]interface{}{},
+		eventSubs:   map[string]int{},
+		records:     map[string]*client.Record{},
+		recordReady: map[string]chan error{},
+		writeAck:    map[string]bool{},
+		writes:      map[string]chan error{},
+		recordSubs:  map[string]int{},
+		requests:    map[string]*client.RPCRequest{},
+		requested:   make(chan *client.RPCRequest, 16),
+		results:     map[string]chan rpcResult{},
+		hasResults:  map[string]chan recordQuery{},
+		snapshots:   map[string]chan recordQuery{},
+	}
+}
+
+func (a *acceptance) close() {
+	if a.client != nil {
+		a.client.Close()
+	}
+	if a.server != nil {
+		a.server.Close()
+	}
+	if a.second != nil {
+		a.second.Close()
+	}
+}
+
+// eventually polls check until it succeeds or stepTimeout expires
+func eventually(check func() error) error {
+	deadline := time.Now().Add(stepTimeout)
+	for {
+		err := check()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// wait receives from ch within stepTimeout
+func wait(ch chan error, what string) error {
+	select {
+	case err := <-ch:
+		return err
+	case <-time.After(stepTimeout):
+		return fmt.Errorf("%s did not complete within %s", what, stepTimeout)
+	}
+}
+
+// raw converts the readable notation of the specs, e.g. C|CHR|<FIRST_SERVER_URL>+,
+// to a raw message, filling in the placeholders
+func (a *acceptance) raw(readable string) string {
+	msg := strings.TrimSuffix(readable, "+")
+	if a.server != nil {
+		msg = strings.Replace(msg, "<FIRST_SERVER_URL>", a.server.URL, -1)
+	}
+	if a.second != nil {
+		msg = strings.Replace(msg, "<SECOND_SERVER_URL>", a.second.URL, -1)
+	}
+	a.mu.Lock()
+	if strings.Contains(msg, "<UID>") && a.uid == "" {
+		a.uid = fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	msg = strings.Replace(msg, "<UID>", a.uid, -1)
+	a.mu.Unlock()
+	return strings.TrimSuffix(message.FromReadable(msg), interfaces.MessageSeparator)
+}
+
+// matches compares a received message with the readable expected one. JSON parts
+// are compared by value and <UID> matches, and remembers, any correlation id.
+func (a *acceptance) matches(readable, received string) bool {
+	expectedParts := strings.Split(strings.TrimSuffix(readable, "+"), "|")
+	receivedParts := strings.Split(received, interfaces.MessagePartSeparator)
+	if len(expectedParts) != len(receivedParts) {
+		return false
+	}
+	uid := ""
+	for i, expected := range expectedParts {
+		got := receivedParts[i]
+		switch {
+		case expected == "<UID>":
+			uid = got
+		case strings.HasPrefix(expected, "{") || strings.HasPrefix(expected, "["):
+			if !matchers.JSONEqual(expected, got) {
+				return false
+			}
+		default:
+			if a.raw(expected) != got {
+				return false
+			}
+		}
+	}
+	if uid != "" {
+		a.mu.Lock()
+		a.uid = uid
+		a.mu.Unlock()
+	}
+	return true
+}
+
+func (a *acceptance) options(opts *client.ClientOptions) error {
+	// timeouts are measured on the fake clock, advanced by the steps letting time
+	// pass, and match the configuration of the client specs
+	opts.Clock = a.clock
+	opts.HandshakeTimeout = 0
+	opts.RecIntvlMin = 50 * time.Millisecond
+	opts.RecIntvlMax = 200 * time.Millisecond
+	opts.RecordReadTimeout = 260 * time.Millisecond
+	opts.RecordWriteAckTimeout = 200 * time.Millisecond
+	opts.RPCAckTimeout = 200 * time.Millisecond
+	opts.RPCResponseTimeout = 200 * time.Millisecond
+	opts.ManualLogin = true
+	opts.Logger = client.NopLogger{}
+	opts.OnError = func(err *errors.DeepstreamError) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.errors = append(a.errors, err)
+	}
+	return nil
+}
+
+// Connection
+
+func (a *acceptance) theTestServerIsReady() error {
+	a.server = dstesting.NewFakeServer()
+	return nil
+}
+
+func (a *acceptance) theSecondTestServerIsReady() error {
+	a.second = dstesting.NewFakeServer()
+	return nil
+}
+
+func (a *acceptance) theServerHasActiveConnections(n int) error {
+	return a.server.WaitForConnections(n, stepTimeout)
+}
+
+func (a *acceptance) theSecondServerHasActiveConnections(n int) error {
+	return a.second.WaitForConnections(n, stepTimeout)
+}
+
+func (a *acceptance) theClientIsInitialised() error {
+	cli, err := client.Dial(a.server.URL, a.options)
+	a.client = cli
+	return err
+}
+
+func (a *acceptance) theClientsConnectionStateIs(state string) error {
+	return eventually(func() error {
+		if got := string(a.client.State()); got != state {
+			return fmt.Errorf("expected connection state %s, got %s", state, got)
+		}
+		return nil
+	})
+}
+
+// on returns the server the client was last connected to, the steps about
+// messages refer to it
+func (a *acceptance) on() *dstesting.FakeServer {
+	if a.active != nil {
+		return a.active
+	}
+	return a.server
+}
+
+func (a *acceptance) theClientIsOnTheSecondServer() error {
+	if err := a.second.WaitForConnections(1, stepTimeout); err != nil {
+		return err
+	}
+	a.active = a.second
+	return nil
+}
+
+func (a *acceptance) theServerSendsTheMessage(readable string) error {
+	// the client may still be connecting
+	if err := eventually(func() error {
+		return a.on().WaitForConnections(1, 10*time.Millisecond)
+	}); err != nil {
+		return err
+	}
+	for _, msg := range strings.Split(strings.TrimSuffix(readable, "+"), "+") {
+		if err := a.on().Send(a.raw(msg)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *acceptance) theLastMessageTheServerReceivedIs(readable string) error {
+	return eventually(func() error {
+		if last := a.on().LastMessage(); !a.matches(readable, last) {
+			return fmt.Errorf("expected last message %s, got %q", readable, last)
+		}
+		return nil
+	})
+}
+
+func (a *acceptance) theServerReceivedTheMessage(readable string) error {
+	return eventually(func() error {
+		for _, msg := range a.on().Received() {
+			if a.matches(readable, msg) {
+				return nil
+			}
+		}
+		return fmt.Errorf("message %s was not received, got %q", readable, a.on().Received())
+	})
+}
+
+func (a *acceptance) theServerDidntReceiveTheMessage(readable string) error {
+	time.Sleep(100 * time.Millisecond)
+	for _, msg := range a.on().Received() {
+		if a.matches(readable, msg) {
+			return fmt.Errorf("message %s was received", readable)
+		}
+	}
+	return nil
+}
 
+func (a *acceptance) theServerHasReceivedMessages(n int) error {
+	return eventually(func() error {
+		if got := a.on().MessageCount(); got != n {
+			return fmt.Errorf("expected %d messages, got %d: %q", n, got, a.on().Received())
+		}
+		return nil
+	})
+}
+
+func (a *acceptance) theServerDidNotRecieveAnyMessages() error {
+	time.Sleep(100 * time.Millisecond)
+	if got := a.on().MessageCount(); got != 0 {
+		return fmt.Errorf("expected no messages, got %q", a.on().Received())
+	}
+	return nil
+}
+
+func (a *acceptance) theServerResetsItsMessageCount() error {
+	a.on().ResetMessageCount()
+	return nil
+}
+
+func (a *acceptance) timePasses(d time.Duration) func() error {
+	return func() error {
+		a.clock.Advance(d)
+		// let the client handle what the clock fired
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+}
+
+func (a *acceptance) theConnectionToTheServerIsLost() error {
+	server := a.on()
+	server.Drop()
+	// the client goes back to the first server once a redirected connection is lost
+	a.active = nil
+	return server.WaitForConnections(0, stepTimeout)
+}
+
+func (a *acceptance) theConnectionToTheServerIsReestablished() error {
+	a.server.Restore()
+	// the client waits for the reconnection interval on the fake clock
+	return eventually(func() error {
+		a.clock.Advance(a.client.Options.RecIntvlMax)
+		return a.server.WaitForConnections(1, 10*time.Millisecond)
+	})
+}
+
+func (a *acceptance) theClientThrowsAErrorWithMessage(event, msg string) error {
+	return eventually(func() error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		for _, err := range a.errors {
+			raw := strings.Replace(err.Raw, interfaces.MessagePartSeparator, "|", -1)
+			if err.Event == event && (strings.Contains(err.Message, msg) || strings.Contains(raw, msg)) {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected a %s error with message %q, got %v", event, msg, a.errors)
+	})
+}
+
+// Auth
+
+func (a *acceptance) theClientLogsInWithUsernameAndPassword(username, password string) error {
+	a.login = make(chan error, 1)
+	go func() {
+		// logging in is only possible once the connection is acknowledged
+		deadline := time.Now().Add(5 * stepTimeout)
+		for a.client.State() != interfaces.ConnectionStateAwaitingAuthentication && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		err := a.client.Login(map[string]interface{}{"username": username, "password": password})
+		// failed logins are returned rather than reported, the specs expect both
+		var dsErr *errors.DeepstreamError
+		if errors.As(err, &dsErr) {
+			a.mu.Lock()
+			a.errors = append(a.errors, dsErr)
+			a.mu.Unlock()
+		}
+		a.login <- err
+	}()
+	return nil
+}
+
+func (a *acceptance) theLastLoginWasSuccessful() error {
+	return wait(a.login, "login")
+}
+
+func (a *acceptance) theLastLoginFailedWithErrorMessage(msg string) error {
+	err := wait(a.login, "login")
+	if err == nil || !strings.Contains(err.Error(), msg) {
+		return fmt.Errorf("expected login to fail with %q, got %v", msg, err)
+	}
+	return nil
+}
+
+// Events
+
+func (a *acceptance) theClientSubscribesToAnEventNamed(name string) error {
+	id, err := a.client.SubscribeEvent(name, func(data interface{}) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.events[name] = append(a.events[name], data)
+	})
+	a.eventSubs[name] = id
+	return err
+}
+
+func (a *acceptance) theClientUnsubscribesFromAnEventNamed(name string) error {
+	return a.client.UnsubscribeEvent(name, a.eventSubs[name])
+}
+
+func (a *acceptance) theClientPublishesAnEventNamedWithData(name, data string) error {
+	a.client.EmitEvent(name, data)
+	// events emitted while disconnected are lost rather than failing the step
+	return nil
+}
+
+func (a *acceptance) theClientReceivedTheEventWithData(name, data string) error {
+	return eventually(func() error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		for _, got := range a.events[name] {
+			if fmt.Sprint(got) == data {
+				return nil
+			}
+		}
+		return fmt.Errorf("event %s with data %q was not received, got %v", name, data, a.events[name])
+	})
+}
+
+// Records
+
+func (a *acceptance) theClientCreatesARecordNamed(name string) error {
+	ready := make(chan error, 1)
+	a.recordReady[name] = ready
+	go func() {
+		rec, err := a.client.GetRecord(name)
+		if err == nil {
+			a.mu.Lock()
+			a.records[name] = rec
+			a.mu.Unlock()
+		}
+		ready <- err
+	}()
+	return nil
+}
+
+func (a *acceptance) record(name string) (*client.Record, error) {
+	a.mu.Lock()
+	rec := a.records[name]
+	a.mu.Unlock()
+	if rec != nil {
+		return rec, nil
+	}
+
+	ready, ok := a.recordReady[name]
+	if !ok {
+		return nil, fmt.Errorf("record %s was not created", name)
+	}
+	if err := wait(ready, "reading record "+name); err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.records[name], nil
+}
+
+func (a *acceptance) theClientRequiresWriteAcknowledgementOnRecord(name string) error {
+	a.writeAck[name] = true
+	return nil
+}
+
+func (a *acceptance) setRecord(name, path string, value interface{}) error {
+	rec, err := a.record(name)
+	if err != nil {
+		return err
+	}
+	if !a.writeAck[name] {
+		return rec.SetPath(path, value)
+	}
+	done := make(chan error, 1)
+	a.writes[name] = done
+	go func() {
+		done <- rec.SetPathWithAck(path, value)
+	}()
+	return nil
+}
+
+func (a *acceptance) theClientSetsTheRecordPathTo(name, path, value string) error {
+	return a.setRecord(name, path, value)
+}
+
+func (a *acceptance) theClientSetsTheRecordTo(name, data string) error {
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return err
+	}
+	return a.setRecord(name, "", value)
+}
+
+func (a *acceptance) theClientRecordDataIs(name, data string) error {
+	rec, err := a.record(name)
+	if err != nil {
+		return err
+	}
+	return eventually(func() error {
+		got, err := json.Marshal(rec.Get())
+		if err != nil {
+			return err
+		}
+		if !matchers.JSONEqual(data, string(got)) {
+			return fmt.Errorf("expected record %s data %s, got %s", name, data, got)
+		}
+		return nil
+	})
+}
+
+func (a *acceptance) theClientIsNotifiedThatTheRecordWasWrittenWithoutError(name string) error {
+	done, ok := a.writes[name]
+	if !ok {
+		return fmt.Errorf("record %s was not written with acknowledgement", name)
+	}
+	return wait(done, "writing record "+name)
+}
+
+func (a *acceptance) theClientIsNotifiedThatTheRecordWasWrittenWithError(name, msg string) error {
+	done, ok := a.writes[name]
+	if !ok {
+		return fmt.Errorf("record %s was not written with acknowledgement", name)
+	}
+	err := wait(done, "writing record "+name)
+	if err == nil || !strings.Contains(err.Error(), msg) {
+		return fmt.Errorf("expected write of record %s to fail with %q, got %v", name, msg, err)
+	}
+	return nil
+}
+
+func (a *acceptance) subscribeRecord(name, path string) error {
+	rec, err := a.record(name)
+	if err != nil {
+		return err
+	}
+	a.recordSubs[name+"#"+path] = rec.Subscribe(path, func(interface{}) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.changes++
+	})
+	return nil
+}
+
+func (a *acceptance) unsubscribeRecord(name, path string) error {
+	rec, err := a.record(name)
+	if err != nil {
+		return err
+	}
+	rec.Unsubscribe(a.recordSubs[name+"#"+path])
+	return nil
+}
+
+func (a *acceptance) theClientSubscribesToTheEntireRecordChanges(name string) error {
+	return a.subscribeRecord(name, "")
+}
+
+func (a *acceptance) theClientUnsubscribesToTheEntireRecordChanges(name string) error {
+	return a.unsubscribeRecord(name, "")
+}
+
+func (a *acceptance) theClientSubscribesToForTheRecord(path, name string) error {
+	return a.subscribeRecord(name, path)
+}
+
+func (a *acceptance) theClientUnsubscribesToForTheRecord(path, name string) error {
+	return a.unsubscribeRecord(name, path)
+}
+
+func (a *acceptance) theClientWillBeNotifiedOfTheRecordChange() error {
+	return eventually(func() error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		if a.changes == 0 {
+			return fmt.Errorf("the client was not notified of the record change")
+		}
+		a.changes = 0
+		return nil
+	})
+}
+
+func (a *acceptance) theClientWillNotBeNotifiedOfTheRecordChange() error {
+	time.Sleep(100 * time.Millisecond)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.changes != 0 {
+		return fmt.Errorf("the client was notified of %d record changes", a.changes)
+	}
+	return nil
+}
+
+func (a *acceptance) theClientDiscardsTheRecordNamed(name string) error {
+	rec, err := a.record(name)
+	if err != nil {
+		return err
+	}
+	return rec.Discard()
+}
+
+func (a *acceptance) theClientDeletesTheRecordNamed(name string) error {
+	rec, err := a.record(name)
+	if err != nil {
+		return err
+	}
+	return rec.Delete()
+}
+
+// Record has and snapshot
+
+func (a *acceptance) queryRecord(results map[string]chan recordQuery, name string, fn func() (interface{}, error)) {
+	result := make(chan recordQuery, 1)
+	a.mu.Lock()
+	results[name] = result
+	a.mu.Unlock()
+	go func() {
+		data, err := fn()
+		result <- recordQuery{data: data, err: err}
+	}()
+}
+
+func (a *acceptance) recordQueryResult(results map[string]chan recordQuery, name, what string) (recordQuery, error) {
+	a.mu.Lock()
+	result, ok := results[name]
+	a.mu.Unlock()
+	if !ok {
+		return recordQuery{}, fmt.Errorf("the client did not ask for the %s of record %s", what, name)
+	}
+	select {
+	case res := <-result:
+		return res, nil
+	case <-time.After(stepTimeout):
+		return recordQuery{}, fmt.Errorf("the %s of record %s did not complete within %s", what, name, stepTimeout)
+	}
+}
+
+func (a *acceptance) theClientChecksIfTheServerHasTheRecord(name string) error {
+	a.queryRecord(a.hasResults, name, func() (interface{}, error) {
+		return a.client.HasRecord(name)
+	})
+	return nil
+}
+
+func (a *acceptance) theClientIsToldTheRecordExists(name, exists string) error {
+	res, err := a.recordQueryResult(a.hasResults, name, "existence check")
+	if err != nil {
+		return err
+	}
+	if res.err != nil {
+		return res.err
+	}
+	if expected := exists == "exists"; res.data != expected {
+		return fmt.Errorf("expected record %s to exist: %v, got %v", name, expected, res.data)
+	}
+	return nil
+}
+
+func (a *acceptance) theClientRequestsASnapshotForTheRecord(name string) error {
+	a.queryRecord(a.snapshots, name, func() (interface{}, error) {
+		return a.client.SnapshotRecord(name)
+	})
+	return nil
+}
+
+func (a *acceptance) theClientHasNoResponseForTheSnapshotOfRecord(name string) error {
+	a.mu.Lock()
+	result, ok := a.snapshots[name]
+	a.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("the client did not ask for the snapshot of record %s", name)
+	}
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case res := <-result:
+		return fmt.Errorf("the client got a response for the snapshot of record %s: %v, %v", name, res.data, res.err)
+	default:
+		return nil
+	}
+}
+
+func (a *acceptance) theClientIsToldTheRecordEncounteredAnErrorRetrievingSnapshot(name string) error {
+	res, err := a.recordQueryResult(a.snapshots, name, "snapshot")
+	if err != nil {
+		return err
+	}
+	if res.err == nil {
+		return fmt.Errorf("expected the snapshot of record %s to fail, got %v", name, res.data)
+	}
+	return nil
+}
+
+func (a *acceptance) theClientIsProvidedTheSnapshotForRecordWithData(name, data string) error {
+	res, err := a.recordQueryResult(a.snapshots, name, "snapshot")
+	if err != nil {
+		return err
+	}
+	if res.err != nil {
+		return res.err
+	}
+	got, err := json.Marshal(res.data)
+	if err != nil {
+		return err
+	}
+	if !matchers.JSONEqual(data, string(got)) {
+		return fmt.Errorf("expected the snapshot of record %s to be %s, got %s", name, data, got)
+	}
+	return nil
+}
+
+// RPC
+
+func (a *acceptance) theClientProvidesARPCCalled(name string) error {
+	return a.client.Provide(name, func(req *client.RPCRequest) {
+		a.requested <- req
+	})
+}
+
+func (a *acceptance) theClientStopsProvidingARPCCalled(name string) error {
+	return a.client.Unprovide(name)
+}
+
+func (a *acceptance) theClientRecievesARequestForARPCCalledWithData(name, data string) error {
+	select {
+	case req := <-a.requested:
+		if req.Name != name || fmt.Sprint(req.Data) != data {
+			return fmt.Errorf("expected a request for %s with data %q, got %s with %v", name, data, req.Name, req.Data)
+		}
+		a.requests[name] = req
+		return nil
+	case <-time.After(stepTimeout):
+		return fmt.Errorf("no request for RPC %s was received", name)
+	}
+}
+
+func (a *acceptance) request(name string) (*client.RPCRequest, error) {
+	req, ok := a.requests[name]
+	if !ok {
+		return nil, fmt.Errorf("no request for RPC %s was received", name)
+	}
+	return req, nil
+}
+
+func (a *acceptance) theClientRespondsToTheRPCWithData(name, data string) error {
+	req, err := a.request(name)
+	if err != nil {
+		return err
+	}
+	return req.Send(data)
+}
+
+func (a *acceptance) theClientRespondsToTheRPCWithTheError(name, reason string) error {
+	req, err := a.request(name)
+	if err != nil {
+		return err
+	}
+	return req.Error(reason)
+}
+
+func (a *acceptance) theClientRejectsTheRPC(name string) error {
+	req, err := a.request(name)
+	if err != nil {
+		return err
+	}
+	return req.Reject()
+}
+
+func (a *acceptance) theClientRequestsRPCWithData(name, data string) error {
+	result := make(chan rpcResult, 1)
+	a.results[name] = result
+	go func() {
+		res, err := a.client.Make(name, data)
+		result <- rpcResult{data: res, err: err}
+	}()
+	return nil
+}
+
+func (a *acceptance) rpcResult(name string) (rpcResult, error) {
+	result, ok := a.results[name]
+	if !ok {
+		return rpcResult{}, fmt.Errorf("RPC %s was not requested", name)
+	}
+	select {
+	case res := <-result:
+		return res, nil
+	case <-time.After(stepTimeout):
+		return rpcResult{}, fmt.Errorf("RPC %s did not complete within %s", name, stepTimeout)
+	}
+}
+
+func (a *acceptance) theClientRecievesASuccessfulRPCCallbackForWithData(name, data string) error {
+	res, err := a.rpcResult(name)
+	if err != nil {
+		return err
+	}
+	if res.err != nil || fmt.Sprint(res.data) != data {
+		return fmt.Errorf("expected RPC %s to succeed with %q, got %v, %v", name, data, res.data, res.err)
+	}
+	return nil
+}
+
+func (a *acceptance) theClientRecievesAnErrorRPCCallbackForWithTheMessage(name, msg string) error {
+	res, err := a.rpcResult(name)
+	if err != nil {
+		return err
+	}
+	// timeouts and missing providers are spec events, anything else comes from the provider
+	var dsErr *errors.DeepstreamError
+	if errors.As(res.err, &dsErr) && dsErr.Event == msg {
+		return nil
+	}
+	providerErr, ok := res.err.(*errors.RPCProviderError)
+	if !ok || providerErr.Message != msg {
+		return fmt.Errorf("expected RPC %s to fail with %q, got %v", name, msg, res.err)
+	}
+	return nil
+}
+
+// Listening
+
+func (a *acceptance) theClientListensTo(action, kind, pattern string) error {
+	topic := interfaces.TopicEvent
+	if kind == "a record" {
+		topic = interfaces.TopicRecord
+	}
+	if action == "unlistens" {
+		if topic == interfaces.TopicRecord {
+			return a.client.UnlistenRecords(pattern)
+		}
+		return a.client.UnlistenEvents(pattern)
+	}
+
+	callback := func(match string, isSubscribed bool) bool {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.listened = append(a.listened, listenMatch{topic: topic, match: match, isSubscribed: isSubscribed})
+		return true
+	}
+	if topic == interfaces.TopicRecord {
+		return a.client.ListenRecords(pattern, callback)
+	}
+	return a.client.ListenEvents(pattern, callback)
+}
+
+func (a *acceptance) theClientWillBeNotifiedOfMatch(kind, removal, match string) error {
+	expected := listenMatch{topic: interfaces.TopicEvent, match: match, isSubscribed: removal == ""}
+	if kind == "record" {
+		expected.topic = interfaces.TopicRecord
+	}
+	return eventually(func() error {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		for _, got := range a.listened {
+			if got == expected {
+				return nil
+			}
+		}
+		return fmt.Errorf("the client was not notified of %s match%s %s, got %v", kind, removal, match, a.listened)
+	})
+}
+
+// Presence
+
+func (a *acceptance) theClientSubscribesToPresenceEvents() error {
+	id, err := a.client.SubscribePresence(func(username string, loggedIn bool) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.presence = append(a.presence, presenceChange{username: username, loggedIn: loggedIn})
+	})
+	a.presenceSub = id
+	return err
+}
+
+func (a *acceptance) theClientUnsubscribesToPresenceEvents() error {
+	return a.client.UnsubscribePresence(a.presenceSub)
+}
+
+func (a *acceptance) theClientQueriesForConnectedClients() error {
+	a.query = make(chan queryResult, 1)
+	go func() {
+		usernames, err := a.client.QueryPresence()
+		a.query <- queryResult{usernames: usernames, err: err}
+	}()
+	return nil
+}
+
+func (a *acceptance) queryResult() ([]string, error) {
+	if a.query == nil {
+		return nil, fmt.Errorf("the client did not query for connected clients")
+	}
+	select {
+	case res := <-a.query:
+		return res.usernames, res.err
+	case <-time.After(stepTimeout):
+		return nil, fmt.Errorf("the query for connected clients did not complete within %s", stepTimeout)
+	}
+}
+
+func (a *acceptance) theClientIsNotifiedThatNoClientsAreConnected() error {
+	usernames, err := a.queryResult()
+	if err != nil {
+		return err
+	}
+	if len(usernames) != 0 {
+		return fmt.Errorf("expected no connected clients, got %v", usernames)
+	}
+	return nil
+}
+
+func (a *acceptance) theClientIsNotifiedThatClientsAreConnected(clients string) error {
+	usernames, err := a.queryResult()
+	if err != nil {
+		return err
+	}
+	if expected := strings.Split(clients, ","); !reflect.DeepEqual(usernames, expected) {
+		return fmt.Errorf("expected connected clients %v, got %v", expected, usernames)
+	}
+	return nil
+}
+
+func (a *acceptance) notifiedOfPresence(username, inOrOut string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, change := range a.presence {
+		if change.username == username && change.loggedIn == (inOrOut == "in") {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *acceptance) theClientIsNotifiedThatClientLogged(username, inOrOut string) error {
+	return eventually(func() error {
+		if !a.notifiedOfPresence(username, inOrOut) {
+			return fmt.Errorf("the client was not notified that %s logged %s", username, inOrOut)
+		}
+		return nil
+	})
+}
+
+func (a *acceptance) theClientIsNotNotifiedThatClientLogged(username, inOrOut string) error {
+	time.Sleep(100 * time.Millisecond)
+	if a.notifiedOfPresence(username, inOrOut) {
+		return fmt.Errorf("the client was notified that %s logged %s", username, inOrOut)
+	}
+	return nil
+}
+
+//FeatureContext binds the steps of deepstream.io-client-specs to the client
+//running against a fake server
 func FeatureContext(s *godog.Suite) {
-	s.Step(`^the test server is ready$`, theTestServerIsReady)
-	s.Step(`^the server has (\d+) active connections$`, theServerHasActiveConnections)
-	s.Step(`^the client is initialised$`, theClientIsInitialised)
-	s.Step(`^the clients connection state is "([^"]*)"$`, theClientsConnectionStateIs)
-	s.Step(`^the client is initialised with a small heartbeat interval$`, theClientIsInitialisedWithASmallHeartbeatInterval)
-	s.Step(`^the server sends the message C\|A\+$`, theServerSendsTheMessageCA)
-	s.Step(`^the client logs in with username "([^"]*)" and password "([^"]*)"$`, theClientLogsInWithUsernameAndPassword)
-	s.Step(`^the server sends the message A\|A\+$`, theServerSendsTheMessageAA)
-	s.Step(`^the server sends the message C\|PI\+$`, theServerSendsTheMessageCPI)
-	s.Step(`^the server received the message C\|PO\+$`, theServerReceivedTheMessageCPO)
-	s.Step(`^two seconds later$`, twoSecondsLater)
-	s.Step(`^the client throws a "([^"]*)" error with message "([^"]*)"$`, theClientThrowsAErrorWithMessage)
-	s.Step(`^the second test server is ready$`, theSecondTestServerIsReady)
-	s.Step(`^the second server has (\d+) active connections$`, theSecondServerHasActiveConnections)
-	s.Step(`^the server sends the message C\|CH\+$`, theServerSendsTheMessageCCH)
-	s.Step(`^the last message the server recieved is C\|CHR\|<FIRST_SERVER_URL>\+$`, theLastMessageTheServerRecievedIsCCHRFIRST_SERVER_URL)
-	s.Step(`^the server sends the message C\|REJ\+$`, theServerSendsTheMessageCREJ)
-	s.Step(`^the server has received (\d+) messages$`, theServerHasReceivedMessages)
-	s.Step(`^the server sends the message C\|RED\|<SECOND_SERVER_URL>\+$`, theServerSendsTheMessageCREDSECOND_SERVER_URL)
-	s.Step(`^some time passes$`, someTimePasses)
-	s.Step(`^the client is on the second server$`, theClientIsOnTheSecondServer)
-	s.Step(`^the last message the server recieved is A\|REQ\|{"([^"]*)":"XXX","([^"]*)":"YYY"}\+$`, theLastMessageTheServerRecievedIsAREQXXXYYY)
-	s.Step(`^the last login was successful$`, theLastLoginWasSuccessful)
-	s.Step(`^the server sends the message A\|E\|INVALID_AUTH_DATA\|Sinvalid authentication data\+$`, theServerSendsTheMessageAEINVALID_AUTH_DATASinvalidAuthenticationData)
-	s.Step(`^the last login failed with error message "([^"]*)"$`, theLastLoginFailedWithErrorMessage)
-	s.Step(`^the server sends the message A\|E\|TOO_MANY_AUTH_ATTEMPTS\|Stoo many authentication attempts\+$`, theServerSendsTheMessageAETOO_MANY_AUTH_ATTEMPTSStooManyAuthenticationAttempts)
-	s.Step(`^the server resets its message count$`, theServerResetsItsMessageCount)
-	s.Step(`^the client subscribes to an event named "([^"]*)"$`, theClientSubscribesToAnEventNamed)
-	s.Step(`^the server sends the message E\|A\|S\|test(\d+)\+$`, theServerSendsTheMessageEAStest)
-	s.Step(`^the server received the message E\|S\|test(\d+)\+$`, theServerReceivedTheMessageEStest)
-	s.Step(`^the last message the server recieved is E\|S\|test(\d+)\+$`, theLastMessageTheServerRecievedIsEStest)
-	s.Step(`^the client listens to events matching "([^"]*)"$`, theClientListensToEventsMatching)
-	s.Step(`^the last message the server recieved is E\|L\|eventPrefix\/\.\*\+$`, theLastMessageTheServerRecievedIsELeventPrefix)
-	s.Step(`^the server sends the message E\|A\|L\|eventPrefix\/\.\*\+$`, theServerSendsTheMessageEALeventPrefix)
-	s.Step(`^the connection to the server is lost$`, theConnectionToTheServerIsLost)
-	s.Step(`^the client publishes an event named "([^"]*)" with data "([^"]*)"$`, theClientPublishesAnEventNamedWithData)
-	s.Step(`^the server did not recieve any messages$`, theServerDidNotRecieveAnyMessages)
-	s.Step(`^the connection to the server is reestablished$`, theConnectionToTheServerIsReestablished)
-	s.Step(`^the server received the message E\|L\|eventPrefix\/\.\*\+$`, theServerReceivedTheMessageELeventPrefix)
-	s.Step(`^the server received the message E\|EVT\|test(\d+)\|SyetAnotherValue\+$`, theServerReceivedTheMessageEEVTtestSyetAnotherValue)
-	s.Step(`^the client unlistens to events matching "([^"]*)"$`, theClientUnlistensToEventsMatching)
-	s.Step(`^the last message the server recieved is E\|UL\|eventPrefix\/\.\*\+$`, theLastMessageTheServerRecievedIsEULeventPrefix)
-	s.Step(`^the server sends the message E\|SP\|eventPrefix\/\.\*\|eventPrefix\/foundAMatch\+$`, theServerSendsTheMessageESPeventPrefixeventPrefixfoundAMatch)
-	s.Step(`^the client will be notified of new event match "([^"]*)"$`, theClientWillBeNotifiedOfNewEventMatch)
-	s.Step(`^the server sends the message E\|SR\|eventPrefix\/\.\*\|eventPrefix\/foundAMatch\+$`, theServerSendsTheMessageESReventPrefixeventPrefixfoundAMatch)
-	s.Step(`^the client will be notified of event match removal "([^"]*)"$`, theClientWillBeNotifiedOfEventMatchRemoval)
-	s.Step(`^the server sends the message E\|A\|UL\|eventPrefix\/\.\*\+$`, theServerSendsTheMessageEAULeventPrefix)
-	s.Step(`^the client unsubscribes from an event named "([^"]*)"$`, theClientUnsubscribesFromAnEventNamed)
-	s.Step(`^the server received the message E\|US\|test(\d+)\+$`, theServerReceivedTheMessageEUStest)
-	s.Step(`^the server sends the message E\|EVT\|test(\d+)\|SsomeValue\+$`, theServerSendsTheMessageEEVTtestSsomeValue)
-	s.Step(`^the client received the event "([^"]*)" with data "([^"]*)"$`, theClientReceivedTheEventWithData)
-	s.Step(`^the server sends the message E\|EVT\|test(\d+)\|SanotherValue\+$`, theServerSendsTheMessageEEVTtestSanotherValue)
-	s.Step(`^the server sends the message E\|A\|US\|test(\d+)\+$`, theServerSendsTheMessageEAUStest)
-	s.Step(`^the server sends the message I only have one part\+$`, theServerSendsTheMessageIOnlyHaveOnePart)
-	s.Step(`^the server sends the message B\|R\+$`, theServerSendsTheMessageBR)
-	s.Step(`^the server sends the message R\|XXX\+$`, theServerSendsTheMessageRXXX)
-	s.Step(`^the client subscribes to presence events$`, theClientSubscribesToPresenceEvents)
-	s.Step(`^the server received the message U\|S\|S\+$`, theServerReceivedTheMessageUSS)
-	s.Step(`^the server sends the message U\|A\|S\|U\+$`, theServerSendsTheMessageUASU)
-	s.Step(`^the client queries for connected clients$`, theClientQueriesForConnectedClients)
-	s.Step(`^the server received the message U\|Q\|Q\+$`, theServerReceivedTheMessageUQQ)
-	s.Step(`^the server sends the message U\|Q\+$`, theServerSendsTheMessageUQ)
-	s.Step(`^the client is notified that no clients are connected$`, theClientIsNotifiedThatNoClientsAreConnected)
-	s.Step(`^the server sends the message U\|Q\|Homer\|Marge\|Bart\+$`, theServerSendsTheMessageUQHomerMargeBart)
-	s.Step(`^the client is notified that clients "([^"]*)" are connected$`, theClientIsNotifiedThatClientsAreConnected)
-	s.Step(`^the server sends the message U\|PNJ\|Homer\+$`, theServerSendsTheMessageUPNJHomer)
-	s.Step(`^the client is notified that client "([^"]*)" logged in$`, theClientIsNotifiedThatClientLoggedIn)
-	s.Step(`^the server sends the message U\|PNL\|Bart\+$`, theServerSendsTheMessageUPNLBart)
-	s.Step(`^the client is notified that client "([^"]*)" logged out$`, theClientIsNotifiedThatClientLoggedOut)
-	s.Step(`^the client unsubscribes to presence events$`, theClientUnsubscribesToPresenceEvents)
-	s.Step(`^the server received the message U\|US\|US\+$`, theServerReceivedTheMessageUUSUS)
-	s.Step(`^the server sends the message U\|A\|US\|U\+$`, theServerSendsTheMessageUAUSU)
-	s.Step(`^the client is not notified that client "([^"]*)" logged in$`, theClientIsNotNotifiedThatClientLoggedIn)
-	s.Step(`^the client creates a record named "([^"]*)"$`, theClientCreatesARecordNamed)
-	s.Step(`^the server sends the message R\|A\|S\|mergeRecord\+$`, theServerSendsTheMessageRASmergeRecord)
-	s.Step(`^the server sends the message R\|R\|mergeRecord\|(\d+)\|{"([^"]*)":"value(\d+)"}\+$`, theServerSendsTheMessageRRmergeRecordValue)
-	s.Step(`^the client sets the record "([^"]*)" "key" to "([^"]*)"$`, theClientSetsTheRecordKeyTo)
-	s.Step(`^the server sends the message R\|E\|VERSION_EXISTS\|mergeRecord\|(\d+)\|{"([^"]*)":"value(\d+)"}\+$`, theServerSendsTheMessageREVERSION_EXISTSmergeRecordValue)
-	s.Step(`^the last message the server recieved is R\|U\|mergeRecord\|(\d+)\|{"([^"]*)":"value(\d+)"}\+$`, theLastMessageTheServerRecievedIsRUmergeRecordValue)
-	s.Step(`^the server sends the message R\|U\|mergeRecord\|(\d+)\|{"([^"]*)":"value(\d+)"}\+$`, theServerSendsTheMessageRUmergeRecordValue)
-	s.Step(`^the last message the server recieved is R\|CR\|connectionRecord\+$`, theLastMessageTheServerRecievedIsRCRconnectionRecord)
-	s.Step(`^the server sends the message R\|A\|S\|connectionRecord\+$`, theServerSendsTheMessageRASconnectionRecord)
-	s.Step(`^the server sends the message R\|R\|connectionRecord\|(\d+)\|{"([^"]*)":"John", "([^"]*)": \[{"([^"]*)":"Ruffles", "([^"]*)":"dog","([^"]*)":(\d+)}\]}\+$`, theServerSendsTheMessageRRconnectionRecordJohnRufflesDog)
-	s.Step(`^the client listens to a record matching "([^"]*)"$`, theClientListensToARecordMatching)
-	s.Step(`^the last message the server recieved is R\|L\|recordPrefix\/\.\*\+$`, theLastMessageTheServerRecievedIsRLrecordPrefix)
-	s.Step(`^the server sends the message R\|A\|L\|recordPrefix\/\.\*\+$`, theServerSendsTheMessageRALrecordPrefix)
-	s.Step(`^the client sets the record "([^"]*)" "pets\.(\d+)\.name" to "([^"]*)"$`, theClientSetsTheRecordPetsNameTo)
-	s.Step(`^the server received the message R\|CR\|connectionRecord\+$`, theServerReceivedTheMessageRCRconnectionRecord)
-	s.Step(`^the server received the message R\|L\|recordPrefix\/\.\*\+$`, theServerReceivedTheMessageRLrecordPrefix)
-	s.Step(`^the server received the message R\|P\|connectionRecord\|(\d+)\|pets\.(\d+)\.name\|SMax\+$`, theServerReceivedTheMessageRPconnectionRecordPetsNameSMax)
-	s.Step(`^the client checks if the server has the record "([^"]*)"$`, theClientChecksIfTheServerHasTheRecord)
-	s.Step(`^the server sends the message R\|H\|existingRecord\|T\|\+$`, theServerSendsTheMessageRHexistingRecordT)
-	s.Step(`^the client is told the record "([^"]*)" exists$`, theClientIsToldTheRecordExists)
-	s.Step(`^the server sends the message R\|H\|nonExistentRecord\|F\|\+$`, theServerSendsTheMessageRHnonExistentRecordF)
-	s.Step(`^the client is told the record "([^"]*)" doesn\'t exist$`, theClientIsToldTheRecordDoesntExist)
-	s.Step(`^the server sends the message R\|A\|S\|hasRecord\+$`, theServerSendsTheMessageRAShasRecord)
-	s.Step(`^the server didn\'t receive the message R\|H\|hasRecord\+$`, theServerDidntReceiveTheMessageRHhasRecord)
-	s.Step(`^the client unlistens to a record matching "([^"]*)"$`, theClientUnlistensToARecordMatching)
-	s.Step(`^the last message the server recieved is R\|UL\|recordPrefix\/\.\*\+$`, theLastMessageTheServerRecievedIsRULrecordPrefix)
-	s.Step(`^the server sends the message R\|SP\|recordPrefix\/\.\*\|recordPrefix\/foundAMatch\+$`, theServerSendsTheMessageRSPrecordPrefixrecordPrefixfoundAMatch)
-	s.Step(`^the client will be notified of new record match "([^"]*)"$`, theClientWillBeNotifiedOfNewRecordMatch)
-	s.Step(`^the server sends the message R\|SR\|recordPrefix\/\.\*\|recordPrefix\/foundAMatch\+$`, theServerSendsTheMessageRSRrecordPrefixrecordPrefixfoundAMatch)
-	s.Step(`^the client will be notified of record match removal "([^"]*)"$`, theClientWillBeNotifiedOfRecordMatchRemoval)
-	s.Step(`^the server sends the message R\|A\|UL\|recordPrefix\/\.\*\+$`, theServerSendsTheMessageRAULrecordPrefix)
-	s.Step(`^the server sends the message R\|A\|S\|doubleRecord\+$`, theServerSendsTheMessageRASdoubleRecord)
-	s.Step(`^the server sends the message R\|R\|doubleRecord\|(\d+)\|{"([^"]*)":"John", "([^"]*)": \[{"([^"]*)":"Ruffles", "([^"]*)":"dog","([^"]*)":(\d+)}\]}\+$`, theServerSendsTheMessageRRdoubleRecordJohnRufflesDog)
-	s.Step(`^the last message the server recieved is R\|CR\|doubleRecord\+$`, theLastMessageTheServerRecievedIsRCRdoubleRecord)
-	s.Step(`^the server sends the message R\|A\|S\|snapshotRecord\+$`, theServerSendsTheMessageRASsnapshotRecord)
-	s.Step(`^the client requests a snapshot for the record "([^"]*)"$`, theClientRequestsASnapshotForTheRecord)
-	s.Step(`^the client has no response for the snapshot of record "([^"]*)"$`, theClientHasNoResponseForTheSnapshotOfRecord)
-	s.Step(`^the server sends the message R\|E\|SN\|snapshotRecord\|RECORD_NOT_FOUND\+$`, theServerSendsTheMessageRESNsnapshotRecordRECORD_NOT_FOUND)
-	s.Step(`^the client is told the record "([^"]*)" encountered an error retrieving snapshot$`, theClientIsToldTheRecordEncounteredAnErrorRetrievingSnapshot)
-	s.Step(`^the server sends the message R\|R\|snapshotRecord\|(\d+)\|{"([^"]*)":"John"}\+$`, theServerSendsTheMessageRRsnapshotRecordJohn)
-	s.Step(`^the client is provided the snapshot for record "([^"]*)" with data "{"([^"]*)":"John"}"$`, theClientIsProvidedTheSnapshotForRecordWithDataJohn)
-	s.Step(`^the last message the server recieved is R\|CR\|subscribeRecord\+$`, theLastMessageTheServerRecievedIsRCRsubscribeRecord)
-	s.Step(`^the server sends the message R\|A\|S\|subscribeRecord\+$`, theServerSendsTheMessageRASsubscribeRecord)
-	s.Step(`^the server sends the message R\|R\|subscribeRecord\|(\d+)\|{"([^"]*)":"Smith","([^"]*)":\[{"([^"]*)":"Ruffus","([^"]*)":"dog","([^"]*)":(\d+)}\]}\+$`, theServerSendsTheMessageRRsubscribeRecordSmithRuffusDog)
-	s.Step(`^the client subscribes to the entire record "([^"]*)" changes$`, theClientSubscribesToTheEntireRecordChanges)
-	s.Step(`^the client will not be notified of the record change$`, theClientWillNotBeNotifiedOfTheRecordChange)
-	s.Step(`^the server sends the message R\|U\|subscribeRecord\|(\d+)\|{"([^"]*)":"Smith","([^"]*)":\[{"([^"]*)":"Ruffus","([^"]*)":"dog","([^"]*)":(\d+)}\]}\+$`, theServerSendsTheMessageRUsubscribeRecordSmithRuffusDog)
-	s.Step(`^the client will be notified of the record change$`, theClientWillBeNotifiedOfTheRecordChange)
-	s.Step(`^the server sends the message R\|P\|subscribeRecord\|(\d+)\|pets\.(\d+)\.name\|SRuffusTheSecond\+$`, theServerSendsTheMessageRPsubscribeRecordPetsNameSRuffusTheSecond)
-	s.Step(`^the client will be notified of the partial record change$`, theClientWillBeNotifiedOfThePartialRecordChange)
-	s.Step(`^the client unsubscribes to the entire record "([^"]*)" changes$`, theClientUnsubscribesToTheEntireRecordChanges)
-	s.Step(`^the client subscribes to "([^"]*)" for the record "([^"]*)"$`, theClientSubscribesToForTheRecord)
-	s.Step(`^the server sends the message R\|P\|subscribeRecord\|(\d+)\|name\|SJohn Smith\+$`, theServerSendsTheMessageRPsubscribeRecordNameSJohnSmith)
-	s.Step(`^the server sends the message R\|U\|subscribeRecord\|(\d+)\|{"([^"]*)":"John Smith", "([^"]*)": (\d+), "([^"]*)": \[{"([^"]*)":"Ruffus", "([^"]*)":"dog","([^"]*)":(\d+)}\]}\+$`, theServerSendsTheMessageRUsubscribeRecordJohnSmithRuffusDog)
-	s.Step(`^the server sends the message R\|P\|subscribeRecord\|(\d+)\|pets\.(\d+)\.age\|N(\d+)\+$`, theServerSendsTheMessageRPsubscribeRecordPetsAgeN)
-	s.Step(`^the client will be notified of the second record change$`, theClientWillBeNotifiedOfTheSecondRecordChange)
-	s.Step(`^the client unsubscribes to "([^"]*)" for the record "([^"]*)"$`, theClientUnsubscribesToForTheRecord)
-	s.Step(`^the server sends the message R\|A\|S\|unhappyRecord\+$`, theServerSendsTheMessageRASunhappyRecord)
-	s.Step(`^the server sends the message R\|R\|unhappyRecord\|(\d+)\|{"([^"]*)":\["([^"]*)"\]}\+$`, theServerSendsTheMessageRRunhappyRecord)
-	s.Step(`^the client sets the record "([^"]*)" to {"([^"]*)":\["([^"]*)"\]}$`, theClientSetsTheRecordTo)
-	s.Step(`^the last message the server recieved is R\|U\|unhappyRecord\|(\d+)\|{"([^"]*)":\["([^"]*)"\]}\+$`, theLastMessageTheServerRecievedIsRUunhappyRecord)
-	s.Step(`^the server sends the message R\|E\|CACHE_RETRIEVAL_TIMEOUT\|unhappyRecord\+$`, theServerSendsTheMessageRECACHE_RETRIEVAL_TIMEOUTunhappyRecord)
-	s.Step(`^the server sends the message R\|E\|STORAGE_RETRIEVAL_TIMEOUT\|unhappyRecord\+$`, theServerSendsTheMessageRESTORAGE_RETRIEVAL_TIMEOUTunhappyRecord)
-	s.Step(`^the client discards the record named "([^"]*)"$`, theClientDiscardsTheRecordNamed)
-	s.Step(`^the last message the server recieved is R\|US\|unhappyRecord\+$`, theLastMessageTheServerRecievedIsRUSunhappyRecord)
-	s.Step(`^the client deletes the record named "([^"]*)"$`, theClientDeletesTheRecordNamed)
-	s.Step(`^the last message the server recieved is R\|D\|unhappyRecord\+$`, theLastMessageTheServerRecievedIsRDunhappyRecord)
-	s.Step(`^the client requires write acknowledgement on record "([^"]*)"$`, theClientRequiresWriteAcknowledgementOnRecord)
-	s.Step(`^the last message the server recieved is R\|CR\|happyRecord\+$`, theLastMessageTheServerRecievedIsRCRhappyRecord)
-	s.Step(`^the server sends the message R\|A\|S\|happyRecord\+$`, theServerSendsTheMessageRAShappyRecord)
-	s.Step(`^the server sends the message R\|R\|happyRecord\|(\d+)\|{"([^"]*)":"John", "([^"]*)": \[{"([^"]*)":"Ruffles", "([^"]*)":"dog","([^"]*)":(\d+)}\]}\+$`, theServerSendsTheMessageRRhappyRecordJohnRufflesDog)
-	s.Step(`^the client record "([^"]*)" data is {"([^"]*)":"John", "([^"]*)": \[{"([^"]*)":"Ruffles", "([^"]*)":"dog","([^"]*)":(\d+)}\]}$`, theClientRecordDataIsJohnRufflesDog)
-	s.Step(`^the last message the server recieved is R\|P\|happyRecord\|(\d+)\|pets\.(\d+)\.name\|SMax\|{"([^"]*)":true}\+$`, theLastMessageTheServerRecievedIsRPhappyRecordPetsNameSMaxTrue)
-	s.Step(`^the server sends the message R\|WA\|happyRecord\|\[(\d+)\]\|L\+$`, theServerSendsTheMessageRWAhappyRecordL)
-	s.Step(`^the client is notified that the record "([^"]*)" was written without error$`, theClientIsNotifiedThatTheRecordWasWrittenWithoutError)
-	s.Step(`^the client sets the record "([^"]*)" to {"([^"]*)":"someValue"}$`, theClientSetsTheRecordToSomeValue)
-	s.Step(`^the last message the server recieved is R\|U\|happyRecord\|(\d+)\|{"([^"]*)":"someValue"}\|{"([^"]*)":true}\+$`, theLastMessageTheServerRecievedIsRUhappyRecordSomeValueTrue)
-	s.Step(`^the client record "([^"]*)" data is {"([^"]*)":"someValue"}$`, theClientRecordDataIsSomeValue)
-	s.Step(`^the client sets the record "([^"]*)" to {"([^"]*)":"newErrorData"}$`, theClientSetsTheRecordToNewErrorData)
-	s.Step(`^the last message the server recieved is R\|U\|happyRecord\|(\d+)\|{"([^"]*)":"newErrorData"}\|{"([^"]*)":true}\+$`, theLastMessageTheServerRecievedIsRUhappyRecordNewErrorDataTrue)
-	s.Step(`^the server sends the message R\|WA\|happyRecord\|\[(\d+)\]\|SError writing record to storage\+$`, theServerSendsTheMessageRWAhappyRecordSErrorWritingRecordToStorage)
-	s.Step(`^the client is notified that the record "([^"]*)" was written with error "([^"]*)"$`, theClientIsNotifiedThatTheRecordWasWrittenWithError)
-	s.Step(`^the client sets the record "([^"]*)" "validData" to "([^"]*)"$`, theClientSetsTheRecordValidDataTo)
-	s.Step(`^the last message the server recieved is R\|P\|happyRecord\|(\d+)\|validData\|SdifferentData\|{"([^"]*)":true}\+$`, theLastMessageTheServerRecievedIsRPhappyRecordValidDataSdifferentDataTrue)
-	s.Step(`^the server sends the message R\|WA\|happyRecord\|\[(\d+)\]\|SError writing record to cache\+$`, theServerSendsTheMessageRWAhappyRecordSErrorWritingRecordToCache)
-	s.Step(`^the last message the server recieved is R\|P\|happyRecord\|(\d+)\|validData\|SsomeData\|{"([^"]*)":true}\+$`, theLastMessageTheServerRecievedIsRPhappyRecordValidDataSsomeDataTrue)
-	s.Step(`^the server sends the message R\|E\|VERSION_EXISTS\|happyRecord\|(\d+)\|{"([^"]*)":"newErrorData"}\|{"([^"]*)":true}\+$`, theServerSendsTheMessageREVERSION_EXISTShappyRecordNewErrorDataTrue)
-	s.Step(`^the server sends the message R\|P\|happyRecord\|(\d+)\|pets\.(\d+)\.age\|N(\d+)\+$`, theServerSendsTheMessageRPhappyRecordPetsAgeN)
-	s.Step(`^the server sends the message R\|U\|happyRecord\|(\d+)\|{"([^"]*)":"Smith", "([^"]*)": \[{"([^"]*)":"Ruffus", "([^"]*)":"dog","([^"]*)":(\d+)}\]}\+$`, theServerSendsTheMessageRUhappyRecordSmithRuffusDog)
-	s.Step(`^the client record "([^"]*)" data is {"([^"]*)":"Smith", "([^"]*)": \[{"([^"]*)":"Ruffus", "([^"]*)":"dog","([^"]*)":(\d+)}\]}$`, theClientRecordDataIsSmithRuffusDog)
-	s.Step(`^the last message the server recieved is R\|P\|happyRecord\|(\d+)\|pets\.(\d+)\.name\|SMax\+$`, theLastMessageTheServerRecievedIsRPhappyRecordPetsNameSMax)
-	s.Step(`^the client sets the record "([^"]*)" to {"([^"]*)":"Smith","([^"]*)":\[{"([^"]*)":"Ruffus","([^"]*)":"dog","([^"]*)":(\d+)}\]}$`, theClientSetsTheRecordToSmithRuffusDog)
-	s.Step(`^the last message the server recieved is R\|U\|happyRecord\|(\d+)\|{"([^"]*)":"Smith","([^"]*)":\[{"([^"]*)":"Ruffus","([^"]*)":"dog","([^"]*)":(\d+)}\]}\+$`, theLastMessageTheServerRecievedIsRUhappyRecordSmithRuffusDog)
-	s.Step(`^the last message the server recieved is R\|US\|happyRecord\+$`, theLastMessageTheServerRecievedIsRUShappyRecord)
-	s.Step(`^the server sends the message R\|A\|US\|happyRecord\+$`, theServerSendsTheMessageRAUShappyRecord)
-	s.Step(`^the last message the server recieved is R\|D\|happyRecord\+$`, theLastMessageTheServerRecievedIsRDhappyRecord)
-	s.Step(`^the server sends the message R\|A\|D\|happyRecord\+$`, theServerSendsTheMessageRADhappyRecord)
-	s.Step(`^the client provides a RPC called "([^"]*)"$`, theClientProvidesARPCCalled)
-	s.Step(`^the last message the server recieved is P\|S\|toUppercase\+$`, theLastMessageTheServerRecievedIsPStoUppercase)
-	s.Step(`^the server sends the message P\|A\|S\|toUppercase\+$`, theServerSendsTheMessagePAStoUppercase)
-	s.Step(`^the server received the message P\|S\|toUppercase\+$`, theServerReceivedTheMessagePStoUppercase)
-	s.Step(`^the server sends the message P\|REQ\|toUppercase\|<UID>\|Sabc\+$`, theServerSendsTheMessagePREQtoUppercaseUIDSabc)
-	s.Step(`^the last message the server recieved is P\|A\|REQ\|toUppercase\|<UID>\+$`, theLastMessageTheServerRecievedIsPAREQtoUppercaseUID)
-	s.Step(`^the client recieves a request for a RPC called "([^"]*)" with data "([^"]*)"$`, theClientRecievesARequestForARPCCalledWithData)
-	s.Step(`^the client responds to the RPC "([^"]*)" with data "([^"]*)"$`, theClientRespondsToTheRPCWithData)
-	s.Step(`^the last message the server recieved is P\|RES\|toUppercase\|<UID>\|SABC\+$`, theLastMessageTheServerRecievedIsPREStoUppercaseUIDSABC)
-	s.Step(`^the client responds to the RPC "([^"]*)" with the error "([^"]*)"$`, theClientRespondsToTheRPCWithTheError)
-	s.Step(`^the last message the server recieved is P\|E\|An Error Occured\|toUppercase\|<UID>\+$`, theLastMessageTheServerRecievedIsPEAnErrorOccuredtoUppercaseUID)
-	s.Step(`^the client rejects the RPC "([^"]*)"$`, theClientRejectsTheRPC)
-	s.Step(`^the last message the server recieved is P\|REJ\|toUppercase\|<UID>\+$`, theLastMessageTheServerRecievedIsPREJtoUppercaseUID)
-	s.Step(`^the server sends the message P\|REQ\|unSupported\|<UID>\|Sabc\+$`, theServerSendsTheMessagePREQunSupportedUIDSabc)
-	s.Step(`^the last message the server recieved is P\|REJ\|unSupported\|<UID>\+$`, theLastMessageTheServerRecievedIsPREJunSupportedUID)
-	s.Step(`^the client stops providing a RPC called "([^"]*)"$`, theClientStopsProvidingARPCCalled)
-	s.Step(`^the last message the server recieved is P\|US\|toUppercase\+$`, theLastMessageTheServerRecievedIsPUStoUppercase)
-	s.Step(`^the server sends the message P\|A\|US\|toUppercase\+$`, theServerSendsTheMessagePAUStoUppercase)
-	s.Step(`^the client requests RPC "([^"]*)" with data "([^"]*)"$`, theClientRequestsRPCWithData)
-	s.Step(`^the last message the server recieved is P\|REQ\|toUppercase\|<UID>\|Sabc\+$`, theLastMessageTheServerRecievedIsPREQtoUppercaseUIDSabc)
-	s.Step(`^the server sends the message P\|A\|REQ\|toUppercase\|<UID>\+$`, theServerSendsTheMessagePAREQtoUppercaseUID)
-	s.Step(`^the server sends the message P\|RES\|toUppercase\|<UID>\|SABC\+$`, theServerSendsTheMessagePREStoUppercaseUIDSABC)
-	s.Step(`^the client recieves a successful RPC callback for "([^"]*)" with data "([^"]*)"$`, theClientRecievesASuccessfulRPCCallbackForWithData)
-	s.Step(`^the server sends the message P\|A\|REQ\|toUpperCase\|<UID>\+$`, theServerSendsTheMessagePAREQtoUpperCaseUID)
-	s.Step(`^the server sends the message P\|E\|RPC Error Message\|toUppercase\|<UID>\+$`, theServerSendsTheMessagePERPCErrorMessagetoUppercaseUID)
-	s.Step(`^the client recieves an error RPC callback for "([^"]*)" with the message "([^"]*)"$`, theClientRecievesAnErrorRPCCallbackForWithTheMessage)
+	a := newAcceptance()
+	s.BeforeScenario(func(interface{}) {
+		*a = *newAcceptance()
+	})
+	s.AfterScenario(func(interface{}, error) {
+		a.close()
+	})
+
+	s.Step(`^the test server is ready$`, a.theTestServerIsReady)
+	s.Step(`^the second test server is ready$`, a.theSecondTestServerIsReady)
+	s.Step(`^the server has (\d+) active connections$`, a.theServerHasActiveConnections)
+	s.Step(`^the second server has (\d+) active connections$`, a.theSecondServerHasActiveConnections)
+	s.Step(`^the client is initialised$`, a.theClientIsInitialised)
+	s.Step(`^the client is initialised with a small heartbeat interval$`, a.theClientIsInitialised)
+	s.Step(`^the clients connection state is "([^"]*)"$`, a.theClientsConnectionStateIs)
+	s.Step(`^the client is on the second server$`, a.theClientIsOnTheSecondServer)
+	s.Step(`^the server sends the message (.+)$`, a.theServerSendsTheMessage)
+	s.Step(`^the last message the server recieved is (.+)$`, a.theLastMessageTheServerReceivedIs)
+	s.Step(`^the server received the message (.+)$`, a.theServerReceivedTheMessage)
+	s.Step(`^the server didn't receive the message (.+)$`, a.theServerDidntReceiveTheMessage)
+	s.Step(`^the server has received (\d+) messages$`, a.theServerHasReceivedMessages)
+	s.Step(`^the server did not recieve any messages$`, a.theServerDidNotRecieveAnyMessages)
+	s.Step(`^the server resets its message count$`, a.theServerResetsItsMessageCount)
+	s.Step(`^two seconds later$`, a.timePasses(2*time.Second))
+	s.Step(`^some time passes$`, a.timePasses(500*time.Millisecond))
+	s.Step(`^the connection to the server is lost$`, a.theConnectionToTheServerIsLost)
+	s.Step(`^the connection to the server is reestablished$`, a.theConnectionToTheServerIsReestablished)
+	s.Step(`^the client throws a "([^"]*)" error with message "([^"]*)"$`, a.theClientThrowsAErrorWithMessage)
+
+	s.Step(`^the client logs in with username "([^"]*)" and password "([^"]*)"$`, a.theClientLogsInWithUsernameAndPassword)
+	s.Step(`^the last login was successful$`, a.theLastLoginWasSuccessful)
+	s.Step(`^the last login failed with error message "([^"]*)"$`, a.theLastLoginFailedWithErrorMessage)
+
+	s.Step(`^the client subscribes to an event named "([^"]*)"$`, a.theClientSubscribesToAnEventNamed)
+	s.Step(`^the client unsubscribes from an event named "([^"]*)"$`, a.theClientUnsubscribesFromAnEventNamed)
+	s.Step(`^the client publishes an event named "([^"]*)" with data "([^"]*)"$`, a.theClientPublishesAnEventNamedWithData)
+	s.Step(`^the client received the event "([^"]*)" with data "([^"]*)"$`, a.theClientReceivedTheEventWithData)
+
+	s.Step(`^the client creates a record named "([^"]*)"$`, a.theClientCreatesARecordNamed)
+	s.Step(`^the client requires write acknowledgement on record "([^"]*)"$`, a.theClientRequiresWriteAcknowledgementOnRecord)
+	s.Step(`^the client sets the record "([^"]*)" "([^"]*)" to "([^"]*)"$`, a.theClientSetsTheRecordPathTo)
+	s.Step(`^the client sets the record "([^"]*)" to (\{.*\})$`, a.theClientSetsTheRecordTo)
+	s.Step(`^the client record "([^"]*)" data is (\{.*\})$`, a.theClientRecordDataIs)
+	s.Step(`^the client is notified that the record "([^"]*)" was written without error$`, a.theClientIsNotifiedThatTheRecordWasWrittenWithoutError)
+	s.Step(`^the client is notified that the record "([^"]*)" was written with error "([^"]*)"$`, a.theClientIsNotifiedThatTheRecordWasWrittenWithError)
+	s.Step(`^the client subscribes to the entire record "([^"]*)" changes$`, a.theClientSubscribesToTheEntireRecordChanges)
+	s.Step(`^the client unsubscribes to the entire record "([^"]*)" changes$`, a.theClientUnsubscribesToTheEntireRecordChanges)
+	s.Step(`^the client subscribes to "([^"]*)" for the record "([^"]*)"$`, a.theClientSubscribesToForTheRecord)
+	s.Step(`^the client unsubscribes to "([^"]*)" for the record "([^"]*)"$`, a.theClientUnsubscribesToForTheRecord)
+	s.Step(`^the client will be notified of the (?:partial |second )?record change$`, a.theClientWillBeNotifiedOfTheRecordChange)
+	s.Step(`^the client will not be notified of the record change$`, a.theClientWillNotBeNotifiedOfTheRecordChange)
+	s.Step(`^the client discards the record named "([^"]*)"$`, a.theClientDiscardsTheRecordNamed)
+	s.Step(`^the client deletes the record named "([^"]*)"$`, a.theClientDeletesTheRecordNamed)
+
+	s.Step(`^the client checks if the server has the record "([^"]*)"$`, a.theClientChecksIfTheServerHasTheRecord)
+	s.Step(`^the client is told the record "([^"]*)" (exists|doesn't exist)$`, a.theClientIsToldTheRecordExists)
+	s.Step(`^the client requests a snapshot for the record "([^"]*)"$`, a.theClientRequestsASnapshotForTheRecord)
+	s.Step(`^the client has no response for the snapshot of record "([^"]*)"$`, a.theClientHasNoResponseForTheSnapshotOfRecord)
+	s.Step(`^the client is told the record "([^"]*)" encountered an error retrieving snapshot$`, a.theClientIsToldTheRecordEncounteredAnErrorRetrievingSnapshot)
+	s.Step(`^the client is provided the snapshot for record "([^"]*)" with data "(.*)"$`, a.theClientIsProvidedTheSnapshotForRecordWithData)
+
+	s.Step(`^the client provides a RPC called "([^"]*)"$`, a.theClientProvidesARPCCalled)
+	s.Step(`^the client stops providing a RPC called "([^"]*)"$`, a.theClientStopsProvidingARPCCalled)
+	s.Step(`^the client recieves a request for a RPC called "([^"]*)" with data "([^"]*)"$`, a.theClientRecievesARequestForARPCCalledWithData)
+	s.Step(`^the client responds to the RPC "([^"]*)" with data "([^"]*)"$`, a.theClientRespondsToTheRPCWithData)
+	s.Step(`^the client responds to the RPC "([^"]*)" with the error "([^"]*)"$`, a.theClientRespondsToTheRPCWithTheError)
+	s.Step(`^the client rejects the RPC "([^"]*)"$`, a.theClientRejectsTheRPC)
+	s.Step(`^the client requests RPC "([^"]*)" with data "([^"]*)"$`, a.theClientRequestsRPCWithData)
+	s.Step(`^the client recieves a successful RPC callback for "([^"]*)" with data "([^"]*)"$`, a.theClientRecievesASuccessfulRPCCallbackForWithData)
+	s.Step(`^the client recieves an error RPC callback for "([^"]*)" with the message "([^"]*)"$`, a.theClientRecievesAnErrorRPCCallbackForWithTheMessage)
+
+	s.Step(`^the client (listens|unlistens) to (events|a record) matching "([^"]*)"$`, a.theClientListensTo)
+	s.Step(`^the client will be notified of (?:new )?(event|record) match( removal)? "([^"]*)"$`, a.theClientWillBeNotifiedOfMatch)
+
+	s.Step(`^the client subscribes to presence events$`, a.theClientSubscribesToPresenceEvents)
+	s.Step(`^the client unsubscribes to presence events$`, a.theClientUnsubscribesToPresenceEvents)
+	s.Step(`^the client queries for connected clients$`, a.theClientQueriesForConnectedClients)
+	s.Step(`^the client is notified that no clients are connected$`, a.theClientIsNotifiedThatNoClientsAreConnected)
+	s.Step(`^the client is notified that clients "([^"]*)" are connected$`, a.theClientIsNotifiedThatClientsAreConnected)
+	s.Step(`^the client is notified that client "([^"]*)" logged (in|out)$`, a.theClientIsNotifiedThatClientLogged)
+	s.Step(`^the client is not notified that client "([^"]*)" logged (in|out)$`, a.theClientIsNotNotifiedThatClientLogged)
 }
@@ -0,0 +1,31 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package interfaces
+
+import "time"
+
+//Clock tells the time and waits for the client, so tests can control timeouts
+//and reconnection intervals
+type Clock interface {
+	//Now returns the current time
+	Now() time.Time
+	//After waits for d to elapse and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+	//Sleep pauses the current goroutine for d
+	Sleep(d time.Duration)
+	//NewTimer creates a timer sending the current time on its channel after d
+	NewTimer(d time.Duration) Timer
+}
+
+//Timer is a single event timer created by a Clock
+type Timer interface {
+	//C returns the channel the time is sent on when the timer fires
+	C() <-chan time.Time
+	//Stop prevents the timer from firing, it returns false if it already fired or was stopped
+	Stop() bool
+}
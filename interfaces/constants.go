@@ -28,7 +28,7 @@ const LogLevelInfo = 1
 const LogLevelWarn = 2
 
 //LogLevelError identifies log level
-const LogLevelError = 2
+const LogLevelError = 3
 
 //LogLevelOff identifies log level
 const LogLevelOff = 100
@@ -151,6 +151,9 @@ const TopicRecord = "R"
 //TopicRPC represents an RPC related topic
 const TopicRPC = "P"
 
+//TopicPresence represents a presence related topic
+const TopicPresence = "U"
+
 //TopicPrivate represents a Private related topic
 const TopicPrivate = "PRIVATE"
 
@@ -166,7 +169,7 @@ const ActionUpdate = "U"
 const ActionPatch = "P"
 const ActionDelete = "D"
 const ActionSubscribe = "S"
-const ActionUnsubscribe = "uS"
+const ActionUnsubscribe = "US"
 const ActionHas = "H"
 const ActionSnapshot = "SN"
 const ActionListenSnapshot = "LSN"
@@ -188,6 +191,9 @@ const ActionResponse = "RES"
 const ActionRejection = "REJ"
 const ActionPing = "PI"
 const ActionPong = "PO"
+const ActionWriteAcknowledgement = "WA"
+const ActionPresenceJoin = "PNJ"
+const ActionPresenceLeave = "PNL"
 
 //Data Types
 
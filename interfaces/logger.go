@@ -0,0 +1,20 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package interfaces
+
+//LogField is a key/value pair attached to a log entry, e.g. url, state, topic or action
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+//Logger receives the logs of the client
+type Logger interface {
+	//Log writes msg with the given level, one of LogLevelDebug to LogLevelError
+	Log(level int, msg string, fields ...LogField)
+}
@@ -33,6 +33,10 @@ func (a *ChallengeAction) ToAction() string {
 	)
 }
 
+func (a *ChallengeAction) String() string {
+	return Readable(a)
+}
+
 type ChallengeResponseAction struct {
 	URL string
 }
@@ -53,6 +57,10 @@ func (a *ChallengeResponseAction) ToAction() string {
 	)
 }
 
+func (a *ChallengeResponseAction) String() string {
+	return Readable(a)
+}
+
 type AckAction struct {
 	Message
 }
@@ -62,11 +70,11 @@ func NewAckAction(msg *Message) (*AckAction, error) {
 }
 
 func (a *AckAction) ToAction() string {
-	return fmt.Sprintf(
-		"C%sA%s",
-		interfaces.MessagePartSeparator,
-		interfaces.MessageSeparator,
-	)
+	return buildAction(a.Topic, interfaces.ActionAck, a.RawData...)
+}
+
+func (a *AckAction) String() string {
+	return Readable(a)
 }
 
 type AuthRequestAction struct {
@@ -91,11 +99,18 @@ func (a *AuthRequestAction) ToAction() string {
 	)
 }
 
+func (a *AuthRequestAction) String() string {
+	return Readable(a)
+}
+
 type CreateOrReadAction struct {
 	Message
 }
 
 func NewCreateOrReadAction(msg *Message) (*CreateOrReadAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
 	return &CreateOrReadAction{*msg}, nil
 }
 
@@ -109,11 +124,18 @@ func (a *CreateOrReadAction) ToAction() string {
 	)
 }
 
+func (a *CreateOrReadAction) String() string {
+	return Readable(a)
+}
+
 type UpdateAction struct {
 	Message
 }
 
 func NewUpdateAction(msg *Message) (*UpdateAction, error) {
+	if err := requireData(msg, 3); err != nil {
+		return nil, err
+	}
 	return &UpdateAction{*msg}, nil
 }
 
@@ -128,11 +150,18 @@ func (a *UpdateAction) ToAction() string {
 	)
 }
 
+func (a *UpdateAction) String() string {
+	return Readable(a)
+}
+
 type PathAction struct {
 	Message
 }
 
 func NewPathAction(msg *Message) (*PathAction, error) {
+	if err := requireData(msg, 4); err != nil {
+		return nil, err
+	}
 	return &PathAction{*msg}, nil
 }
 
@@ -146,11 +175,18 @@ func (a *PathAction) ToAction() string {
 	)
 }
 
+func (a *PathAction) String() string {
+	return Readable(a)
+}
+
 type ReadAction struct {
 	Message
 }
 
 func NewReadAction(msg *Message) (*ReadAction, error) {
+	if err := requireData(msg, 3); err != nil {
+		return nil, err
+	}
 	return &ReadAction{*msg}, nil
 }
 
@@ -164,11 +200,18 @@ func (a *ReadAction) ToAction() string {
 	)
 }
 
+func (a *ReadAction) String() string {
+	return Readable(a)
+}
+
 type EventAction struct {
 	Message
 }
 
 func NewEventAction(msg *Message) (*EventAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
 	return &EventAction{*msg}, nil
 }
 
@@ -183,23 +226,48 @@ func (a *EventAction) ToAction() string {
 	)
 }
 
-// E|S|test1+
+func (a *EventAction) String() string {
+	return Readable(a)
+}
+
+// C|RED|ws://localhost:6021/deepstream+ redirects the client to another server
+type RedirectAction struct {
+	Message
+}
+
+func NewRedirectAction(msg *Message) (*RedirectAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
+	return &RedirectAction{*msg}, nil
+}
+
+func (a *RedirectAction) ToAction() string {
+	return buildAction(interfaces.TopicConnection, interfaces.ActionRedirect, a.RawData...)
+}
+
+func (a *RedirectAction) String() string {
+	return Readable(a)
+}
+
+// E|S|test1+ or P|S|toUppercase+
 type SubscribeAction struct {
 	Message
 }
 
 func NewSubscribeAction(msg *Message) (*SubscribeAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
 	return &SubscribeAction{*msg}, nil
 }
 
 func (a *SubscribeAction) ToAction() string {
-	return fmt.Sprintf(
-		"E%sS%s%s%s",
-		interfaces.MessagePartSeparator,
-		interfaces.MessagePartSeparator,
-		strings.Join(a.RawData, interfaces.MessagePartSeparator),
-		interfaces.MessageSeparator,
-	)
+	return buildAction(a.Topic, interfaces.ActionSubscribe, a.RawData...)
+}
+
+func (a *SubscribeAction) String() string {
+	return Readable(a)
 }
 
 type PingAction struct {
@@ -217,6 +285,10 @@ func (a *PingAction) ToAction() string {
 	)
 }
 
+func (a *PingAction) String() string {
+	return Readable(a)
+}
+
 type PongAction struct {
 	Message
 }
@@ -231,3 +303,187 @@ func (a *PongAction) ToAction() string {
 		interfaces.MessagePartSeparator,
 	)
 }
+
+func (a *PongAction) String() string {
+	return Readable(a)
+}
+
+// E|US|test1+ or R|US|recordName+
+type UnsubscribeAction struct {
+	Message
+}
+
+func NewUnsubscribeAction(msg *Message) (*UnsubscribeAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
+	return &UnsubscribeAction{*msg}, nil
+}
+
+func (a *UnsubscribeAction) ToAction() string {
+	return buildAction(a.Topic, interfaces.ActionUnsubscribe, a.RawData...)
+}
+
+func (a *UnsubscribeAction) String() string {
+	return Readable(a)
+}
+
+// X|E|MESSAGE_PERMISSION_ERROR|S...+ or R|E|VERSION_EXISTS|recordName|2|{...}+
+type ErrorAction struct {
+	Message
+}
+
+func NewErrorAction(msg *Message) (*ErrorAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
+	return &ErrorAction{*msg}, nil
+}
+
+func (a *ErrorAction) ToAction() string {
+	return buildAction(a.Topic, interfaces.ActionError, a.RawData...)
+}
+
+func (a *ErrorAction) String() string {
+	return Readable(a)
+}
+
+// R|D|recordName+
+type DeleteAction struct {
+	Message
+}
+
+func NewDeleteAction(msg *Message) (*DeleteAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
+	return &DeleteAction{*msg}, nil
+}
+
+func (a *DeleteAction) ToAction() string {
+	return buildAction(interfaces.TopicRecord, interfaces.ActionDelete, a.RawData...)
+}
+
+func (a *DeleteAction) String() string {
+	return Readable(a)
+}
+
+// R|WA|recordName|[2,3]|L+
+type WriteAckAction struct {
+	Message
+}
+
+func NewWriteAckAction(msg *Message) (*WriteAckAction, error) {
+	if err := requireData(msg, 3); err != nil {
+		return nil, err
+	}
+	return &WriteAckAction{*msg}, nil
+}
+
+func (a *WriteAckAction) ToAction() string {
+	return buildAction(interfaces.TopicRecord, interfaces.ActionWriteAcknowledgement, a.RawData...)
+}
+
+func (a *WriteAckAction) String() string {
+	return Readable(a)
+}
+
+// R|H|recordName+ asks whether the record exists, R|H|recordName|T+ answers
+type HasAction struct {
+	Message
+}
+
+func NewHasAction(msg *Message) (*HasAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
+	return &HasAction{*msg}, nil
+}
+
+func (a *HasAction) ToAction() string {
+	return buildAction(interfaces.TopicRecord, interfaces.ActionHas, a.RawData...)
+}
+
+func (a *HasAction) String() string {
+	return Readable(a)
+}
+
+// R|SN|recordName+, answered with R|R|recordName|1|{...}+
+type SnapshotAction struct {
+	Message
+}
+
+func NewSnapshotAction(msg *Message) (*SnapshotAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
+	return &SnapshotAction{*msg}, nil
+}
+
+func (a *SnapshotAction) ToAction() string {
+	return buildAction(interfaces.TopicRecord, interfaces.ActionSnapshot, a.RawData...)
+}
+
+func (a *SnapshotAction) String() string {
+	return Readable(a)
+}
+
+// P|REQ|toUppercase|<UID>|Sabc+
+type RPCRequestAction struct {
+	Message
+}
+
+func NewRPCRequestAction(msg *Message) (*RPCRequestAction, error) {
+	if err := requireData(msg, 2); err != nil {
+		return nil, err
+	}
+	return &RPCRequestAction{*msg}, nil
+}
+
+func (a *RPCRequestAction) ToAction() string {
+	return buildAction(interfaces.TopicRPC, interfaces.ActionRequest, a.RawData...)
+}
+
+func (a *RPCRequestAction) String() string {
+	return Readable(a)
+}
+
+// P|RES|toUppercase|<UID>|SABC+
+type RPCResponseAction struct {
+	Message
+}
+
+func NewRPCResponseAction(msg *Message) (*RPCResponseAction, error) {
+	if err := requireData(msg, 2); err != nil {
+		return nil, err
+	}
+	return &RPCResponseAction{*msg}, nil
+}
+
+func (a *RPCResponseAction) ToAction() string {
+	return buildAction(interfaces.TopicRPC, interfaces.ActionResponse, a.RawData...)
+}
+
+func (a *RPCResponseAction) String() string {
+	return Readable(a)
+}
+
+// P|REJ|toUppercase|<UID>+
+type RPCRejectionAction struct {
+	Message
+}
+
+func NewRPCRejectionAction(msg *Message) (*RPCRejectionAction, error) {
+	if err := requireData(msg, 2); err != nil {
+		return nil, err
+	}
+	return &RPCRejectionAction{*msg}, nil
+}
+
+func (a *RPCRejectionAction) ToAction() string {
+	return buildAction(interfaces.TopicRPC, interfaces.ActionRejection, a.RawData...)
+}
+
+func (a *RPCRejectionAction) String() string {
+	return Readable(a)
+}
@@ -0,0 +1,102 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+)
+
+const (
+	//FrameInbound is the direction of the frames received from the server
+	FrameInbound = "in"
+	//FrameOutbound is the direction of the frames sent to the server
+	FrameOutbound = "out"
+	//FrameConnect marks a connection being established, Data is the URL
+	FrameConnect = "connect"
+	//FrameClose marks a connection being closed, Data is FrameClosedByClient
+	//when the client closed it itself and empty when the connection was lost
+	FrameClose = "close"
+	//FrameClosedByClient is the data of the connections closed with Client.Close
+	FrameClosedByClient = "client"
+	//FrameRedactedAuth replaces the credentials of the logins recorded
+	FrameRedactedAuth = `{"redacted":true}`
+)
+
+//Frame is what went over the connection at a given time, see client.WithRecorder
+type Frame struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	// Data is the raw frame, one or more messages in the wire format
+	Data string `json:"data,omitempty"`
+}
+
+//Readable returns the data of the frame in readable notation
+func (f Frame) Readable() string {
+	return ToReadable(f.Data)
+}
+
+//RedactFrame returns raw with the credentials of its logins, A|REQ|{...},
+//replaced by FrameRedactedAuth so recordings don't store them
+func RedactFrame(raw string) string {
+	messages := strings.Split(raw, interfaces.MessageSeparator)
+	for i, msg := range messages {
+		parts := strings.Split(msg, interfaces.MessagePartSeparator)
+		if len(parts) > 2 && parts[0] == interfaces.TopicAuth && parts[1] == interfaces.ActionRequest {
+			messages[i] = strings.Join([]string{parts[0], parts[1], FrameRedactedAuth}, interfaces.MessagePartSeparator)
+		}
+	}
+	return strings.Join(messages, interfaces.MessageSeparator)
+}
+
+//FrameWriter writes frames as JSON lines, it is safe for concurrent use
+type FrameWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+//NewFrameWriter returns a writer of frames to w, e.g. a file
+func NewFrameWriter(w io.Writer) *FrameWriter {
+	return &FrameWriter{enc: json.NewEncoder(w)}
+}
+
+//Write writes frame as a single line
+func (w *FrameWriter) Write(frame Frame) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.enc.Encode(frame)
+}
+
+//ReadFrames reads the frames written by a FrameWriter, blank lines are skipped
+func ReadFrames(r io.Reader) ([]Frame, error) {
+	frames := []Frame{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var frame Frame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return nil, fmt.Errorf("invalid frame on line %d: %s", line, err)
+		}
+		frames = append(frames, frame)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return frames, nil
+}
@@ -0,0 +1,63 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message_test
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/message"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Frames", func() {
+	Describe("[Unit]", func() {
+		It("Should write and read frames as JSON lines", func() {
+			now := time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)
+			frames := []message.Frame{
+				{Time: now, Direction: message.FrameConnect, Data: "ws://localhost:6020/deepstream"},
+				{Time: now, Direction: message.FrameInbound, Data: message.FromReadable("C|CH+")},
+				{Time: now.Add(time.Millisecond), Direction: message.FrameOutbound, Data: message.FromReadable("C|CHR|localhost:6020+")},
+				{Time: now.Add(time.Second), Direction: message.FrameClose},
+			}
+
+			var buf bytes.Buffer
+			writer := message.NewFrameWriter(&buf)
+			for _, frame := range frames {
+				Expect(writer.Write(frame)).To(Succeed())
+			}
+			Expect(strings.Count(buf.String(), "\n")).To(Equal(4))
+
+			read, err := message.ReadFrames(&buf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read).To(Equal(frames))
+			Expect(read[2].Readable()).To(Equal("C|CHR|localhost:6020+"))
+		})
+
+		It("Should redact the credentials of logins", func() {
+			raw := message.FromReadable(`E|S|test+A|REQ|{"username":"Lisa","password":"secret"}+`)
+			Expect(message.ToReadable(message.RedactFrame(raw))).To(Equal(`E|S|test+A|REQ|{"redacted":true}+`))
+			Expect(message.RedactFrame(message.FromReadable("A|A+"))).To(Equal(message.FromReadable("A|A+")))
+		})
+
+		It("Should skip blank lines", func() {
+			read, err := message.ReadFrames(strings.NewReader("\n{\"direction\":\"close\"}\n\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read).To(HaveLen(1))
+			Expect(read[0].Direction).To(Equal(message.FrameClose))
+		})
+
+		It("Should fail on invalid lines", func() {
+			_, err := message.ReadFrames(strings.NewReader("{\"direction\":\"close\"}\nnot json\n"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("invalid frame on line 2"))
+		})
+	})
+})
@@ -0,0 +1,214 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+// the fuzz targets run their seeds with go test, explore with e.g.
+// go test ./message -run ^$ -fuzz FuzzParseMessages
+
+var fuzzSeeds = []string{
+	"",
+	"C|CH+",
+	"C|A+",
+	"C|PI+",
+	"A|A+",
+	"A|E|INVALID_AUTH_DATA|Sinvalid authentication data+",
+	"A|REQ|{\"username\":\"userA\"}+",
+	"E|S|test1+",
+	"E|A|S|test1+",
+	"E|EVT|test1|SyetAnotherValue+",
+	"E|EVT|test1+",
+	"R|CR|user/Lisa+",
+	"R|R|user/Lisa|1|{\"name\":\"Lisa\"}+",
+	"R|U|user/Lisa|2|{\"name\":\"Smith\",\"pets\":[{\"name\":\"Ruffus\"}]}+",
+	"R|P|user/Lisa|1|lastname|SOwen+",
+	"R|WA|user/Lisa|[2,3]|L+",
+	"R|E|VERSION_EXISTS|user/Lisa|2|{}+",
+	"R|A|D|user/Lisa+",
+	"P|REQ|toUppercase|1234|Sabc+",
+	"P|A|REQ|toUppercase|1234+",
+	"P|RES|toUppercase|1234|SABC+",
+	"P|REJ|toUppercase|1234+",
+	"P|E|NO_RPC_PROVIDER|toUppercase|1234+",
+	"X|E|MESSAGE_PARSE_ERROR|garbage+",
+	"E|S|test1+C|PI+",
+	"R",
+	"R|",
+	"|P|user/Lisa",
+	"+",
+	"++",
+	"R|P+",
+	"P|REQ+",
+}
+
+func FuzzParseMessages(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(message.FromReadable(seed))
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		messages, err := message.ParseMessages(raw)
+		if err != nil {
+			if messages != nil {
+				t.Fatalf("messages returned with error %v", err)
+			}
+			return
+		}
+		for _, msg := range messages {
+			if msg.Topic == "" || msg.Action == "" {
+				t.Fatalf("message %q parsed without a topic or an action", msg.Raw)
+			}
+			checkAction(t, msg)
+		}
+	})
+}
+
+func FuzzCathegorizeAction(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		parts := strings.Split(strings.TrimSuffix(seed, "+"), "|")
+		if len(parts) < 2 {
+			continue
+		}
+		f.Add(parts[0], parts[1], strings.Join(parts[2:], "|"))
+	}
+	f.Fuzz(func(t *testing.T, topic, action, data string) {
+		raw := topic + interfaces.MessagePartSeparator + action
+		if data != "" {
+			raw += interfaces.MessagePartSeparator + strings.Replace(data, "|", interfaces.MessagePartSeparator, -1)
+		}
+		if strings.Contains(raw, interfaces.MessageSeparator) {
+			// ParseMessages splits messages before they get here
+			t.Skip()
+		}
+		msg, err := message.NewMessage(raw)
+		if err != nil {
+			return
+		}
+		checkAction(t, msg)
+	})
+}
+
+func FuzzActionConstructors(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		parts := strings.Split(strings.TrimSuffix(seed, "+"), "|")
+		f.Add(parts[0], strings.Join(parts[1:], "|"))
+	}
+	f.Fuzz(func(t *testing.T, topic, data string) {
+		msg := &message.Message{Topic: topic}
+		if data != "" {
+			msg.RawData = strings.Split(data, "|")
+		}
+		constructors := []func(*message.Message) (interfaces.Action, error){}
+		for _, constructor := range message.AvailableMessageTypes {
+			constructors = append(constructors, constructor)
+		}
+		for _, actions := range message.AvailableTopicMessageTypes {
+			for _, constructor := range actions {
+				constructors = append(constructors, constructor)
+			}
+		}
+		for _, constructor := range constructors {
+			action, err := constructor(msg)
+			if err != nil {
+				continue
+			}
+			// the action must be serializable whatever it was built from
+			_ = action.ToAction()
+			_ = message.Readable(action)
+		}
+	})
+}
+
+func FuzzParseTyped(f *testing.F) {
+	for _, seed := range []string{"", "SOwen", "S", "N12", "N-1.5e3", "NNaN", "N", "O{\"pets\":[{\"name\":\"Max\"}]}", "O", "T", "F", "L", "U", "Xfoo"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		value, err := message.ParseTyped(raw)
+		if err != nil {
+			if value != nil {
+				t.Fatalf("%q parsed to %#v with error %v", raw, value, err)
+			}
+			return
+		}
+		// what was parsed must serialize back to an equal value
+		typed, err := message.ConvertTyped(value)
+		if err != nil {
+			t.Fatalf("%q parsed to %#v which can't be converted back: %v", raw, value, err)
+		}
+		again, err := message.ParseTyped(typed)
+		if err != nil {
+			t.Fatalf("%q converted back to %q which can't be parsed: %v", raw, typed, err)
+		}
+		if !reflect.DeepEqual(value, again) {
+			t.Fatalf("%q parsed to %#v, then to %#v once converted back", raw, value, again)
+		}
+	})
+}
+
+func FuzzSetPath(f *testing.F) {
+	for _, seed := range []string{"", "name", "pets[0].name", "pets.0.name", "pets[1]", "pets[2]", "a.999999999999", "[0][0]", "pets[-1]", "..", "pets[0"} {
+		f.Add(seed, "SMax")
+	}
+	f.Fuzz(func(t *testing.T, path, typed string) {
+		value, err := message.ParseTyped(typed)
+		if err != nil {
+			return
+		}
+		data := map[string]interface{}{
+			"name": "Lisa",
+			"pets": []interface{}{map[string]interface{}{"name": "Max"}},
+		}
+		updated, err := message.SetPath(data, path, value)
+		if err != nil {
+			if updated != nil {
+				t.Fatalf("%q set to %#v with error %v", path, updated, err)
+			}
+			return
+		}
+		if got := message.GetPath(updated, path); !reflect.DeepEqual(got, value) {
+			t.Fatalf("%q set to %#v but got %#v back", path, value, got)
+		}
+		if message.GetPath(data, "pets[0].name") != "Max" || len(data) != 2 {
+			t.Fatalf("setting %q changed the original data to %#v", path, data)
+		}
+	})
+}
+
+// checkAction cathegorizes msg and makes sure the resulting action can be sent
+// and parsed back into an action of the same kind
+func checkAction(t *testing.T, msg *message.Message) {
+	action, err := message.CathegorizeAction(msg)
+	if err != nil {
+		if action != nil {
+			t.Fatalf("%s cathegorized with error %v", msg, err)
+		}
+		return
+	}
+	raw := action.ToAction()
+	_ = message.Readable(action)
+
+	parsed, err := message.ParseMessages(raw)
+	if err != nil || len(parsed) != 1 {
+		t.Fatalf("%s sent as %q which can't be parsed back: %v", msg, message.ToReadable(raw), err)
+	}
+	again, err := message.CathegorizeAction(parsed[0])
+	if err != nil {
+		t.Fatalf("%s sent as %q which can't be cathegorized back: %v", msg, message.ToReadable(raw), err)
+	}
+	if reflect.TypeOf(again) != reflect.TypeOf(action) {
+		t.Fatalf("%s cathegorized as %T, then as %T once sent", msg, action, again)
+	}
+}
@@ -0,0 +1,130 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message
+
+import "github.com/ga-con/deepstream.io-client-go/interfaces"
+
+// E|L|eventPrefix/.*+ or R|L|recordPrefix/.*+
+type ListenAction struct {
+	Message
+}
+
+func NewListenAction(msg *Message) (*ListenAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
+	return &ListenAction{*msg}, nil
+}
+
+func (a *ListenAction) ToAction() string {
+	return buildAction(a.Topic, interfaces.ActionListen, a.RawData...)
+}
+
+func (a *ListenAction) String() string {
+	return Readable(a)
+}
+
+// E|UL|eventPrefix/.*+
+type UnlistenAction struct {
+	Message
+}
+
+func NewUnlistenAction(msg *Message) (*UnlistenAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
+	return &UnlistenAction{*msg}, nil
+}
+
+func (a *UnlistenAction) ToAction() string {
+	return buildAction(a.Topic, interfaces.ActionUnlisten, a.RawData...)
+}
+
+func (a *UnlistenAction) String() string {
+	return Readable(a)
+}
+
+// E|LA|eventPrefix/.*|eventPrefix/match+
+type ListenAcceptAction struct {
+	Message
+}
+
+func NewListenAcceptAction(msg *Message) (*ListenAcceptAction, error) {
+	if err := requireData(msg, 2); err != nil {
+		return nil, err
+	}
+	return &ListenAcceptAction{*msg}, nil
+}
+
+func (a *ListenAcceptAction) ToAction() string {
+	return buildAction(a.Topic, interfaces.ActionListenAccept, a.RawData...)
+}
+
+func (a *ListenAcceptAction) String() string {
+	return Readable(a)
+}
+
+// E|LR|eventPrefix/.*|eventPrefix/match+
+type ListenRejectAction struct {
+	Message
+}
+
+func NewListenRejectAction(msg *Message) (*ListenRejectAction, error) {
+	if err := requireData(msg, 2); err != nil {
+		return nil, err
+	}
+	return &ListenRejectAction{*msg}, nil
+}
+
+func (a *ListenRejectAction) ToAction() string {
+	return buildAction(a.Topic, interfaces.ActionListenReject, a.RawData...)
+}
+
+func (a *ListenRejectAction) String() string {
+	return Readable(a)
+}
+
+// E|SP|eventPrefix/.*|eventPrefix/match+
+type SubscriptionFoundAction struct {
+	Message
+}
+
+func NewSubscriptionFoundAction(msg *Message) (*SubscriptionFoundAction, error) {
+	if err := requireData(msg, 2); err != nil {
+		return nil, err
+	}
+	return &SubscriptionFoundAction{*msg}, nil
+}
+
+func (a *SubscriptionFoundAction) ToAction() string {
+	return buildAction(a.Topic, interfaces.ActionSubscriptionForPatternFound, a.RawData...)
+}
+
+func (a *SubscriptionFoundAction) String() string {
+	return Readable(a)
+}
+
+// E|SR|eventPrefix/.*|eventPrefix/match+
+type SubscriptionRemovedAction struct {
+	Message
+}
+
+func NewSubscriptionRemovedAction(msg *Message) (*SubscriptionRemovedAction, error) {
+	if err := requireData(msg, 2); err != nil {
+		return nil, err
+	}
+	return &SubscriptionRemovedAction{*msg}, nil
+}
+
+func (a *SubscriptionRemovedAction) ToAction() string {
+	return buildAction(a.Topic, interfaces.ActionSubscriptionForPatternRemoved, a.RawData...)
+}
+
+func (a *SubscriptionRemovedAction) String() string {
+	return Readable(a)
+}
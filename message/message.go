@@ -27,6 +27,34 @@ var (
 		interfaces.ActionSubscribe:    func(msg *Message) (interfaces.Action, error) { return NewSubscribeAction(msg) },
 		interfaces.ActionPing:         func(msg *Message) (interfaces.Action, error) { return NewPingAction(msg) },
 		interfaces.ActionPong:         func(msg *Message) (interfaces.Action, error) { return NewPongAction(msg) },
+		interfaces.ActionUnsubscribe:  func(msg *Message) (interfaces.Action, error) { return NewUnsubscribeAction(msg) },
+		interfaces.ActionDelete:       func(msg *Message) (interfaces.Action, error) { return NewDeleteAction(msg) },
+		interfaces.ActionError:        func(msg *Message) (interfaces.Action, error) { return NewErrorAction(msg) },
+		interfaces.ActionRequest:      func(msg *Message) (interfaces.Action, error) { return NewAuthRequestAction(msg) },
+		interfaces.ActionRedirect:     func(msg *Message) (interfaces.Action, error) { return NewRedirectAction(msg) },
+
+		interfaces.ActionWriteAcknowledgement: func(msg *Message) (interfaces.Action, error) { return NewWriteAckAction(msg) },
+		interfaces.ActionHas:                  func(msg *Message) (interfaces.Action, error) { return NewHasAction(msg) },
+		interfaces.ActionSnapshot:             func(msg *Message) (interfaces.Action, error) { return NewSnapshotAction(msg) },
+		interfaces.ActionQuery:                func(msg *Message) (interfaces.Action, error) { return NewQueryAction(msg) },
+		interfaces.ActionPresenceJoin:         func(msg *Message) (interfaces.Action, error) { return NewPresenceJoinAction(msg) },
+		interfaces.ActionPresenceLeave:        func(msg *Message) (interfaces.Action, error) { return NewPresenceLeaveAction(msg) },
+
+		interfaces.ActionListen:                        func(msg *Message) (interfaces.Action, error) { return NewListenAction(msg) },
+		interfaces.ActionUnlisten:                      func(msg *Message) (interfaces.Action, error) { return NewUnlistenAction(msg) },
+		interfaces.ActionListenAccept:                  func(msg *Message) (interfaces.Action, error) { return NewListenAcceptAction(msg) },
+		interfaces.ActionListenReject:                  func(msg *Message) (interfaces.Action, error) { return NewListenRejectAction(msg) },
+		interfaces.ActionSubscriptionForPatternFound:   func(msg *Message) (interfaces.Action, error) { return NewSubscriptionFoundAction(msg) },
+		interfaces.ActionSubscriptionForPatternRemoved: func(msg *Message) (interfaces.Action, error) { return NewSubscriptionRemovedAction(msg) },
+	}
+
+	//AvailableTopicMessageTypes returns the message types whose action means something else within a topic
+	AvailableTopicMessageTypes = map[string]map[string]func(*Message) (interfaces.Action, error){
+		interfaces.TopicRPC: {
+			interfaces.ActionRequest:   func(msg *Message) (interfaces.Action, error) { return NewRPCRequestAction(msg) },
+			interfaces.ActionResponse:  func(msg *Message) (interfaces.Action, error) { return NewRPCResponseAction(msg) },
+			interfaces.ActionRejection: func(msg *Message) (interfaces.Action, error) { return NewRPCRejectionAction(msg) },
+		},
 	}
 )
 
@@ -57,6 +85,21 @@ func NewMessage(raw string) (*Message, error) {
 	return msg, nil
 }
 
+//GetMessage returns the message an action was built from
+func (m *Message) GetMessage() *Message {
+	return m
+}
+
+//MessageOf returns the message carried by an action, or nil if the action does not carry one
+func MessageOf(action interfaces.Action) *Message {
+	if a, ok := action.(interface {
+		GetMessage() *Message
+	}); ok {
+		return a.GetMessage()
+	}
+	return nil
+}
+
 //Parse the raw message
 func (m *Message) Parse() error {
 	if m.Raw == "" {
@@ -64,6 +107,9 @@ func (m *Message) Parse() error {
 	}
 
 	parts := strings.Split(m.Raw, interfaces.MessagePartSeparator)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return errors.ErrMalformedMessage
+	}
 	m.Topic = parts[0]
 	m.Action = parts[1]
 	m.RawData = parts[2:]
@@ -71,7 +117,8 @@ func (m *Message) Parse() error {
 	return nil
 }
 
-//ParseMessages in a raw string
+//ParseMessages in a raw string, only the last message may be empty since every
+//message ends with a separator
 func ParseMessages(raw string) ([]*Message, error) {
 	if raw == "" {
 		return nil, errors.ErrEmptyRawMessage
@@ -79,8 +126,8 @@ func ParseMessages(raw string) ([]*Message, error) {
 
 	rawMessages := strings.Split(raw, interfaces.MessageSeparator)
 	messages := []*Message{}
-	for _, rawMessage := range rawMessages {
-		if rawMessage == "" {
+	for i, rawMessage := range rawMessages {
+		if rawMessage == "" && i == len(rawMessages)-1 {
 			continue
 		}
 		message, err := NewMessage(rawMessage)
@@ -95,7 +142,10 @@ func ParseMessages(raw string) ([]*Message, error) {
 
 //CathegorizeAction returns a cathegorized action
 func CathegorizeAction(message *Message) (interfaces.Action, error) {
-	actionFunc, ok := AvailableMessageTypes[message.Action]
+	actionFunc, ok := AvailableTopicMessageTypes[message.Topic][message.Action]
+	if !ok {
+		actionFunc, ok = AvailableMessageTypes[message.Action]
+	}
 	if !ok {
 		return nil, errors.ErrUnknownAction
 	}
@@ -105,3 +155,16 @@ func CathegorizeAction(message *Message) (interfaces.Action, error) {
 	}
 	return action, nil
 }
+
+// requireData fails unless msg carries at least n data parts
+func requireData(msg *Message, n int) error {
+	if len(msg.RawData) < n {
+		return errors.ErrMissingMessageData
+	}
+	return nil
+}
+
+func buildAction(topic, action string, data ...string) string {
+	parts := append([]string{topic, action}, data...)
+	return strings.Join(parts, interfaces.MessagePartSeparator) + interfaces.MessageSeparator
+}
@@ -65,13 +65,107 @@ var _ = Describe("Message Package", func() {
 			})
 
 			It("Should fail on empty message when parsing many and one is empty", func() {
-				rawMessage := "R\u001fP\u001fuser/Lisa\u001f1\u001flastname\u001fSOwen\u001e"
+				rawMessage := "R\u001fP\u001fuser/Lisa\u001f1\u001flastname\u001fSOwen\u001e\u001eE\u001fS\u001ftest1\u001e"
 				message, err := message.ParseMessages(rawMessage)
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(MatchError(errors.ErrEmptyRawMessage))
 				Expect(message).To(BeNil())
 			})
 
+			It("Should fail on messages without a topic or an action", func() {
+				for _, rawMessage := range []string{"R", "R\u001f", "\u001fP\u001fuser/Lisa"} {
+					_, err := message.NewMessage(rawMessage)
+					Expect(err).To(MatchError(errors.ErrMalformedMessage), rawMessage)
+				}
+
+				messages, err := message.ParseMessages("E\u001fS\u001ftest1\u001eC\u001e")
+				Expect(err).To(MatchError(errors.ErrMalformedMessage))
+				Expect(messages).To(BeNil())
+			})
+
+			It("Should fail to cathegorize actions missing data", func() {
+				for _, rawMessage := range []string{"R\u001fCR", "R\u001fP\u001fuser/Lisa\u001f1\u001flastname", "E\u001fEVT", "P\u001fREQ\u001ftoUppercase", "U\u001fPNJ", "C\u001fRED", "E\u001fSP\u001fevent/.*"} {
+					msg, err := message.NewMessage(rawMessage)
+					Expect(err).NotTo(HaveOccurred())
+					action, err := message.CathegorizeAction(msg)
+					Expect(err).To(MatchError(errors.ErrMissingMessageData), rawMessage)
+					Expect(action).To(BeNil())
+				}
+			})
+
+			It("Should cathegorize actions by topic", func() {
+				msg, err := message.NewMessage("P\u001fREQ\u001ftoUppercase\u001f1234\u001fSabc")
+				Expect(err).NotTo(HaveOccurred())
+				action, err := message.CathegorizeAction(msg)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(action).To(BeAssignableToTypeOf(&message.RPCRequestAction{}))
+				Expect(action.ToAction()).To(Equal("P\u001fREQ\u001ftoUppercase\u001f1234\u001fSabc\u001e"))
+
+				msg, err = message.NewMessage("A\u001fREQ\u001f{}")
+				Expect(err).NotTo(HaveOccurred())
+				action, err = message.CathegorizeAction(msg)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(action).To(BeAssignableToTypeOf(&message.AuthRequestAction{}))
+			})
+
+			It("Should cathegorize redirect actions", func() {
+				msg, err := message.NewMessage("C\u001fRED\u001fws://localhost:6021/deepstream")
+				Expect(err).NotTo(HaveOccurred())
+				action, err := message.CathegorizeAction(msg)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(action).To(BeAssignableToTypeOf(&message.RedirectAction{}))
+				Expect(action.ToAction()).To(Equal("C\u001fRED\u001fws://localhost:6021/deepstream\u001e"))
+			})
+
+			It("Should cathegorize record has and snapshot actions", func() {
+				for rawMessage, expected := range map[string]interface{}{
+					"R\u001fH\u001fuser/Lisa":        &message.HasAction{},
+					"R\u001fH\u001fuser/Lisa\u001fT": &message.HasAction{},
+					"R\u001fSN\u001fuser/Lisa":       &message.SnapshotAction{},
+				} {
+					msg, err := message.NewMessage(rawMessage)
+					Expect(err).NotTo(HaveOccurred())
+					action, err := message.CathegorizeAction(msg)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(action).To(BeAssignableToTypeOf(expected), rawMessage)
+					Expect(action.ToAction()).To(Equal(rawMessage+"\u001e"), rawMessage)
+				}
+			})
+
+			It("Should cathegorize listen actions", func() {
+				for rawMessage, expected := range map[string]interface{}{
+					"E\u001fL\u001fevent/.*":                &message.ListenAction{},
+					"R\u001fUL\u001fuser/.*":                &message.UnlistenAction{},
+					"E\u001fLA\u001fevent/.*\u001fevent/a":  &message.ListenAcceptAction{},
+					"E\u001fLR\u001fevent/.*\u001fevent/a":  &message.ListenRejectAction{},
+					"E\u001fSP\u001fevent/.*\u001fevent/a":  &message.SubscriptionFoundAction{},
+					"R\u001fSR\u001fuser/.*\u001fuser/Lisa": &message.SubscriptionRemovedAction{},
+				} {
+					msg, err := message.NewMessage(rawMessage)
+					Expect(err).NotTo(HaveOccurred())
+					action, err := message.CathegorizeAction(msg)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(action).To(BeAssignableToTypeOf(expected), rawMessage)
+					Expect(action.ToAction()).To(Equal(rawMessage+"\u001e"), rawMessage)
+				}
+			})
+
+			It("Should cathegorize presence actions", func() {
+				for rawMessage, expected := range map[string]interface{}{
+					"U\u001fQ":                       &message.QueryAction{},
+					"U\u001fQ\u001fHomer\u001fMarge": &message.QueryAction{},
+					"U\u001fPNJ\u001fHomer":          &message.PresenceJoinAction{},
+					"U\u001fPNL\u001fHomer":          &message.PresenceLeaveAction{},
+				} {
+					msg, err := message.NewMessage(rawMessage)
+					Expect(err).NotTo(HaveOccurred())
+					action, err := message.CathegorizeAction(msg)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(action).To(BeAssignableToTypeOf(expected), rawMessage)
+					Expect(action.ToAction()).To(Equal(rawMessage+"\u001e"), rawMessage)
+				}
+			})
+
 			Measure("it should parse messages efficiently", func(b Benchmarker) {
 				var buffer bytes.Buffer
 
@@ -0,0 +1,129 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+)
+
+// splitPath tokenizes a record path such as "pets[0].name" or "pets.0.name"
+func splitPath(path string) []string {
+	path = strings.Replace(path, "[", ".", -1)
+	path = strings.Replace(path, "]", "", -1)
+
+	tokens := []string{}
+	for _, token := range strings.Split(path, ".") {
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+//GetPath returns the value at a record path such as "pets[0].name" inside data,
+//or nil if it does not exist. An empty path is data itself.
+func GetPath(data interface{}, path string) interface{} {
+	value, _ := LookupPath(data, path)
+	return value
+}
+
+//LookupPath is GetPath telling whether the path exists, so that missing values
+//can be told apart from null ones
+func LookupPath(data interface{}, path string) (interface{}, bool) {
+	current := data
+	for _, token := range splitPath(path) {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+//SetPath returns a copy of data with value stored at a record path, creating
+//intermediate objects and arrays as needed. data itself is left untouched. An
+//array index may append to the array but not go past its end, e.g. pets[2] is
+//only valid with at least 2 pets. Numeric tokens are keys of existing objects,
+//e.g. users.123 sets the key "123" when users is an object.
+func SetPath(data interface{}, path string, value interface{}) (interface{}, error) {
+	return setTokens(DeepCopy(data), splitPath(path), value)
+}
+
+func setTokens(node interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	token, rest := tokens[0], tokens[1:]
+	// numeric tokens are keys of existing objects, e.g. users.123
+	_, isObject := node.(map[string]interface{})
+	if index, err := strconv.Atoi(token); err == nil && index >= 0 && !isObject {
+		list, ok := node.([]interface{})
+		if !ok {
+			list = []interface{}{}
+		}
+		// the index comes from the server, don't let it allocate unbounded arrays
+		if index > len(list) {
+			return nil, errors.ErrPathIndexOutOfRange
+		}
+		if index == len(list) {
+			list = append(list, nil)
+		}
+		item, err := setTokens(list[index], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		list[index] = item
+		return list, nil
+	}
+
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		obj = map[string]interface{}{}
+	}
+	item, err := setTokens(obj[token], rest, value)
+	if err != nil {
+		return nil, err
+	}
+	obj[token] = item
+	return obj, nil
+}
+
+//DeepCopy copies the maps and slices produced by decoding JSON
+func DeepCopy(data interface{}) interface{} {
+	switch node := data.(type) {
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			obj[key] = DeepCopy(value)
+		}
+		return obj
+	case []interface{}:
+		list := make([]interface{}, len(node))
+		for i, value := range node {
+			list[i] = DeepCopy(value)
+		}
+		return list
+	}
+	return data
+}
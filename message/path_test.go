@@ -0,0 +1,97 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message_test
+
+import (
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/message"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Record paths", func() {
+	Describe("[Unit]", func() {
+		var data interface{}
+
+		BeforeEach(func() {
+			data = map[string]interface{}{
+				"name": "Lisa",
+				"pets": []interface{}{map[string]interface{}{"name": "Max"}},
+			}
+		})
+
+		It("Should get the value at a path", func() {
+			Expect(message.GetPath(data, "pets[0].name")).To(Equal("Max"))
+			Expect(message.GetPath(data, "pets.0.name")).To(Equal("Max"))
+			Expect(message.GetPath(data, "")).To(Equal(data))
+			Expect(message.GetPath(data, "pets[1].name")).To(BeNil())
+			Expect(message.GetPath(data, "name.first")).To(BeNil())
+		})
+
+		It("Should tell missing values from null ones", func() {
+			data.(map[string]interface{})["nickname"] = nil
+			value, ok := message.LookupPath(data, "nickname")
+			Expect(ok).To(BeTrue())
+			Expect(value).To(BeNil())
+			_, ok = message.LookupPath(data, "pets[1]")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Should set a path in a copy of the data", func() {
+			updated, err := message.SetPath(data, "pets[0].name", "Snowball")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(message.GetPath(updated, "pets[0].name")).To(Equal("Snowball"))
+			Expect(message.GetPath(data, "pets[0].name")).To(Equal("Max"))
+		})
+
+		It("Should create missing objects and append to arrays", func() {
+			updated, err := message.SetPath(data, "pets[1].name", "Ruffus")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(message.GetPath(updated, "pets")).To(HaveLen(2))
+
+			updated, err = message.SetPath(nil, "address.tags[0]", "home")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(Equal(map[string]interface{}{
+				"address": map[string]interface{}{"tags": []interface{}{"home"}},
+			}))
+		})
+
+		It("Should use numeric tokens as keys of existing objects", func() {
+			users := map[string]interface{}{
+				"users": map[string]interface{}{"123": "a", "0": "z"},
+			}
+			Expect(message.GetPath(users, "users.123")).To(Equal("a"))
+
+			updated, err := message.SetPath(users, "users.123", "b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(Equal(map[string]interface{}{
+				"users": map[string]interface{}{"123": "b", "0": "z"},
+			}))
+
+			updated, err = message.SetPath(users, "users.0", "b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(Equal(map[string]interface{}{
+				"users": map[string]interface{}{"123": "a", "0": "b"},
+			}))
+
+			updated, err = message.SetPath(users, "users[7].name", "c")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(message.GetPath(updated, "users.7.name")).To(Equal("c"))
+			Expect(message.GetPath(updated, "users.123")).To(Equal("a"))
+		})
+
+		It("Should fail on array indexes past the end of the array", func() {
+			updated, err := message.SetPath(data, "pets[2].name", "Ruffus")
+			Expect(err).To(MatchError(errors.ErrPathIndexOutOfRange))
+			Expect(updated).To(BeNil())
+
+			_, err = message.SetPath(data, "a.999999999999", "S")
+			Expect(err).To(MatchError(errors.ErrPathIndexOutOfRange))
+		})
+	})
+})
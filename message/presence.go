@@ -0,0 +1,67 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message
+
+import "github.com/ga-con/deepstream.io-client-go/interfaces"
+
+// U|Q|Q+ asks for the connected clients, U|Q|Homer|Marge+ lists them
+type QueryAction struct {
+	Message
+}
+
+func NewQueryAction(msg *Message) (*QueryAction, error) {
+	return &QueryAction{*msg}, nil
+}
+
+func (a *QueryAction) ToAction() string {
+	return buildAction(interfaces.TopicPresence, interfaces.ActionQuery, a.RawData...)
+}
+
+func (a *QueryAction) String() string {
+	return Readable(a)
+}
+
+// U|PNJ|Homer+
+type PresenceJoinAction struct {
+	Message
+}
+
+func NewPresenceJoinAction(msg *Message) (*PresenceJoinAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
+	return &PresenceJoinAction{*msg}, nil
+}
+
+func (a *PresenceJoinAction) ToAction() string {
+	return buildAction(interfaces.TopicPresence, interfaces.ActionPresenceJoin, a.RawData...)
+}
+
+func (a *PresenceJoinAction) String() string {
+	return Readable(a)
+}
+
+// U|PNL|Homer+
+type PresenceLeaveAction struct {
+	Message
+}
+
+func NewPresenceLeaveAction(msg *Message) (*PresenceLeaveAction, error) {
+	if err := requireData(msg, 1); err != nil {
+		return nil, err
+	}
+	return &PresenceLeaveAction{*msg}, nil
+}
+
+func (a *PresenceLeaveAction) ToAction() string {
+	return buildAction(interfaces.TopicPresence, interfaces.ActionPresenceLeave, a.RawData...)
+}
+
+func (a *PresenceLeaveAction) String() string {
+	return Readable(a)
+}
@@ -0,0 +1,61 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message
+
+import (
+	"strings"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+)
+
+//ReadablePartSeparator separates the parts of a message in readable notation
+const ReadablePartSeparator = "|"
+
+//ReadableMessageSeparator ends every message in readable notation
+const ReadableMessageSeparator = "+"
+
+var (
+	toReadable = strings.NewReplacer(
+		interfaces.MessagePartSeparator, ReadablePartSeparator,
+		interfaces.MessageSeparator, ReadableMessageSeparator,
+	)
+	fromReadable = strings.NewReplacer(
+		ReadablePartSeparator, interfaces.MessagePartSeparator,
+		ReadableMessageSeparator, interfaces.MessageSeparator,
+	)
+)
+
+//FromReadable converts messages in the readable notation of the specs, e.g.
+//R|P|user/Lisa|1|lastname|SOwen+, to the wire format. The + ending the last
+//message is optional. Parts containing | or + can't be written in readable notation.
+func FromReadable(readable string) string {
+	if readable != "" && !strings.HasSuffix(readable, ReadableMessageSeparator) {
+		readable += ReadableMessageSeparator
+	}
+	return fromReadable.Replace(readable)
+}
+
+//ToReadable converts messages in the wire format to the readable notation
+func ToReadable(raw string) string {
+	return toReadable.Replace(raw)
+}
+
+//ParseReadable parses messages in readable notation
+func ParseReadable(readable string) ([]*Message, error) {
+	return ParseMessages(FromReadable(readable))
+}
+
+//Readable returns the readable notation of the message sent for action
+func Readable(action interfaces.Action) string {
+	return ToReadable(action.ToAction())
+}
+
+//String returns the message in readable notation, e.g. E|S|test1+
+func (m *Message) String() string {
+	return ToReadable(buildAction(m.Topic, m.Action, m.RawData...))
+}
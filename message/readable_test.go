@@ -0,0 +1,64 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message_test
+
+import (
+	"fmt"
+
+	"github.com/ga-con/deepstream.io-client-go/message"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Readable Notation", func() {
+	Describe("[Unit]", func() {
+		It("Should convert readable notation to the wire format", func() {
+			Expect(message.FromReadable("R|P|user/Lisa|1|lastname|SOwen+")).To(Equal("R\u001fP\u001fuser/Lisa\u001f1\u001flastname\u001fSOwen\u001e"))
+			Expect(message.FromReadable("C|CH+C|A+")).To(Equal("C\u001fCH\u001eC\u001fA\u001e"))
+		})
+
+		It("Should end the last message when the + is missing", func() {
+			Expect(message.FromReadable("E|S|test1")).To(Equal("E\u001fS\u001ftest1\u001e"))
+			Expect(message.FromReadable("")).To(Equal(""))
+		})
+
+		It("Should convert the wire format to readable notation", func() {
+			Expect(message.ToReadable("R\u001fP\u001fuser/Lisa\u001f1\u001flastname\u001fSOwen\u001e")).To(Equal("R|P|user/Lisa|1|lastname|SOwen+"))
+		})
+
+		It("Should parse readable notation", func() {
+			msgs, err := message.ParseReadable("R|P|user/Lisa|1|lastname|SOwen+E|S|test1+")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(msgs).To(HaveLen(2))
+			Expect(msgs[0].Topic).To(Equal("R"))
+			Expect(msgs[0].RawData).To(Equal([]string{"user/Lisa", "1", "lastname", "SOwen"}))
+			Expect(msgs[1].Action).To(Equal("S"))
+		})
+
+		It("Should print messages in readable notation", func() {
+			msgs, err := message.ParseReadable("R|P|user/Lisa|1|lastname|SOwen")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(msgs[0].String()).To(Equal("R|P|user/Lisa|1|lastname|SOwen+"))
+			Expect(fmt.Sprint(msgs[0])).To(Equal("R|P|user/Lisa|1|lastname|SOwen+"))
+
+			msg := &message.Message{Topic: "E", Action: "S", RawData: []string{"test1"}}
+			Expect(msg.String()).To(Equal("E|S|test1+"))
+		})
+
+		It("Should print actions as they are sent", func() {
+			Expect(message.NewChallengeResponseAction("localhost:6020").String()).To(Equal("C|CHR|localhost:6020+"))
+
+			msgs, err := message.ParseReadable("R|CR|rec|extra")
+			Expect(err).NotTo(HaveOccurred())
+			action, err := message.CathegorizeAction(msgs[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fmt.Sprint(action)).To(Equal("R|CR|rec+"))
+			Expect(message.Readable(action)).To(Equal("R|CR|rec+"))
+		})
+	})
+})
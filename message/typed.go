@@ -0,0 +1,81 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+)
+
+//ConvertTyped serializes a value prefixed with its deepstream.io data type (SJohn, N12, O{...})
+func ConvertTyped(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return string(interfaces.TypesNull), nil
+	case string:
+		return string(interfaces.TypesString) + v, nil
+	case bool:
+		if v {
+			return string(interfaces.TypesTrue), nil
+		}
+		return string(interfaces.TypesFalse), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(interfaces.TypesNumber) + string(raw), nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(interfaces.TypesObject) + string(raw), nil
+}
+
+//ParseTyped deserializes a value prefixed with its deepstream.io data type
+func ParseTyped(raw string) (interface{}, error) {
+	if raw == "" {
+		return nil, errors.ErrUnknownDataType
+	}
+
+	value := raw[1:]
+	switch interfaces.DataType(raw[:1]) {
+	case interfaces.TypesString:
+		return value, nil
+	case interfaces.TypesNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		// NaN and Inf are not JSON numbers, deepstream.io can't send them
+		if math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, &strconv.NumError{Func: "ParseFloat", Num: value, Err: strconv.ErrSyntax}
+		}
+		return number, nil
+	case interfaces.TypesObject:
+		var data interface{}
+		if err := json.Unmarshal([]byte(value), &data); err != nil {
+			return nil, err
+		}
+		return data, nil
+	case interfaces.TypesTrue:
+		return true, nil
+	case interfaces.TypesFalse:
+		return false, nil
+	case interfaces.TypesNull, interfaces.TypesUndefined:
+		return nil, nil
+	}
+
+	return nil, errors.ErrUnknownDataType
+}
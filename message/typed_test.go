@@ -0,0 +1,80 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package message_test
+
+import (
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/message"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Typed Data", func() {
+	Describe("[Unit]", func() {
+		It("Should convert values to typed strings", func() {
+			for value, expected := range map[interface{}]string{
+				"Owen": "SOwen",
+				12:     "N12",
+				1.5:    "N1.5",
+				true:   "T",
+				false:  "F",
+				nil:    "L",
+			} {
+				typed, err := message.ConvertTyped(value)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(typed).To(Equal(expected))
+			}
+		})
+
+		It("Should convert objects to typed strings", func() {
+			typed, err := message.ConvertTyped(map[string]interface{}{"name": "Max"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(typed).To(Equal(`O{"name":"Max"}`))
+		})
+
+		It("Should parse typed strings", func() {
+			value, err := message.ParseTyped("SOwen")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("Owen"))
+
+			value, err = message.ParseTyped("N12")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal(12.0))
+
+			value, err = message.ParseTyped(`O{"pets":[{"name":"Max"}]}`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal(map[string]interface{}{
+				"pets": []interface{}{map[string]interface{}{"name": "Max"}},
+			}))
+
+			value, err = message.ParseTyped("T")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(BeTrue())
+
+			value, err = message.ParseTyped("L")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(BeNil())
+		})
+
+		It("Should fail on unknown types", func() {
+			_, err := message.ParseTyped("Xfoo")
+			Expect(err).To(MatchError(errors.ErrUnknownDataType))
+
+			_, err = message.ParseTyped("")
+			Expect(err).To(MatchError(errors.ErrUnknownDataType))
+		})
+
+		It("Should fail on numbers JSON can't represent", func() {
+			for _, raw := range []string{"NNaN", "NInf", "N-Inf", "N1e999", "Nabc"} {
+				value, err := message.ParseTyped(raw)
+				Expect(err).To(HaveOccurred(), raw)
+				Expect(value).To(BeNil())
+			}
+		})
+	})
+})
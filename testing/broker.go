@@ -0,0 +1,690 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+	"github.com/gorilla/websocket"
+)
+
+//ErrBrokerClosed is returned by the protocols of a Broker once their connection is closed
+var ErrBrokerClosed = fmt.Errorf("broker connection is closed")
+
+//BrokerOptions configures a Broker
+type BrokerOptions struct {
+	// Authenticate checks the auth params of a login and returns the username,
+	// default to accepting everyone, named after their username param or OPEN
+	Authenticate func(params map[string]interface{}) (string, error)
+}
+
+//Broker is an in-memory server speaking enough of the deepstream.io text protocol
+//for the clients of a test to talk to each other without a real server: login,
+//events with listening, versioned records, RPC routing with rejections and
+//presence. Clients connect to URL with client.Dial, or in process with
+//client.New(url, broker.NewProtocol()). Close must be called to stop it.
+type Broker struct {
+	// URL is the websocket URL clients should dial, e.g. ws://127.0.0.1:1234/deepstream
+	URL string
+	// Addr is the host:port the broker listens on
+	Addr string
+
+	options  BrokerOptions
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu        sync.Mutex
+	sessions  map[*brokerSession]bool
+	users     map[string]int
+	presence  map[*brokerSession]bool
+	subjects  map[string]map[string]*brokerSubject
+	listeners map[string][]*brokerListener
+	records   map[string]*brokerRecord
+	providers map[string][]*brokerSession
+	requests  map[string]*brokerRequest
+	turns     map[string]int
+}
+
+// brokerSubject is an event or record name with its subscribers and the
+// listener providing it
+type brokerSubject struct {
+	subscribers map[*brokerSession]bool
+	provider    *brokerListener
+	offered     *brokerListener
+	rejected    map[*brokerListener]bool
+}
+
+type brokerListener struct {
+	session *brokerSession
+	pattern string
+	re      *regexp.Regexp
+}
+
+//NewBroker starts a broker configured by options
+func NewBroker(options BrokerOptions) *Broker {
+	if options.Authenticate == nil {
+		options.Authenticate = openAuthentication
+	}
+	b := &Broker{
+		options:   options,
+		sessions:  map[*brokerSession]bool{},
+		users:     map[string]int{},
+		presence:  map[*brokerSession]bool{},
+		subjects:  map[string]map[string]*brokerSubject{},
+		listeners: map[string][]*brokerListener{},
+		records:   map[string]*brokerRecord{},
+		providers: map[string][]*brokerSession{},
+		requests:  map[string]*brokerRequest{},
+		turns:     map[string]int{},
+	}
+	b.server = httptest.NewServer(http.HandlerFunc(b.handle))
+	b.Addr = b.server.Listener.Addr().String()
+	b.URL = fmt.Sprintf("ws://%s/deepstream", b.Addr)
+	return b
+}
+
+func openAuthentication(params map[string]interface{}) (string, error) {
+	if username, ok := params["username"].(string); ok && username != "" {
+		return username, nil
+	}
+	return "OPEN", nil
+}
+
+//Close drops every connection and stops the broker
+func (b *Broker) Close() {
+	b.mu.Lock()
+	sessions := []*brokerSession{}
+	for s := range b.sessions {
+		sessions = append(sessions, s)
+	}
+	b.mu.Unlock()
+
+	for _, s := range sessions {
+		b.closeSession(s)
+	}
+	b.server.Close()
+}
+
+//Connections returns how many clients are connected
+func (b *Broker) Connections() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.sessions)
+}
+
+//Users returns the usernames logged in, sorted
+func (b *Broker) Users() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.usernames("")
+}
+
+// usernames returns the users logged in but except, b.mu must be held
+func (b *Broker) usernames(except string) []string {
+	users := []string{}
+	for user := range b.users {
+		if user != except {
+			users = append(users, user)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// brokerSession is a client connection, the messages sent to it are queued so
+// the broker never blocks on a slow client
+type brokerSession struct {
+	mu            sync.Mutex
+	queue         []string
+	closed        bool
+	changed       chan struct{}
+	username      string
+	authenticated bool
+	hangUp        func()
+}
+
+func newBrokerSession() *brokerSession {
+	return &brokerSession{changed: make(chan struct{})}
+}
+
+// send queues a message for the client
+func (s *brokerSession) send(topic, action string, data ...string) {
+	raw := strings.Join(append([]string{topic, action}, data...), interfaces.MessagePartSeparator)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.queue = append(s.queue, raw+interfaces.MessageSeparator)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// next returns the next message for the client, blocking until there is one,
+// or false once the session is closed
+func (s *brokerSession) next() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.queue) == 0 {
+		if s.closed {
+			return "", false
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		<-changed
+		s.mu.Lock()
+	}
+	raw := s.queue[0]
+	s.queue = s.queue[1:]
+	return raw, true
+}
+
+func (s *brokerSession) close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.changed)
+	s.changed = make(chan struct{})
+	hangUp := s.hangUp
+	s.mu.Unlock()
+
+	if hangUp != nil {
+		hangUp()
+	}
+}
+
+func (s *brokerSession) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// open registers a new session and challenges it
+func (b *Broker) open(s *brokerSession) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sessions[s] = true
+	s.send(interfaces.TopicConnection, interfaces.ActionChallenge)
+}
+
+func (b *Broker) handle(w http.ResponseWriter, r *http.Request) {
+	ws, err := b.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	s := newBrokerSession()
+	s.hangUp = func() { ws.Close() }
+	b.open(s)
+	defer b.closeSession(s)
+
+	go func() {
+		for {
+			raw, ok := s.next()
+			if !ok {
+				return
+			}
+			if err := ws.WriteMessage(websocket.TextMessage, []byte(raw)); err != nil {
+				ws.Close()
+				return
+			}
+		}
+	}()
+	for {
+		_, body, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		b.receive(s, string(body))
+	}
+}
+
+//NewProtocol returns a protocol connecting to the broker in process, without a
+//websocket, e.g. for client.New
+func (b *Broker) NewProtocol() interfaces.Protocol {
+	return &brokerProtocol{broker: b}
+}
+
+type brokerProtocol struct {
+	broker *Broker
+
+	mu      sync.Mutex
+	session *brokerSession
+}
+
+func (p *brokerProtocol) current() *brokerSession {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.session
+}
+
+func (p *brokerProtocol) Connect() error {
+	s := newBrokerSession()
+	p.mu.Lock()
+	previous := p.session
+	p.session = s
+	p.mu.Unlock()
+
+	if previous != nil {
+		p.broker.closeSession(previous)
+	}
+	p.broker.open(s)
+	return nil
+}
+
+func (p *brokerProtocol) Close() error {
+	if s := p.current(); s != nil {
+		p.broker.closeSession(s)
+	}
+	return nil
+}
+
+func (p *brokerProtocol) SendAction(action interfaces.Action) error {
+	s := p.current()
+	if s == nil || s.isClosed() {
+		return ErrBrokerClosed
+	}
+	p.broker.receive(s, action.ToAction())
+	return nil
+}
+
+func (p *brokerProtocol) RecvActions() ([]interfaces.Action, error) {
+	s := p.current()
+	if s == nil {
+		return nil, ErrBrokerClosed
+	}
+	for {
+		raw, ok := s.next()
+		if !ok {
+			return nil, ErrBrokerClosed
+		}
+		actions, err := parseActions(raw)
+		if err != nil {
+			// the client can't categorize every message of the protocol, e.g.
+			// presence, it would drop them anyway
+			continue
+		}
+		return actions, nil
+	}
+}
+
+// closeSession forgets everything about s and tells the other clients
+func (b *Broker) closeSession(s *brokerSession) {
+	b.mu.Lock()
+	if b.sessions[s] {
+		delete(b.sessions, s)
+		b.leave(s)
+	}
+	b.mu.Unlock()
+
+	s.close()
+}
+
+// leave removes s from every subscription, b.mu must be held
+func (b *Broker) leave(s *brokerSession) {
+	delete(b.presence, s)
+	for topic, names := range b.subjects {
+		for name, subject := range names {
+			if subject.subscribers[s] {
+				delete(subject.subscribers, s)
+				b.release(topic, name)
+			}
+		}
+	}
+	for topic, listeners := range b.listeners {
+		for _, l := range listeners {
+			if l.session == s {
+				b.removeListener(topic, l)
+			}
+		}
+	}
+	b.dropProvider(s)
+	if s.authenticated {
+		b.users[s.username]--
+		if b.users[s.username] == 0 {
+			delete(b.users, s.username)
+			b.announce(interfaces.ActionPresenceLeave, s.username)
+		}
+	}
+}
+
+// receive handles the raw messages sent by the client of s
+func (b *Broker) receive(s *brokerSession, raw string) {
+	msgs, err := message.ParseMessages(raw)
+	if err != nil {
+		s.send(interfaces.TopicError, interfaces.ActionError, errors.ErrMessageParseError.Event, message.ToReadable(raw))
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, msg := range msgs {
+		if !b.sessions[s] {
+			return
+		}
+		b.route(s, msg)
+	}
+}
+
+// route handles a message, b.mu must be held
+func (b *Broker) route(s *brokerSession, msg *message.Message) {
+	switch msg.Topic {
+	case interfaces.TopicConnection:
+		b.routeConnection(s, msg)
+		return
+	case interfaces.TopicAuth:
+		b.routeAuth(s, msg)
+		return
+	}
+	if !s.authenticated {
+		s.send(msg.Topic, interfaces.ActionError, errors.ErrNotAuthenticated.Event, nameOf(msg))
+		return
+	}
+	switch msg.Topic {
+	case interfaces.TopicEvent:
+		b.routeEvent(s, msg)
+	case interfaces.TopicRecord:
+		b.routeRecord(s, msg)
+	case interfaces.TopicRPC:
+		b.routeRPC(s, msg)
+	case interfaces.TopicPresence:
+		b.routePresence(s, msg)
+	default:
+		s.send(interfaces.TopicError, interfaces.ActionError, errors.ErrUnknownTopic.Event, msg.Topic)
+	}
+}
+
+// nameOf returns the name a message is about, its first data part
+func nameOf(msg *message.Message) string {
+	if len(msg.RawData) == 0 {
+		return ""
+	}
+	return msg.RawData[0]
+}
+
+func unknownAction(s *brokerSession, msg *message.Message) {
+	s.send(msg.Topic, interfaces.ActionError, errors.ErrUnknownActionEvent.Event, msg.Action)
+}
+
+// invalidMessage tells the client the message misses data
+func invalidMessage(s *brokerSession, msg *message.Message) {
+	s.send(msg.Topic, interfaces.ActionError, errors.ErrInvalidMessageData.Event, msg.String())
+}
+
+func (b *Broker) routeConnection(s *brokerSession, msg *message.Message) {
+	switch msg.Action {
+	case interfaces.ActionChallengeResponse:
+		s.send(interfaces.TopicConnection, interfaces.ActionAck)
+	case interfaces.ActionPing:
+		s.send(interfaces.TopicConnection, interfaces.ActionPong)
+	case interfaces.ActionPong:
+	default:
+		unknownAction(s, msg)
+	}
+}
+
+func (b *Broker) routeAuth(s *brokerSession, msg *message.Message) {
+	if msg.Action != interfaces.ActionRequest {
+		unknownAction(s, msg)
+		return
+	}
+	params := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(nameOf(msg)), &params); err != nil {
+		s.send(interfaces.TopicAuth, interfaces.ActionError, errors.ErrInvalidAuthMsg.Event, "Sinvalid authentication message")
+		return
+	}
+	username, err := b.options.Authenticate(params)
+	if err != nil {
+		s.send(interfaces.TopicAuth, interfaces.ActionError, errors.ErrInvalidAuthData.Event, "S"+err.Error())
+		return
+	}
+	if s.authenticated {
+		s.send(interfaces.TopicAuth, interfaces.ActionAck)
+		return
+	}
+
+	s.authenticated = true
+	s.username = username
+	s.send(interfaces.TopicAuth, interfaces.ActionAck)
+	b.users[username]++
+	if b.users[username] == 1 {
+		b.announce(interfaces.ActionPresenceJoin, username)
+	}
+}
+
+func (b *Broker) routeEvent(s *brokerSession, msg *message.Message) {
+	switch msg.Action {
+	case interfaces.ActionSubscribe, interfaces.ActionUnsubscribe:
+		if len(msg.RawData) < 1 {
+			invalidMessage(s, msg)
+			return
+		}
+		if msg.Action == interfaces.ActionSubscribe {
+			b.subscribe(s, msg.Topic, msg.RawData[0], true)
+		} else {
+			b.unsubscribe(s, msg.Topic, msg.RawData[0])
+		}
+	case interfaces.ActionEvent:
+		if len(msg.RawData) < 1 {
+			invalidMessage(s, msg)
+			return
+		}
+		if subject := b.subjects[msg.Topic][msg.RawData[0]]; subject != nil {
+			for subscriber := range subject.subscribers {
+				if subscriber != s {
+					subscriber.send(msg.Topic, msg.Action, msg.RawData...)
+				}
+			}
+		}
+	default:
+		b.routeListen(s, msg)
+	}
+}
+
+// subjectOf returns the subscriptions to a topic name, b.mu must be held
+func (b *Broker) subjectOf(topic, name string) *brokerSubject {
+	if b.subjects[topic] == nil {
+		b.subjects[topic] = map[string]*brokerSubject{}
+	}
+	subject, ok := b.subjects[topic][name]
+	if !ok {
+		subject = &brokerSubject{
+			subscribers: map[*brokerSession]bool{},
+			rejected:    map[*brokerListener]bool{},
+		}
+		b.subjects[topic][name] = subject
+	}
+	return subject
+}
+
+// subscribe adds s to the subscribers of a topic name, acknowledging it if ack
+// is set, b.mu must be held
+func (b *Broker) subscribe(s *brokerSession, topic, name string, ack bool) {
+	subject := b.subjectOf(topic, name)
+	if subject.subscribers[s] {
+		if ack {
+			s.send(topic, interfaces.ActionError, errors.ErrMultipleSubscriptions.Event, name)
+		}
+		return
+	}
+	subject.subscribers[s] = true
+	if ack {
+		s.send(topic, interfaces.ActionAck, interfaces.ActionSubscribe, name)
+	}
+	b.offer(topic, name)
+}
+
+// unsubscribe removes s from the subscribers of a topic name, b.mu must be held
+func (b *Broker) unsubscribe(s *brokerSession, topic, name string) {
+	subject := b.subjects[topic][name]
+	if subject == nil || !subject.subscribers[s] {
+		s.send(topic, interfaces.ActionError, errors.ErrNotSubscribed.Event, name)
+		return
+	}
+	delete(subject.subscribers, s)
+	s.send(topic, interfaces.ActionAck, interfaces.ActionUnsubscribe, name)
+	b.release(topic, name)
+}
+
+// release forgets a topic name nobody subscribes to anymore, telling its
+// provider to stop, b.mu must be held
+func (b *Broker) release(topic, name string) {
+	subject := b.subjects[topic][name]
+	if subject == nil || len(subject.subscribers) > 0 {
+		return
+	}
+	if l := subject.provider; l != nil {
+		l.session.send(topic, interfaces.ActionSubscriptionForPatternRemoved, l.pattern, name)
+	}
+	delete(b.subjects[topic], name)
+}
+
+// offer asks the next listener matching a subscribed topic name to provide it,
+// b.mu must be held
+func (b *Broker) offer(topic, name string) {
+	subject := b.subjects[topic][name]
+	if subject == nil || len(subject.subscribers) == 0 || subject.provider != nil || subject.offered != nil {
+		return
+	}
+	for _, l := range b.listeners[topic] {
+		if l.re.MatchString(name) && !subject.rejected[l] {
+			subject.offered = l
+			l.session.send(topic, interfaces.ActionSubscriptionForPatternFound, l.pattern, name)
+			return
+		}
+	}
+}
+
+func (b *Broker) routeListen(s *brokerSession, msg *message.Message) {
+	topic := msg.Topic
+	switch msg.Action {
+	case interfaces.ActionListen, interfaces.ActionUnlisten:
+		if len(msg.RawData) < 1 {
+			invalidMessage(s, msg)
+			return
+		}
+		pattern := msg.RawData[0]
+		if msg.Action == interfaces.ActionUnlisten {
+			for _, l := range b.listeners[topic] {
+				if l.session == s && l.pattern == pattern {
+					b.removeListener(topic, l)
+					s.send(topic, interfaces.ActionAck, interfaces.ActionUnlisten, pattern)
+					return
+				}
+			}
+			s.send(topic, interfaces.ActionError, errors.ErrNotListening.Event, pattern)
+			return
+		}
+
+		for _, l := range b.listeners[topic] {
+			if l.session == s && l.pattern == pattern {
+				s.send(topic, interfaces.ActionError, errors.ErrListenerExists.Event, pattern)
+				return
+			}
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			invalidMessage(s, msg)
+			return
+		}
+		b.listeners[topic] = append(b.listeners[topic], &brokerListener{session: s, pattern: pattern, re: re})
+		s.send(topic, interfaces.ActionAck, interfaces.ActionListen, pattern)
+		names := []string{}
+		for name := range b.subjects[topic] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b.offer(topic, name)
+		}
+	case interfaces.ActionListenAccept, interfaces.ActionListenReject:
+		if len(msg.RawData) < 2 {
+			invalidMessage(s, msg)
+			return
+		}
+		subject := b.subjects[topic][msg.RawData[1]]
+		if subject == nil || subject.offered == nil || subject.offered.session != s || subject.offered.pattern != msg.RawData[0] {
+			return
+		}
+		l := subject.offered
+		subject.offered = nil
+		if msg.Action == interfaces.ActionListenAccept {
+			subject.provider = l
+			return
+		}
+		subject.rejected[l] = true
+		b.offer(topic, msg.RawData[1])
+	default:
+		unknownAction(s, msg)
+	}
+}
+
+// removeListener stops l and offers what it provided to the other listeners,
+// b.mu must be held
+func (b *Broker) removeListener(topic string, l *brokerListener) {
+	listeners := b.listeners[topic]
+	for i, listener := range listeners {
+		if listener == l {
+			b.listeners[topic] = append(listeners[:i:i], listeners[i+1:]...)
+			break
+		}
+	}
+	for name, subject := range b.subjects[topic] {
+		delete(subject.rejected, l)
+		if subject.provider == l || subject.offered == l {
+			subject.provider = nil
+			subject.offered = nil
+			b.offer(topic, name)
+		}
+	}
+}
+
+func (b *Broker) routePresence(s *brokerSession, msg *message.Message) {
+	switch msg.Action {
+	case interfaces.ActionSubscribe:
+		b.presence[s] = true
+		s.send(msg.Topic, interfaces.ActionAck, interfaces.ActionSubscribe, interfaces.ActionSubscribe)
+	case interfaces.ActionUnsubscribe:
+		delete(b.presence, s)
+		s.send(msg.Topic, interfaces.ActionAck, interfaces.ActionUnsubscribe, interfaces.ActionUnsubscribe)
+	case interfaces.ActionQuery:
+		s.send(msg.Topic, interfaces.ActionQuery, b.usernames(s.username)...)
+	default:
+		unknownAction(s, msg)
+	}
+}
+
+// announce tells the presence subscribers a user joined or left, b.mu must be held
+func (b *Broker) announce(action, username string) {
+	for s := range b.presence {
+		if s.username != username {
+			s.send(interfaces.TopicPresence, action, username)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+type brokerRecord struct {
+	version int
+	data    interface{}
+}
+
+func (r *brokerRecord) raw() string {
+	raw, _ := json.Marshal(r.data)
+	return string(raw)
+}
+
+//Record returns a copy of the data and the version of a record, ok is false if
+//it doesn't exist
+func (b *Broker) Record(name string) (data interface{}, version int, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rec, ok := b.records[name]
+	if !ok {
+		return nil, 0, false
+	}
+	return message.DeepCopy(rec.data), rec.version, true
+}
+
+//SetRecord stores data as the next version of a record, creating it if needed,
+//and sends it to the clients subscribed to the record, e.g. to seed a test
+func (b *Broker) SetRecord(name string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rec := b.record(name)
+	rec.version++
+	rec.data = normalized
+	b.publish(nil, name, interfaces.ActionUpdate, name, strconv.Itoa(rec.version), string(raw))
+	return nil
+}
+
+// record returns the record with the given name, creating an empty one if
+// needed, b.mu must be held
+func (b *Broker) record(name string) *brokerRecord {
+	rec, ok := b.records[name]
+	if !ok {
+		rec = &brokerRecord{data: map[string]interface{}{}}
+		b.records[name] = rec
+	}
+	return rec
+}
+
+// publish sends a record message to the subscribers of the record but from,
+// b.mu must be held
+func (b *Broker) publish(from *brokerSession, name, action string, data ...string) {
+	subject := b.subjects[interfaces.TopicRecord][name]
+	if subject == nil {
+		return
+	}
+	for s := range subject.subscribers {
+		if s != from {
+			s.send(interfaces.TopicRecord, action, data...)
+		}
+	}
+}
+
+func (b *Broker) routeRecord(s *brokerSession, msg *message.Message) {
+	topic := msg.Topic
+	switch msg.Action {
+	case interfaces.ActionCreateOrRead:
+		if len(msg.RawData) < 1 {
+			invalidMessage(s, msg)
+			return
+		}
+		name := msg.RawData[0]
+		rec := b.record(name)
+		b.subscribe(s, topic, name, false)
+		s.send(topic, interfaces.ActionAck, interfaces.ActionSubscribe, name)
+		s.send(topic, interfaces.ActionRead, name, strconv.Itoa(rec.version), rec.raw())
+	case interfaces.ActionUpdate, interfaces.ActionPatch:
+		b.writeRecord(s, msg)
+	case interfaces.ActionUnsubscribe:
+		if len(msg.RawData) < 1 {
+			invalidMessage(s, msg)
+			return
+		}
+		b.unsubscribe(s, topic, msg.RawData[0])
+	case interfaces.ActionDelete:
+		if len(msg.RawData) < 1 {
+			invalidMessage(s, msg)
+			return
+		}
+		name := msg.RawData[0]
+		delete(b.records, name)
+		s.send(topic, interfaces.ActionAck, interfaces.ActionDelete, name)
+		b.publish(s, name, interfaces.ActionAck, interfaces.ActionDelete, name)
+		if subject := b.subjects[topic][name]; subject != nil {
+			subject.subscribers = map[*brokerSession]bool{}
+			b.release(topic, name)
+		}
+	case interfaces.ActionHas:
+		if len(msg.RawData) < 1 {
+			invalidMessage(s, msg)
+			return
+		}
+		has := string(interfaces.TypesFalse)
+		if _, ok := b.records[msg.RawData[0]]; ok {
+			has = string(interfaces.TypesTrue)
+		}
+		s.send(topic, interfaces.ActionHas, msg.RawData[0], has)
+	case interfaces.ActionSnapshot:
+		if len(msg.RawData) < 1 {
+			invalidMessage(s, msg)
+			return
+		}
+		rec, ok := b.records[msg.RawData[0]]
+		if !ok {
+			s.send(topic, interfaces.ActionError, errors.ErrRecordNotFound.Event, msg.RawData[0])
+			return
+		}
+		s.send(topic, interfaces.ActionRead, msg.RawData[0], strconv.Itoa(rec.version), rec.raw())
+	default:
+		b.routeListen(s, msg)
+	}
+}
+
+// writeRecord applies R|U|name|version|data and R|P|name|version|path|value,
+// optionally followed by a config asking for a write acknowledgement
+func (b *Broker) writeRecord(s *brokerSession, msg *message.Message) {
+	topic := msg.Topic
+	parts := 3
+	if msg.Action == interfaces.ActionPatch {
+		parts = 4
+	}
+	if len(msg.RawData) < parts {
+		invalidMessage(s, msg)
+		return
+	}
+	name := msg.RawData[0]
+	version, err := strconv.Atoi(msg.RawData[1])
+	if err != nil {
+		s.send(topic, interfaces.ActionError, errors.ErrInvalidVersion.Event, name)
+		return
+	}
+
+	var value interface{}
+	if msg.Action == interfaces.ActionPatch {
+		value, err = message.ParseTyped(msg.RawData[3])
+	} else {
+		err = json.Unmarshal([]byte(msg.RawData[2]), &value)
+	}
+	if err != nil {
+		invalidMessage(s, msg)
+		return
+	}
+
+	rec := b.record(name)
+	if version != rec.version+1 {
+		s.send(topic, interfaces.ActionError, errors.ErrVersionExists.Event, name, strconv.Itoa(rec.version), rec.raw())
+		return
+	}
+	if msg.Action == interfaces.ActionPatch {
+		if value, err = message.SetPath(rec.data, msg.RawData[2], value); err != nil {
+			invalidMessage(s, msg)
+			return
+		}
+	}
+	rec.data = value
+	rec.version = version
+	b.publish(s, name, msg.Action, msg.RawData[:parts]...)
+
+	if len(msg.RawData) > parts && wantsWriteAck(msg.RawData[parts]) {
+		s.send(topic, interfaces.ActionWriteAcknowledgement, name, "["+strconv.Itoa(version)+"]", string(interfaces.TypesNull))
+	}
+}
+
+func wantsWriteAck(config string) bool {
+	var options struct {
+		WriteSuccess bool `json:"writeSuccess"`
+	}
+	return json.Unmarshal([]byte(config), &options) == nil && options.WriteSuccess
+}
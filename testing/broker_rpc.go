@@ -0,0 +1,166 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing
+
+import (
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+// brokerRequest is an RPC request waiting for its provider's response
+type brokerRequest struct {
+	name      string
+	cid       string
+	data      []string
+	requester *brokerSession
+	provider  *brokerSession
+	tried     map[*brokerSession]bool
+}
+
+func (b *Broker) routeRPC(s *brokerSession, msg *message.Message) {
+	topic := msg.Topic
+	switch msg.Action {
+	case interfaces.ActionSubscribe:
+		if len(msg.RawData) < 1 {
+			invalidMessage(s, msg)
+			return
+		}
+		name := msg.RawData[0]
+		for _, provider := range b.providers[name] {
+			if provider == s {
+				s.send(topic, interfaces.ActionError, errors.ErrMultipleSubscriptions.Event, name)
+				return
+			}
+		}
+		b.providers[name] = append(b.providers[name], s)
+		s.send(topic, interfaces.ActionAck, interfaces.ActionSubscribe, name)
+	case interfaces.ActionUnsubscribe:
+		if len(msg.RawData) < 1 {
+			invalidMessage(s, msg)
+			return
+		}
+		name := msg.RawData[0]
+		if !b.removeProvider(s, name) {
+			s.send(topic, interfaces.ActionError, errors.ErrNotSubscribed.Event, name)
+			return
+		}
+		s.send(topic, interfaces.ActionAck, interfaces.ActionUnsubscribe, name)
+	case interfaces.ActionRequest:
+		if len(msg.RawData) < 2 {
+			invalidMessage(s, msg)
+			return
+		}
+		req := &brokerRequest{
+			name:      msg.RawData[0],
+			cid:       msg.RawData[1],
+			data:      msg.RawData[2:],
+			requester: s,
+			tried:     map[*brokerSession]bool{},
+		}
+		b.requests[req.cid] = req
+		b.assign(req)
+	case interfaces.ActionAck:
+		// providers acknowledge with P|A|REQ|name|cid
+		if len(msg.RawData) < 3 || msg.RawData[0] != interfaces.ActionRequest {
+			return
+		}
+		if req := b.requestOf(s, msg.RawData[2]); req != nil {
+			req.requester.send(topic, interfaces.ActionAck, msg.RawData...)
+		}
+	case interfaces.ActionResponse, interfaces.ActionRejection:
+		if len(msg.RawData) < 2 {
+			invalidMessage(s, msg)
+			return
+		}
+		req := b.requestOf(s, msg.RawData[1])
+		if req == nil {
+			return
+		}
+		if msg.Action == interfaces.ActionRejection {
+			b.assign(req)
+			return
+		}
+		delete(b.requests, req.cid)
+		req.requester.send(topic, msg.Action, msg.RawData...)
+	case interfaces.ActionError:
+		// providers fail requests with P|E|reason|name|cid
+		if len(msg.RawData) < 3 {
+			invalidMessage(s, msg)
+			return
+		}
+		if req := b.requestOf(s, msg.RawData[2]); req != nil {
+			delete(b.requests, req.cid)
+			req.requester.send(topic, msg.Action, msg.RawData...)
+		}
+	default:
+		unknownAction(s, msg)
+	}
+}
+
+// requestOf returns the request with the given correlation id if provider is
+// handling it, b.mu must be held
+func (b *Broker) requestOf(provider *brokerSession, cid string) *brokerRequest {
+	req := b.requests[cid]
+	if req == nil || req.provider != provider {
+		return nil
+	}
+	return req
+}
+
+// assign routes req to the next provider that didn't reject it yet, taking turns
+// between providers, or fails it with NO_RPC_PROVIDER, b.mu must be held
+func (b *Broker) assign(req *brokerRequest) {
+	providers := b.providers[req.name]
+	for i := range providers {
+		provider := providers[(b.turns[req.name]+i)%len(providers)]
+		if req.tried[provider] {
+			continue
+		}
+		b.turns[req.name]++
+		req.tried[provider] = true
+		req.provider = provider
+		provider.send(interfaces.TopicRPC, interfaces.ActionRequest, append([]string{req.name, req.cid}, req.data...)...)
+		return
+	}
+
+	delete(b.requests, req.cid)
+	req.requester.send(interfaces.TopicRPC, interfaces.ActionError, errors.ErrNoRPCProvider.Event, req.name, req.cid)
+}
+
+// removeProvider stops routing the requests of an RPC to s, returning false if
+// s didn't provide it, b.mu must be held
+func (b *Broker) removeProvider(s *brokerSession, name string) bool {
+	providers := b.providers[name]
+	for i, provider := range providers {
+		if provider == s {
+			b.providers[name] = append(providers[:i:i], providers[i+1:]...)
+			if len(b.providers[name]) == 0 {
+				delete(b.providers, name)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// dropProvider forgets a disconnected client, rerouting the requests it was
+// handling and dropping the ones it made, b.mu must be held
+func (b *Broker) dropProvider(s *brokerSession) {
+	for name := range b.providers {
+		b.removeProvider(s, name)
+	}
+	for cid, req := range b.requests {
+		switch {
+		case req.requester == s:
+			delete(b.requests, cid)
+		case req.provider == s:
+			b.assign(req)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing_test
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/errors"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+	dstesting "github.com/ga-con/deepstream.io-client-go/testing"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func brokerOptions(opts *client.ClientOptions) error {
+	opts.ManualLogin = true
+	opts.RecIntvlMin = 10 * time.Millisecond
+	opts.Logger = client.NopLogger{}
+	opts.RPCAckTimeout = time.Second
+	return nil
+}
+
+// rawConn talks to a broker in readable notation, for what the client doesn't support
+type rawConn struct {
+	ws *websocket.Conn
+}
+
+func (c *rawConn) send(readable string) {
+	Expect(c.ws.WriteMessage(websocket.TextMessage, []byte(message.FromReadable(readable)))).To(Succeed())
+}
+
+func (c *rawConn) next() string {
+	c.ws.SetReadDeadline(time.Now().Add(time.Second))
+	_, body, err := c.ws.ReadMessage()
+	Expect(err).NotTo(HaveOccurred())
+	return message.ToReadable(string(body))
+}
+
+var _ = Describe("Broker", func() {
+	var broker *dstesting.Broker
+	var clients []*client.Client
+
+	BeforeEach(func() {
+		broker = dstesting.NewBroker(dstesting.BrokerOptions{})
+		clients = nil
+	})
+
+	AfterEach(func() {
+		for _, cli := range clients {
+			cli.Close()
+		}
+		broker.Close()
+	})
+
+	login := func(cli *client.Client, username string) {
+		clients = append(clients, cli)
+		Eventually(func() interfaces.ConnectionState {
+			return cli.State()
+		}).Should(Equal(interfaces.ConnectionStateAwaitingAuthentication))
+		Expect(cli.Login(map[string]interface{}{"username": username})).To(Succeed())
+	}
+
+	connect := func(username string) *client.Client {
+		cli, err := client.New(broker.URL, broker.NewProtocol(), brokerOptions)
+		Expect(err).NotTo(HaveOccurred())
+		login(cli, username)
+		return cli
+	}
+
+	dialRaw := func(username string) *rawConn {
+		ws, _, err := websocket.DefaultDialer.Dial(broker.URL, nil)
+		Expect(err).NotTo(HaveOccurred())
+		conn := &rawConn{ws: ws}
+		Expect(conn.next()).To(Equal("C|CH+"))
+		conn.send("C|CHR|" + broker.URL)
+		Expect(conn.next()).To(Equal("C|A+"))
+		conn.send(fmt.Sprintf(`A|REQ|{"username":"%s"}`, username))
+		Expect(conn.next()).To(Equal("A|A+"))
+		return conn
+	}
+
+	Describe("[Unit]", func() {
+		Describe("Authentication", func() {
+			It("Should log clients in", func() {
+				connect("userA")
+				connect("userB")
+				Expect(broker.Users()).To(Equal([]string{"userA", "userB"}))
+				Expect(broker.Connections()).To(Equal(2))
+			})
+
+			It("Should reject the logins refused by Authenticate", func() {
+				broker.Close()
+				broker = dstesting.NewBroker(dstesting.BrokerOptions{
+					Authenticate: func(params map[string]interface{}) (string, error) {
+						return "", fmt.Errorf("invalid authentication data")
+					},
+				})
+
+				cli, err := client.New(broker.URL, broker.NewProtocol(), brokerOptions)
+				Expect(err).NotTo(HaveOccurred())
+				clients = append(clients, cli)
+				Eventually(func() interfaces.ConnectionState {
+					return cli.State()
+				}).Should(Equal(interfaces.ConnectionStateAwaitingAuthentication))
+
+				err = cli.Login(map[string]interface{}{"username": "userA"})
+				Expect(err).To(HaveOccurred())
+				Expect(broker.Users()).To(BeEmpty())
+			})
+		})
+
+		Describe("Events", func() {
+			It("Should deliver events to the other clients", func() {
+				subscriber := connect("userA")
+				publisher := connect("userB")
+
+				received := make(chan interface{}, 1)
+				_, err := subscriber.SubscribeEvent("test1", func(data interface{}) {
+					received <- data
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(publisher.EmitEvent("test1", map[string]interface{}{"a": 1})).To(Succeed())
+				Eventually(received).Should(Receive(Equal(map[string]interface{}{"a": float64(1)})))
+			})
+		})
+
+		Describe("Records", func() {
+			It("Should share records between clients", func() {
+				recA, err := connect("userA").GetRecord("user/lisa")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(recA.Set(map[string]interface{}{"name": "Lisa"})).To(Succeed())
+
+				recB, err := connect("userB").GetRecord("user/lisa")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(recB.Get()).To(Equal(map[string]interface{}{"name": "Lisa"}))
+				Expect(recB.Version()).To(Equal(1))
+
+				Expect(recB.SetPathWithAck("pets[0]", "Snowball")).To(Succeed())
+				Eventually(func() interface{} { return recA.GetPath("pets[0]") }).Should(Equal("Snowball"))
+
+				data, version, ok := broker.Record("user/lisa")
+				Expect(ok).To(BeTrue())
+				Expect(version).To(Equal(2))
+				Expect(data).To(Equal(map[string]interface{}{"name": "Lisa", "pets": []interface{}{"Snowball"}}))
+			})
+
+			It("Should send the records set by the test", func() {
+				rec, err := connect("userA").GetRecord("config")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(broker.SetRecord("config", map[string]interface{}{"debug": true})).To(Succeed())
+				Eventually(rec.Get).Should(Equal(map[string]interface{}{"debug": true}))
+
+				Expect(rec.Update(func(current interface{}) (interface{}, error) {
+					current.(map[string]interface{})["debug"] = false
+					return current, nil
+				})).To(Succeed())
+				data, version, _ := broker.Record("config")
+				Expect(version).To(Equal(2))
+				Expect(data).To(Equal(map[string]interface{}{"debug": false}))
+			})
+
+			It("Should delete records", func() {
+				rec, err := connect("userA").GetRecord("doc")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rec.Delete()).To(Succeed())
+
+				Eventually(func() bool {
+					_, _, ok := broker.Record("doc")
+					return ok
+				}).Should(BeFalse())
+			})
+		})
+
+		Describe("RPC", func() {
+			It("Should route requests to a provider", func() {
+				provider := connect("provider")
+				Expect(provider.Provide("toUppercase", func(req *client.RPCRequest) {
+					req.Send(strings.ToUpper(req.Data.(string)))
+				})).To(Succeed())
+
+				result, err := connect("requester").Make("toUppercase", "abc")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(Equal("ABC"))
+			})
+
+			It("Should forward the errors of providers", func() {
+				provider := connect("provider")
+				Expect(provider.Provide("fail", func(req *client.RPCRequest) {
+					req.Error("oops")
+				})).To(Succeed())
+
+				_, err := connect("requester").Make("fail", nil)
+				Expect(err).To(Equal(&errors.RPCProviderError{Name: "fail", Message: "oops"}))
+			})
+
+			It("Should reroute rejected requests to another provider", func() {
+				var rejected int32
+				reluctant := connect("reluctant")
+				Expect(reluctant.Provide("toUppercase", func(req *client.RPCRequest) {
+					atomic.AddInt32(&rejected, 1)
+					req.Reject()
+				})).To(Succeed())
+				willing := connect("willing")
+				Expect(willing.Provide("toUppercase", func(req *client.RPCRequest) {
+					req.Send(strings.ToUpper(req.Data.(string)))
+				})).To(Succeed())
+
+				requester := connect("requester")
+				for _, data := range []string{"abc", "def"} {
+					result, err := requester.Make("toUppercase", data)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result).To(Equal(strings.ToUpper(data)))
+				}
+				Expect(atomic.LoadInt32(&rejected)).To(BeNumerically(">=", 1))
+			})
+
+			It("Should fail requests without providers", func() {
+				requester := connect("requester")
+				_, err := requester.Make("missing", nil)
+				Expect(err).To(MatchError(errors.ErrNoRPCProvider))
+
+				provider := connect("provider")
+				Expect(provider.Provide("rejected", func(req *client.RPCRequest) {
+					req.Reject()
+				})).To(Succeed())
+				_, err = requester.Make("rejected", nil)
+				Expect(err).To(MatchError(errors.ErrNoRPCProvider))
+			})
+
+			It("Should reroute the requests of disconnected providers", func() {
+				requests := make(chan *client.RPCRequest, 1)
+				leaving := connect("leaving")
+				Expect(leaving.Provide("toUppercase", func(req *client.RPCRequest) {
+					requests <- req
+				})).To(Succeed())
+
+				requester := connect("requester")
+				result := make(chan interface{}, 1)
+				go func() {
+					data, _ := requester.Make("toUppercase", "abc")
+					result <- data
+				}()
+				Eventually(requests).Should(Receive())
+
+				staying := connect("staying")
+				Expect(staying.Provide("toUppercase", func(req *client.RPCRequest) {
+					req.Send(strings.ToUpper(req.Data.(string)))
+				})).To(Succeed())
+				Expect(leaving.Close()).To(Succeed())
+				Eventually(result).Should(Receive(Equal("ABC")))
+			})
+		})
+	})
+
+	Describe("[Integration]", func() {
+		It("Should serve clients over websockets", func() {
+			dial := func(username string) *client.Client {
+				cli, err := client.Dial(broker.URL, brokerOptions, func(opts *client.ClientOptions) error {
+					opts.HandshakeTimeout = 0
+					return nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				login(cli, username)
+				return cli
+			}
+			subscriber := dial("userA")
+			publisher := connect("userB")
+
+			received := make(chan interface{}, 100)
+			_, err := subscriber.SubscribeEvent("test1", func(data interface{}) {
+				received <- data
+			})
+			Expect(err).NotTo(HaveOccurred())
+			// the subscription isn't acknowledged, emit until it reaches the broker
+			Eventually(func() interface{} {
+				Expect(publisher.EmitEvent("test1", "data")).To(Succeed())
+				select {
+				case data := <-received:
+					return data
+				case <-time.After(20 * time.Millisecond):
+					return nil
+				}
+			}).Should(Equal("data"))
+		})
+
+		It("Should tell presence subscribers who joins and leaves", func() {
+			watcher := dialRaw("watcher")
+			watcher.send("U|S|S")
+			Expect(watcher.next()).To(Equal("U|A|S|S+"))
+
+			joining := connect("userA")
+			Expect(watcher.next()).To(Equal("U|PNJ|userA+"))
+
+			watcher.send("U|Q|Q")
+			Expect(watcher.next()).To(Equal("U|Q|userA+"))
+
+			Expect(joining.Close()).To(Succeed())
+			Expect(watcher.next()).To(Equal("U|PNL|userA+"))
+		})
+
+		It("Should let listeners provide the events subscribed to", func() {
+			reluctant := dialRaw("reluctant")
+			reluctant.send("E|L|news/.*")
+			Expect(reluctant.next()).To(Equal("E|A|L|news/.*+"))
+			listener := dialRaw("listener")
+			listener.send("E|L|news/.*")
+			Expect(listener.next()).To(Equal("E|A|L|news/.*+"))
+
+			subscriber := connect("subscriber")
+			received := make(chan interface{}, 1)
+			id, err := subscriber.SubscribeEvent("news/sports", func(data interface{}) {
+				received <- data
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reluctant.next()).To(Equal("E|SP|news/.*|news/sports+"))
+			reluctant.send("E|LR|news/.*|news/sports")
+			Expect(listener.next()).To(Equal("E|SP|news/.*|news/sports+"))
+			listener.send("E|LA|news/.*|news/sports")
+
+			listener.send("E|EVT|news/sports|Sgoal")
+			Eventually(received).Should(Receive(Equal("goal")))
+
+			Expect(subscriber.UnsubscribeEvent("news/sports", id)).To(Succeed())
+			Expect(listener.next()).To(Equal("E|SR|news/.*|news/sports+"))
+		})
+
+		It("Should refuse stale record versions", func() {
+			conn := dialRaw("userA")
+			conn.send("R|CR|doc")
+			Expect(conn.next()).To(Equal("R|A|S|doc+"))
+			Expect(conn.next()).To(Equal("R|R|doc|0|{}+"))
+
+			conn.send(`R|U|doc|1|{"a":1}|{"writeSuccess":true}`)
+			Expect(conn.next()).To(Equal("R|WA|doc|[1]|L+"))
+			conn.send(`R|U|doc|1|{"a":2}`)
+			Expect(conn.next()).To(Equal(`R|E|VERSION_EXISTS|doc|1|{"a":1}+`))
+		})
+
+		It("Should report unknown topics and unauthenticated messages", func() {
+			ws, _, err := websocket.DefaultDialer.Dial(broker.URL, nil)
+			Expect(err).NotTo(HaveOccurred())
+			conn := &rawConn{ws: ws}
+			Expect(conn.next()).To(Equal("C|CH+"))
+			conn.send("E|S|test1")
+			Expect(conn.next()).To(Equal("E|E|NOT_AUTHENTICATED|test1+"))
+
+			conn = dialRaw("userA")
+			conn.send("Z|S|test1")
+			Expect(conn.next()).To(Equal("X|E|UNKNOWN_TOPIC|Z+"))
+		})
+	})
+})
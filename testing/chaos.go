@@ -0,0 +1,336 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+//ErrChaosDisconnect is returned by a ChaosProtocol when it forces a disconnection
+var ErrChaosDisconnect = fmt.Errorf("chaos protocol forced a disconnection")
+
+//ChaosOptions configures the faults injected by a ChaosProtocol. Probabilities go
+//from 0, never, to 1, always, and are rolled for every message in both directions.
+type ChaosOptions struct {
+	// Seed makes the faults deterministic, the same seed and traffic inject the same faults
+	Seed int64
+	// Drop is the probability of losing a message
+	Drop float64
+	// Delay is the probability of delaying a message by up to MaxDelay
+	Delay float64
+	// MaxDelay is the longest delay, default to 100ms
+	MaxDelay time.Duration
+	// Duplicate is the probability of delivering a message twice
+	Duplicate float64
+	// Reorder is the probability of delivering a message after the next one
+	Reorder float64
+	// Truncate is the probability of cutting a message short. Truncated inbound
+	// messages that can't be parsed anymore are lost, see ChaosStats.Lost.
+	Truncate float64
+	// Disconnect is the probability of closing the connection instead of
+	// delivering a message
+	Disconnect float64
+	// Clock measures the delays, default to the system clock, e.g. the
+	// FakeClock given to client.WithClock
+	Clock interfaces.Clock
+}
+
+//ChaosStats counts the faults injected by a ChaosProtocol
+type ChaosStats struct {
+	Dropped     int
+	Delayed     int
+	Duplicated  int
+	Reordered   int
+	Truncated   int
+	Disconnects int
+	// Lost counts the truncated inbound messages that couldn't be parsed
+	// anymore and were never delivered
+	Lost int
+}
+
+//ChaosProtocol wraps a protocol and injects network faults into its traffic, to
+//exercise reconnection and recovery without a real network, e.g. with
+//client.New(url, NewChaosProtocol(NewMockProtocol(), options))
+type ChaosProtocol struct {
+	inner interfaces.Protocol
+
+	mu      sync.Mutex
+	options ChaosOptions
+	sendRng *rand.Rand
+	recvRng *rand.Rand
+	stats   ChaosStats
+	// held are the messages reordered after the next one in each direction
+	heldSend interfaces.Action
+	heldRecv []interfaces.Action
+}
+
+//NewChaosProtocol wraps inner with the faults configured by options
+func NewChaosProtocol(inner interfaces.Protocol, options ChaosOptions) *ChaosProtocol {
+	if options.MaxDelay == 0 {
+		options.MaxDelay = 100 * time.Millisecond
+	}
+	return &ChaosProtocol{
+		inner:   inner,
+		options: options,
+		// each direction has its own source so concurrent sends and receives
+		// don't change each other's faults
+		sendRng: rand.New(rand.NewSource(options.Seed)),
+		recvRng: rand.New(rand.NewSource(options.Seed + 1)),
+	}
+}
+
+//SetOptions changes the faults injected from now on, keeping the random sources
+func (c *ChaosProtocol) SetOptions(options ChaosOptions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if options.MaxDelay == 0 {
+		options.MaxDelay = 100 * time.Millisecond
+	}
+	c.options = options
+}
+
+//Stats returns the faults injected so far
+func (c *ChaosProtocol) Stats() ChaosStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+//Disconnect closes the wrapped connection right away, like a network failure
+func (c *ChaosProtocol) Disconnect() error {
+	c.mu.Lock()
+	c.stats.Disconnects++
+	c.mu.Unlock()
+
+	return c.inner.Close()
+}
+
+// sleep waits for d on the configured clock
+func (c *ChaosProtocol) sleep(d time.Duration) {
+	c.mu.Lock()
+	clock := c.options.Clock
+	c.mu.Unlock()
+
+	if clock == nil {
+		time.Sleep(d)
+		return
+	}
+	clock.Sleep(d)
+}
+
+// roll returns true with probability p, the rng must be used with c.mu held
+func roll(rng *rand.Rand, p float64) bool {
+	return p > 0 && rng.Float64() < p
+}
+
+// faults are the faults rolled for a single message
+type faults struct {
+	disconnect bool
+	drop       bool
+	delay      time.Duration
+	truncateAt int
+	duplicate  bool
+	reorder    bool
+}
+
+// rollFaults decides what happens to a message of length size, c.mu must be held
+func (c *ChaosProtocol) rollFaults(rng *rand.Rand, size int) faults {
+	var f faults
+	opts := c.options
+	if roll(rng, opts.Disconnect) {
+		c.stats.Disconnects++
+		f.disconnect = true
+		return f
+	}
+	if roll(rng, opts.Drop) {
+		c.stats.Dropped++
+		f.drop = true
+		return f
+	}
+	if roll(rng, opts.Delay) {
+		c.stats.Delayed++
+		f.delay = time.Duration(rng.Int63n(int64(opts.MaxDelay)) + 1)
+	}
+	if roll(rng, opts.Truncate) && size > 1 {
+		c.stats.Truncated++
+		f.truncateAt = rng.Intn(size-1) + 1
+	}
+	if roll(rng, opts.Duplicate) {
+		c.stats.Duplicated++
+		f.duplicate = true
+	}
+	if roll(rng, opts.Reorder) {
+		c.stats.Reordered++
+		f.reorder = true
+	}
+	return f
+}
+
+// rawAction is an action sent as is, e.g. a truncated one
+type rawAction string
+
+func (a rawAction) ToAction() string {
+	return string(a)
+}
+
+func truncate(action interfaces.Action, at int) interfaces.Action {
+	raw := strings.TrimSuffix(action.ToAction(), interfaces.MessageSeparator)
+	return rawAction(raw[:at] + interfaces.MessageSeparator)
+}
+
+func messageSize(action interfaces.Action) int {
+	return len(strings.TrimSuffix(action.ToAction(), interfaces.MessageSeparator))
+}
+
+//Connect connects the wrapped protocol and forgets the reordered messages
+func (c *ChaosProtocol) Connect() error {
+	c.mu.Lock()
+	c.heldSend = nil
+	c.heldRecv = nil
+	c.mu.Unlock()
+
+	return c.inner.Connect()
+}
+
+//Close closes the wrapped protocol
+func (c *ChaosProtocol) Close() error {
+	return c.inner.Close()
+}
+
+//SendAction sends action through the wrapped protocol, unless a fault is injected
+func (c *ChaosProtocol) SendAction(action interfaces.Action) error {
+	c.mu.Lock()
+	f := c.rollFaults(c.sendRng, messageSize(action))
+	c.mu.Unlock()
+
+	if f.disconnect {
+		c.inner.Close()
+		return ErrChaosDisconnect
+	}
+	if f.drop {
+		return nil
+	}
+	if f.delay > 0 {
+		c.sleep(f.delay)
+	}
+	if f.truncateAt > 0 {
+		action = truncate(action, f.truncateAt)
+	}
+
+	batch := []interfaces.Action{action}
+	if f.duplicate {
+		batch = append(batch, action)
+	}
+
+	c.mu.Lock()
+	if f.reorder && c.heldSend == nil {
+		c.heldSend = action
+		batch = batch[1:]
+	} else if c.heldSend != nil {
+		batch = append(batch, c.heldSend)
+		c.heldSend = nil
+	}
+	c.mu.Unlock()
+
+	for _, act := range batch {
+		if err := c.inner.SendAction(act); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//RecvActions receives actions from the wrapped protocol, injecting faults into
+//each of them. It keeps receiving while every action of a batch is lost.
+func (c *ChaosProtocol) RecvActions() ([]interfaces.Action, error) {
+	for {
+		actions, err := c.inner.RecvActions()
+		if err != nil {
+			return nil, err
+		}
+
+		delivered, delay, disconnect := c.recvFaults(actions)
+		if disconnect {
+			c.inner.Close()
+			return nil, ErrChaosDisconnect
+		}
+		if delay > 0 {
+			c.sleep(delay)
+		}
+		if len(delivered) > 0 {
+			return delivered, nil
+		}
+	}
+}
+
+// recvFaults applies the faults to a batch of received actions
+func (c *ChaosProtocol) recvFaults(actions []interfaces.Action) ([]interfaces.Action, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delivered := []interfaces.Action{}
+	var delay time.Duration
+	for _, action := range actions {
+		f := c.rollFaults(c.recvRng, messageSize(action))
+		if f.disconnect {
+			return nil, 0, true
+		}
+		if f.drop {
+			continue
+		}
+		if f.delay > delay {
+			delay = f.delay
+		}
+		if f.truncateAt > 0 {
+			truncated, ok := reparse(truncate(action, f.truncateAt))
+			if !ok {
+				c.stats.Lost++
+				continue
+			}
+			action = truncated
+		}
+
+		if f.reorder {
+			c.heldRecv = append(c.heldRecv, action)
+			if f.duplicate {
+				c.heldRecv = append(c.heldRecv, action)
+			}
+			continue
+		}
+		delivered = append(delivered, action)
+		if f.duplicate {
+			delivered = append(delivered, action)
+		}
+		// the reordered messages are delivered right after the next one
+		delivered = append(delivered, c.heldRecv...)
+		c.heldRecv = nil
+	}
+	return delivered, delay, false
+}
+
+// reparse parses a truncated inbound action like the client would
+func reparse(action interfaces.Action) (interfaces.Action, bool) {
+	msgs, err := message.ParseMessages(action.ToAction())
+	if err != nil || len(msgs) != 1 {
+		return nil, false
+	}
+	parsed, err := message.CathegorizeAction(msgs[0])
+	if err != nil {
+		return nil, false
+	}
+	return parsed, true
+}
@@ -0,0 +1,252 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing_test
+
+import (
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+	dstesting "github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chaos Protocol", func() {
+	Describe("[Unit]", func() {
+		var mock *dstesting.MockProtocol
+
+		BeforeEach(func() {
+			mock = dstesting.NewMockProtocol()
+			Expect(mock.Connect()).To(Succeed())
+		})
+
+		subscribe := func(name string) interfaces.Action {
+			action, err := message.NewSubscribeAction(&message.Message{
+				Topic:   interfaces.TopicEvent,
+				Action:  interfaces.ActionSubscribe,
+				RawData: []string{name},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return action
+		}
+
+		recv := func(chaos *dstesting.ChaosProtocol) []string {
+			actions, err := chaos.RecvActions()
+			Expect(err).NotTo(HaveOccurred())
+			msgs := []string{}
+			for _, action := range actions {
+				msgs = append(msgs, message.Readable(action))
+			}
+			return msgs
+		}
+
+		It("Should pass messages through without faults", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{})
+
+			Expect(chaos.SendAction(subscribe("test1"))).To(Succeed())
+			Expect(mock.SentMessages()).To(Equal([]string{msg("E", "S", "test1")}))
+
+			Expect(mock.DeliverRaw(msg("E", "A", "S", "test1"))).To(Succeed())
+			Expect(recv(chaos)).To(Equal([]string{"E|A|S|test1+"}))
+			Expect(chaos.Stats()).To(Equal(dstesting.ChaosStats{}))
+		})
+
+		It("Should drop messages", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Drop: 1})
+
+			Expect(chaos.SendAction(subscribe("test1"))).To(Succeed())
+			Expect(mock.SentMessages()).To(BeEmpty())
+			Expect(chaos.Stats().Dropped).To(Equal(1))
+		})
+
+		It("Should keep receiving while messages are dropped", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Drop: 1})
+			Expect(mock.DeliverRaw(msg("E", "A", "S", "test1"))).To(Succeed())
+
+			received := make(chan []interfaces.Action, 1)
+			go func() {
+				actions, _ := chaos.RecvActions()
+				received <- actions
+			}()
+
+			Eventually(func() int { return chaos.Stats().Dropped }).Should(Equal(1))
+			Consistently(received, 50*time.Millisecond).ShouldNot(Receive())
+
+			chaos.SetOptions(dstesting.ChaosOptions{})
+			Expect(mock.DeliverRaw(msg("E", "A", "S", "test2"))).To(Succeed())
+			Eventually(received).Should(Receive(HaveLen(1)))
+		})
+
+		It("Should duplicate messages", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Duplicate: 1})
+
+			Expect(chaos.SendAction(subscribe("test1"))).To(Succeed())
+			Expect(mock.SentMessages()).To(Equal([]string{msg("E", "S", "test1"), msg("E", "S", "test1")}))
+
+			Expect(mock.DeliverRaw(msg("E", "A", "S", "test1"))).To(Succeed())
+			Expect(recv(chaos)).To(Equal([]string{"E|A|S|test1+", "E|A|S|test1+"}))
+		})
+
+		It("Should reorder messages", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Reorder: 1})
+
+			Expect(chaos.SendAction(subscribe("test1"))).To(Succeed())
+			Expect(mock.SentMessages()).To(BeEmpty())
+			Expect(chaos.SendAction(subscribe("test2"))).To(Succeed())
+			Expect(mock.SentMessages()).To(Equal([]string{msg("E", "S", "test2"), msg("E", "S", "test1")}))
+
+			chaos.SetOptions(dstesting.ChaosOptions{})
+			Expect(mock.DeliverRaw(msg("E", "A", "S", "test1"), msg("E", "A", "S", "test2"))).To(Succeed())
+			Expect(recv(chaos)).To(Equal([]string{"E|A|S|test1+", "E|A|S|test2+"}))
+		})
+
+		It("Should deliver reordered messages after the next one", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Reorder: 1})
+			Expect(mock.DeliverRaw(msg("E", "A", "S", "test1"))).To(Succeed())
+			Expect(mock.DeliverRaw(msg("E", "A", "S", "test2"))).To(Succeed())
+
+			received := make(chan []string, 1)
+			go func() {
+				defer GinkgoRecover()
+				received <- recv(chaos)
+			}()
+			Eventually(func() int { return chaos.Stats().Reordered }).Should(Equal(2))
+
+			chaos.SetOptions(dstesting.ChaosOptions{})
+			Expect(mock.DeliverRaw(msg("E", "A", "S", "test3"))).To(Succeed())
+			Eventually(received).Should(Receive(Equal([]string{"E|A|S|test3+", "E|A|S|test1+", "E|A|S|test2+"})))
+		})
+
+		It("Should truncate messages", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Truncate: 1})
+
+			Expect(chaos.SendAction(subscribe("test1"))).To(Succeed())
+			sent := mock.SentMessages()
+			Expect(sent).To(HaveLen(1))
+			Expect(len(sent[0])).To(BeNumerically("<", len(msg("E", "S", "test1"))))
+			Expect(msg("E", "S", "test1")).To(HavePrefix(sent[0]))
+			Expect(chaos.Stats().Truncated).To(Equal(1))
+		})
+
+		It("Should delay messages", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Delay: 1, MaxDelay: 20 * time.Millisecond})
+
+			Expect(chaos.SendAction(subscribe("test1"))).To(Succeed())
+			Expect(mock.SentMessages()).To(HaveLen(1))
+			Expect(chaos.Stats().Delayed).To(Equal(1))
+		})
+
+		It("Should delay messages on the clock", func() {
+			clock := dstesting.NewFakeClock()
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Delay: 1, MaxDelay: 20 * time.Millisecond, Clock: clock})
+
+			sent := make(chan error, 1)
+			go func() {
+				sent <- chaos.SendAction(subscribe("test1"))
+			}()
+			Expect(clock.WaitForTimers(1, time.Second)).To(Succeed())
+			Expect(mock.SentMessages()).To(BeEmpty())
+
+			clock.Advance(20 * time.Millisecond)
+			Eventually(sent).Should(Receive(BeNil()))
+			Expect(mock.SentMessages()).To(HaveLen(1))
+		})
+
+		It("Should count the truncated inbound messages that can't be parsed as lost", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Truncate: 1})
+			// every prefix of C|PI is malformed
+			Expect(mock.DeliverRaw(msg("C", "PI"))).To(Succeed())
+
+			received := make(chan []interfaces.Action, 1)
+			go func() {
+				actions, _ := chaos.RecvActions()
+				received <- actions
+			}()
+
+			Eventually(func() int { return chaos.Stats().Lost }).Should(Equal(1))
+			Expect(chaos.Stats().Truncated).To(Equal(1))
+			Consistently(received, 50*time.Millisecond).ShouldNot(Receive())
+
+			chaos.SetOptions(dstesting.ChaosOptions{})
+			Expect(mock.DeliverRaw(msg("E", "A", "S", "test1"))).To(Succeed())
+			Eventually(received).Should(Receive(HaveLen(1)))
+		})
+
+		It("Should disconnect", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Disconnect: 1})
+
+			err := chaos.SendAction(subscribe("test1"))
+			Expect(err).To(Equal(dstesting.ErrChaosDisconnect))
+			Expect(mock.IsClosed).To(BeTrue())
+			Expect(mock.SentMessages()).To(BeEmpty())
+		})
+
+		It("Should disconnect on demand", func() {
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{})
+
+			Expect(chaos.Disconnect()).To(Succeed())
+			_, err := chaos.RecvActions()
+			Expect(err).To(Equal(dstesting.ErrMockClosed))
+			Expect(chaos.Stats().Disconnects).To(Equal(1))
+		})
+
+		It("Should inject the same faults with the same seed", func() {
+			run := func(seed int64) []string {
+				mock := dstesting.NewMockProtocol()
+				chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{
+					Seed:      seed,
+					Drop:      0.3,
+					Duplicate: 0.3,
+					Reorder:   0.3,
+					Truncate:  0.3,
+				})
+				for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+					Expect(chaos.SendAction(subscribe(name))).To(Succeed())
+				}
+				return mock.SentMessages()
+			}
+
+			Expect(run(42)).To(Equal(run(42)))
+			Expect(run(42)).NotTo(Equal(run(7)))
+		})
+	})
+
+	Describe("[Integration]", func() {
+		It("Should let the client reconnect after a forced disconnection", func() {
+			mock := dstesting.NewMockProtocol()
+			chaos := dstesting.NewChaosProtocol(mock, dstesting.ChaosOptions{Seed: 1})
+
+			cli, err := client.New("localhost:6020", chaos, func(opts *client.ClientOptions) error {
+				opts.ManualLogin = true
+				opts.RecIntvlMin = 10 * time.Millisecond
+				opts.Logger = client.NopLogger{}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer cli.Close()
+
+			handshake := func() {
+				Expect(mock.DeliverRaw(msg("C", "CH"))).To(Succeed())
+				Expect(mock.DeliverRaw(msg("C", "A"))).To(Succeed())
+				Expect(mock.DeliverRaw(msg("A", "A"))).To(Succeed())
+			}
+			handshake()
+			Eventually(func() int { return mock.Calls(dstesting.MethodSendAction) }).Should(Equal(1))
+			Expect(cli.Login(map[string]interface{}{})).To(Succeed())
+
+			Expect(chaos.Disconnect()).To(Succeed())
+			Eventually(func() int { return mock.Calls(dstesting.MethodConnect) }).Should(Equal(2))
+
+			handshake()
+			_, err = mock.WaitForSent(`^C\|CHR\|localhost:6020$`, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
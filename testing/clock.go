@@ -0,0 +1,150 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+)
+
+//FakeClock is a clock whose time only moves when Advance is called, so tests
+//control timeouts and reconnection intervals, see client.WithClock
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	changed chan struct{}
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	c     chan time.Time
+}
+
+//NewFakeClock returns a clock stopped at a fixed date
+func NewFakeClock() *FakeClock {
+	return &FakeClock{
+		now:     time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC),
+		changed: make(chan struct{}),
+	}
+}
+
+// notify wakes up the goroutines waiting for a change, c.mu must be held
+func (c *FakeClock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+//Now returns the time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+//After returns a channel receiving the time once the clock is advanced by d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+//Sleep blocks until the clock is advanced by d
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+//NewTimer creates a timer firing once the clock is advanced by d, right away if
+//d isn't positive
+func (c *FakeClock) NewTimer(d time.Duration) interfaces.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, when: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	c.notify()
+	return t
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.notify()
+			return true
+		}
+	}
+	return false
+}
+
+//Advance moves the clock forward by d, firing the timers due in order
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].when.Before(c.timers[j].when)
+	})
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.when.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.timers = pending
+	c.notify()
+}
+
+//Timers returns how many timers are waiting for the clock to advance, sleeps included
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+//WaitForTimers waits until n timers are waiting for the clock to advance, so a
+//test knows the code under test is blocked on the clock before advancing it,
+//failing after timeout
+func (c *FakeClock) WaitForTimers(n int, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	for {
+		c.mu.Lock()
+		if len(c.timers) >= n {
+			c.mu.Unlock()
+			return nil
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return fmt.Errorf("expected %d timers, got %d after %s", n, c.Timers(), timeout)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing_test
+
+import (
+	"time"
+
+	dstesting "github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fake Clock", func() {
+	Describe("[Unit]", func() {
+		var clock *dstesting.FakeClock
+
+		BeforeEach(func() {
+			clock = dstesting.NewFakeClock()
+		})
+
+		It("Should only move when advanced", func() {
+			start := clock.Now()
+			Expect(clock.Now()).To(Equal(start))
+
+			clock.Advance(time.Minute)
+			Expect(clock.Now()).To(Equal(start.Add(time.Minute)))
+		})
+
+		It("Should fire timers once their duration elapsed", func() {
+			after := clock.After(2 * time.Second)
+			Expect(clock.Timers()).To(Equal(1))
+
+			clock.Advance(time.Second)
+			Expect(after).NotTo(Receive())
+
+			clock.Advance(time.Second)
+			Expect(after).To(Receive(Equal(clock.Now())))
+			Expect(clock.Timers()).To(Equal(0))
+		})
+
+		It("Should fire timers that aren't positive right away", func() {
+			Expect(clock.After(0)).To(Receive())
+			Expect(clock.Timers()).To(Equal(0))
+		})
+
+		It("Should stop timers", func() {
+			timer := clock.NewTimer(time.Second)
+			Expect(timer.Stop()).To(BeTrue())
+			Expect(timer.Stop()).To(BeFalse())
+
+			clock.Advance(time.Second)
+			Expect(timer.C()).NotTo(Receive())
+		})
+
+		It("Should wake up sleepers", func() {
+			woke := make(chan struct{})
+			go func() {
+				clock.Sleep(time.Hour)
+				close(woke)
+			}()
+
+			Expect(clock.WaitForTimers(1, time.Second)).To(Succeed())
+			Consistently(woke, 20*time.Millisecond).ShouldNot(BeClosed())
+
+			clock.Advance(time.Hour)
+			Eventually(woke).Should(BeClosed())
+		})
+
+		It("Should fail waiting for timers that aren't created", func() {
+			Expect(clock.WaitForTimers(1, 10*time.Millisecond)).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,308 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/gorilla/websocket"
+)
+
+//FakeServer is a scriptable deepstream.io server listening on a random local port.
+//It records the messages it receives and lets tests push raw messages to the
+//connected clients. Messages are raw protocol strings without the trailing
+//message separator, e.g. "C" + MessagePartSeparator + "CH".
+type FakeServer struct {
+	// URL is the websocket URL clients should dial, e.g. ws://127.0.0.1:1234/deepstream
+	URL string
+	// Addr is the host:port the server listens on
+	Addr string
+
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu               sync.Mutex
+	conns            []*fakeConn
+	totalConnections int
+	received         []string
+	replies          map[string][]string
+	greeting         []string
+	offline          bool
+	changed          chan struct{}
+}
+
+type fakeConn struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+func (c *fakeConn) write(raw string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ws.WriteMessage(websocket.TextMessage, []byte(raw))
+}
+
+//NewFakeServer starts a fake server, Close must be called to stop it
+func NewFakeServer() *FakeServer {
+	s := &FakeServer{
+		replies: map[string][]string{},
+		changed: make(chan struct{}),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.Addr = s.server.Listener.Addr().String()
+	s.URL = fmt.Sprintf("ws://%s/deepstream", s.Addr)
+	return s
+}
+
+//Close drops every connection and stops the server
+func (s *FakeServer) Close() {
+	s.Drop()
+	s.server.Close()
+}
+
+func (s *FakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	offline := s.offline
+	s.mu.Unlock()
+	if offline {
+		http.Error(w, "server is offline", http.StatusServiceUnavailable)
+		return
+	}
+
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	conn := &fakeConn{ws: ws}
+
+	s.mu.Lock()
+	s.conns = append(s.conns, conn)
+	s.totalConnections++
+	greeting := s.greeting
+	s.notify()
+	s.mu.Unlock()
+
+	defer s.remove(conn)
+	for _, msg := range greeting {
+		if err := conn.write(msg + interfaces.MessageSeparator); err != nil {
+			return
+		}
+	}
+	for {
+		_, body, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		for _, msg := range strings.Split(string(body), interfaces.MessageSeparator) {
+			if msg == "" {
+				continue
+			}
+			s.receive(conn, msg)
+		}
+	}
+}
+
+func (s *FakeServer) receive(conn *fakeConn, msg string) {
+	s.mu.Lock()
+	s.received = append(s.received, msg)
+	replies := s.replies[msg]
+	s.notify()
+	s.mu.Unlock()
+
+	for _, reply := range replies {
+		conn.write(reply + interfaces.MessageSeparator)
+	}
+}
+
+func (s *FakeServer) remove(conn *fakeConn) {
+	conn.ws.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, c := range s.conns {
+		if c == conn {
+			s.conns = append(s.conns[:i], s.conns[i+1:]...)
+			break
+		}
+	}
+	s.notify()
+}
+
+// notify wakes up the goroutines waiting for a change, s.mu must be held
+func (s *FakeServer) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+//Send pushes msg to every connected client
+func (s *FakeServer) Send(msg string) error {
+	s.mu.Lock()
+	conns := append([]*fakeConn{}, s.conns...)
+	s.mu.Unlock()
+
+	if len(conns) == 0 {
+		return fmt.Errorf("no client is connected to %s", s.URL)
+	}
+	for _, conn := range conns {
+		if err := conn.write(msg + interfaces.MessageSeparator); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//OnConnect scripts the server to send msgs to every new connection, e.g. the
+//C|CH challenge
+func (s *FakeServer) OnConnect(msgs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.greeting = msgs
+}
+
+//Reply scripts the server to answer with replies every time it receives msg
+func (s *FakeServer) Reply(msg string, replies ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replies[msg] = replies
+}
+
+//Received returns the messages received since the last ResetMessageCount
+func (s *FakeServer) Received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.received...)
+}
+
+//LastMessage returns the last message received, or an empty string
+func (s *FakeServer) LastMessage() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.received) == 0 {
+		return ""
+	}
+	return s.received[len(s.received)-1]
+}
+
+//HasReceived returns true if msg was received since the last ResetMessageCount
+func (s *FakeServer) HasReceived(msg string) bool {
+	for _, received := range s.Received() {
+		if received == msg {
+			return true
+		}
+	}
+	return false
+}
+
+//MessageCount returns how many messages were received since the last ResetMessageCount
+func (s *FakeServer) MessageCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.received)
+}
+
+//ResetMessageCount forgets the received messages
+func (s *FakeServer) ResetMessageCount() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.received = nil
+}
+
+//ActiveConnections returns how many clients are connected
+func (s *FakeServer) ActiveConnections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.conns)
+}
+
+//TotalConnections returns how many connections were accepted since the server started
+func (s *FakeServer) TotalConnections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.totalConnections
+}
+
+//Drop closes every connection and refuses new ones until Restore is called
+func (s *FakeServer) Drop() {
+	s.mu.Lock()
+	s.offline = true
+	conns := append([]*fakeConn{}, s.conns...)
+	s.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.ws.Close()
+	}
+}
+
+//Restore accepts connections again after Drop
+func (s *FakeServer) Restore() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offline = false
+}
+
+//WaitForMessage waits until msg is received, failing after timeout
+func (s *FakeServer) WaitForMessage(msg string, timeout time.Duration) error {
+	return s.waitFor(timeout, func() bool {
+		for _, received := range s.received {
+			if received == msg {
+				return true
+			}
+		}
+		return false
+	}, func() string {
+		return fmt.Sprintf("message %q was not received, got %q", msg, s.received)
+	})
+}
+
+//WaitForConnections waits until n clients are connected, failing after timeout
+func (s *FakeServer) WaitForConnections(n int, timeout time.Duration) error {
+	return s.waitFor(timeout, func() bool {
+		return len(s.conns) == n
+	}, func() string {
+		return fmt.Sprintf("expected %d active connections, got %d", n, len(s.conns))
+	})
+}
+
+// waitFor waits until done returns true, both done and describe are called with s.mu held
+func (s *FakeServer) waitFor(timeout time.Duration, done func() bool, describe func() string) error {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		if done() {
+			s.mu.Unlock()
+			return nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return fmt.Errorf("%s after %s", describe(), timeout)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	dstesting "github.com/ga-con/deepstream.io-client-go/testing"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// msg builds a raw message from readable parts, e.g. msg("C", "CH")
+func msg(parts ...string) string {
+	return strings.Join(parts, interfaces.MessagePartSeparator)
+}
+
+var _ = Describe("Fake Server", func() {
+	Describe("[Unit]", func() {
+		var server *dstesting.FakeServer
+
+		BeforeEach(func() {
+			server = dstesting.NewFakeServer()
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		dial := func() *websocket.Conn {
+			conn, _, err := websocket.DefaultDialer.Dial(server.URL, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(server.WaitForConnections(1, time.Second)).To(Succeed())
+			return conn
+		}
+
+		read := func(conn *websocket.Conn) string {
+			_, body, err := conn.ReadMessage()
+			Expect(err).NotTo(HaveOccurred())
+			return string(body)
+		}
+
+		It("Should record received messages", func() {
+			conn := dial()
+			defer conn.Close()
+
+			raw := msg("E", "S", "test1") + interfaces.MessageSeparator + msg("E", "S", "test2") + interfaces.MessageSeparator
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte(raw))).To(Succeed())
+
+			Expect(server.WaitForMessage(msg("E", "S", "test2"), time.Second)).To(Succeed())
+			Expect(server.Received()).To(Equal([]string{msg("E", "S", "test1"), msg("E", "S", "test2")}))
+			Expect(server.LastMessage()).To(Equal(msg("E", "S", "test2")))
+			Expect(server.HasReceived(msg("E", "S", "test1"))).To(BeTrue())
+
+			server.ResetMessageCount()
+			Expect(server.MessageCount()).To(Equal(0))
+		})
+
+		It("Should push and reply to messages", func() {
+			server.OnConnect(msg("C", "CH"))
+			server.Reply(msg("C", "CHR", "url"), msg("C", "A"))
+			conn := dial()
+			defer conn.Close()
+
+			Expect(read(conn)).To(Equal(msg("C", "CH") + interfaces.MessageSeparator))
+			Expect(conn.WriteMessage(websocket.TextMessage, []byte(msg("C", "CHR", "url")))).To(Succeed())
+			Expect(read(conn)).To(Equal(msg("C", "A") + interfaces.MessageSeparator))
+
+			Expect(server.Send(msg("C", "PI"))).To(Succeed())
+			Expect(read(conn)).To(Equal(msg("C", "PI") + interfaces.MessageSeparator))
+		})
+
+		It("Should drop and restore connectivity", func() {
+			conn := dial()
+			defer conn.Close()
+
+			server.Drop()
+			Expect(server.WaitForConnections(0, time.Second)).To(Succeed())
+			_, _, err := websocket.DefaultDialer.Dial(server.URL, nil)
+			Expect(err).To(HaveOccurred())
+
+			server.Restore()
+			other := dial()
+			defer other.Close()
+			Expect(server.TotalConnections()).To(Equal(2))
+		})
+
+		It("Should run independent instances", func() {
+			second := dstesting.NewFakeServer()
+			defer second.Close()
+
+			Expect(second.URL).NotTo(Equal(server.URL))
+			conn := dial()
+			defer conn.Close()
+			Expect(second.ActiveConnections()).To(Equal(0))
+		})
+
+		It("Should fail to send without clients", func() {
+			Expect(server.Send(msg("C", "PI"))).NotTo(Succeed())
+		})
+	})
+
+	Describe("[Integration]", func() {
+		It("Should log a client in", func() {
+			server := dstesting.NewFakeServer()
+			defer server.Close()
+
+			server.OnConnect(msg("C", "CH"))
+			server.Reply(msg("C", "CHR", server.URL), msg("C", "A"))
+			server.Reply(msg("A", "REQ", `{"password":"secret","username":"user"}`), msg("A", "A"))
+
+			cli, err := client.Dial(server.URL, func(opts *client.ClientOptions) error {
+				opts.HandshakeTimeout = 200 * time.Millisecond
+				opts.AuthUser = client.AuthUser{Username: "user", Password: "secret"}
+				opts.Logger = client.NopLogger{}
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer cli.Close()
+
+			Expect(server.WaitForMessage(msg("A", "REQ", `{"password":"secret","username":"user"}`), time.Second)).To(Succeed())
+			Expect(server.ActiveConnections()).To(Equal(1))
+
+			// the client answers pings once logged in
+			Eventually(func() error { return server.Send(msg("C", "PI")) }).Should(Succeed())
+			Expect(server.WaitForMessage(msg("C", "PO"), time.Second)).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,373 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+//Package matchers provides gomega matchers for deepstream.io messages written in
+//the readable notation of the client specs: parts are separated by | and messages
+//by +, e.g. "E|S|test1" or "C|CH+C|A+". A part made of * matches any part and
+//JSON parts are compared by value.
+//
+//The matchers work against a testing.MockProtocol (the messages it sent), a
+//testing.FakeServer (the messages it received), a raw message string, an action
+//or a slice of raw messages or actions.
+package matchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// messagesOf returns the raw messages, without separators, of a matcher's actual value
+func messagesOf(actual interface{}) ([]string, error) {
+	switch source := actual.(type) {
+	case interface{ SentMessages() []string }:
+		return source.SentMessages(), nil
+	case interface{ Received() []string }:
+		return source.Received(), nil
+	case string:
+		return splitRaw(source), nil
+	case []string:
+		return source, nil
+	case interfaces.Action:
+		return splitRaw(source.ToAction()), nil
+	case []interfaces.Action:
+		msgs := []string{}
+		for _, action := range source {
+			msgs = append(msgs, splitRaw(action.ToAction())...)
+		}
+		return msgs, nil
+	}
+	return nil, fmt.Errorf("expected a mock protocol, a fake server, messages or actions, got:\n%s", format.Object(actual, 1))
+}
+
+func splitRaw(raw string) []string {
+	msgs := []string{}
+	for _, msg := range strings.Split(raw, interfaces.MessageSeparator) {
+		if msg != "" {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// parseReadable splits readable notation into the parts of each message
+func parseReadable(readable string) [][]string {
+	msgs := [][]string{}
+	for _, msg := range splitRaw(message.FromReadable(readable)) {
+		msgs = append(msgs, strings.Split(msg, interfaces.MessagePartSeparator))
+	}
+	return msgs
+}
+
+// readable returns raw messages in readable notation, one per line
+func readable(msgs []string) string {
+	out := make([]string, len(msgs))
+	for i, msg := range msgs {
+		out[i] = message.ToReadable(msg + interfaces.MessageSeparator)
+	}
+	return strings.Join(out, "\n")
+}
+
+// matchParts reports whether a raw message matches the expected parts
+func matchParts(expected []string, raw string) bool {
+	parts := strings.Split(raw, interfaces.MessagePartSeparator)
+	if len(parts) != len(expected) {
+		return false
+	}
+	for i, part := range expected {
+		if part == "*" || part == parts[i] {
+			continue
+		}
+		// only objects and arrays are compared by value, versions 1 and 1.0 differ
+		isJSON := strings.HasPrefix(part, "{") || strings.HasPrefix(part, "[")
+		if !isJSON || !JSONEqual(part, parts[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+//JSONEqual reports whether expected and actual are JSON documents of the same
+//value, e.g. objects with their keys in a different order. Strings that aren't
+//JSON are compared as is.
+func JSONEqual(expected, actual string) bool {
+	var e, a interface{}
+	if json.Unmarshal([]byte(expected), &e) != nil || json.Unmarshal([]byte(actual), &a) != nil {
+		return expected == actual
+	}
+	return reflect.DeepEqual(e, a)
+}
+
+// normalize returns value as decoded from JSON, so 3 and 3.0 compare equal
+func normalize(value interface{}) interface{} {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+//HaveSentMessage succeeds if the messages, in readable notation, were sent or
+//received in order, possibly with other messages in between
+func HaveSentMessage(expected string) types.GomegaMatcher {
+	return &sentMessageMatcher{expected: expected}
+}
+
+type sentMessageMatcher struct {
+	expected string
+	msgs     []string
+}
+
+func (m *sentMessageMatcher) Match(actual interface{}) (bool, error) {
+	msgs, err := messagesOf(actual)
+	if err != nil {
+		return false, err
+	}
+	m.msgs = msgs
+
+	expected := parseReadable(m.expected)
+	next := 0
+	for _, msg := range msgs {
+		if next < len(expected) && matchParts(expected[next], msg) {
+			next++
+		}
+	}
+	return next == len(expected), nil
+}
+
+func (m *sentMessageMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected messages\n%s\nto contain\n%s", readable(m.msgs), m.expected)
+}
+
+func (m *sentMessageMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected messages\n%s\nnot to contain\n%s", readable(m.msgs), m.expected)
+}
+
+//HaveLastSentMessage succeeds if the last messages sent or received are the
+//messages in readable notation
+func HaveLastSentMessage(expected string) types.GomegaMatcher {
+	return &lastSentMessageMatcher{expected: expected}
+}
+
+type lastSentMessageMatcher struct {
+	expected string
+	msgs     []string
+}
+
+func (m *lastSentMessageMatcher) Match(actual interface{}) (bool, error) {
+	msgs, err := messagesOf(actual)
+	if err != nil {
+		return false, err
+	}
+	m.msgs = msgs
+
+	expected := parseReadable(m.expected)
+	if len(msgs) < len(expected) {
+		return false, nil
+	}
+	last := msgs[len(msgs)-len(expected):]
+	for i, parts := range expected {
+		if !matchParts(parts, last[i]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (m *lastSentMessageMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected messages\n%s\nto end with\n%s", readable(m.msgs), m.expected)
+}
+
+func (m *lastSentMessageMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected messages\n%s\nnot to end with\n%s", readable(m.msgs), m.expected)
+}
+
+//BeMessage succeeds if the messages are exactly the messages in readable notation
+func BeMessage(expected string) types.GomegaMatcher {
+	return &beMessageMatcher{expected: expected}
+}
+
+type beMessageMatcher struct {
+	expected string
+	msgs     []string
+}
+
+func (m *beMessageMatcher) Match(actual interface{}) (bool, error) {
+	msgs, err := messagesOf(actual)
+	if err != nil {
+		return false, err
+	}
+	m.msgs = msgs
+
+	expected := parseReadable(m.expected)
+	if len(msgs) != len(expected) {
+		return false, nil
+	}
+	for i, parts := range expected {
+		if !matchParts(parts, msgs[i]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (m *beMessageMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected messages\n%s\nto be\n%s", readable(m.msgs), m.expected)
+}
+
+func (m *beMessageMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected messages\n%s\nnot to be\n%s", readable(m.msgs), m.expected)
+}
+
+//HaveSentMessages succeeds if count messages were sent or received
+func HaveSentMessages(count int) types.GomegaMatcher {
+	return &sentMessagesMatcher{count: count}
+}
+
+type sentMessagesMatcher struct {
+	count int
+	msgs  []string
+}
+
+func (m *sentMessagesMatcher) Match(actual interface{}) (bool, error) {
+	msgs, err := messagesOf(actual)
+	if err != nil {
+		return false, err
+	}
+	m.msgs = msgs
+	return len(msgs) == m.count, nil
+}
+
+func (m *sentMessagesMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected %d messages, got %d:\n%s", m.count, len(m.msgs), readable(m.msgs))
+}
+
+func (m *sentMessagesMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected not %d messages, got:\n%s", m.count, readable(m.msgs))
+}
+
+//HaveTopicAndAction succeeds if a message with topic and action was sent or received,
+//e.g. HaveTopicAndAction(interfaces.TopicEvent, interfaces.ActionSubscribe)
+func HaveTopicAndAction(topic, action string) types.GomegaMatcher {
+	return &topicAndActionMatcher{topic: topic, action: action}
+}
+
+type topicAndActionMatcher struct {
+	topic  string
+	action string
+	msgs   []string
+}
+
+func (m *topicAndActionMatcher) Match(actual interface{}) (bool, error) {
+	msgs, err := messagesOf(actual)
+	if err != nil {
+		return false, err
+	}
+	m.msgs = msgs
+
+	prefix := m.topic + interfaces.MessagePartSeparator + m.action
+	for _, msg := range msgs {
+		if msg == prefix || strings.HasPrefix(msg, prefix+interfaces.MessagePartSeparator) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *topicAndActionMatcher) FailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected messages\n%s\nto contain a %s|%s message", readable(m.msgs), m.topic, m.action)
+}
+
+func (m *topicAndActionMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("Expected messages\n%s\nnot to contain a %s|%s message", readable(m.msgs), m.topic, m.action)
+}
+
+//HaveRecordData succeeds if the value at path of a record is value. It works against
+//a record, anything with a GetPath method, and against messages, where a record
+//update or patch must have set path to value. An empty path is the whole data.
+func HaveRecordData(path string, value interface{}) types.GomegaMatcher {
+	return &recordDataMatcher{path: path, value: value}
+}
+
+type recordDataMatcher struct {
+	path  string
+	value interface{}
+	found []interface{}
+}
+
+func (m *recordDataMatcher) Match(actual interface{}) (bool, error) {
+	expected := normalize(m.value)
+	m.found = nil
+
+	if record, ok := actual.(interface{ GetPath(string) interface{} }); ok {
+		got := normalize(record.GetPath(m.path))
+		m.found = append(m.found, got)
+		return reflect.DeepEqual(got, expected), nil
+	}
+
+	msgs, err := messagesOf(actual)
+	if err != nil {
+		return false, err
+	}
+	for _, raw := range msgs {
+		got, ok := recordValue(raw, m.path)
+		if !ok {
+			continue
+		}
+		m.found = append(m.found, got)
+		if reflect.DeepEqual(got, expected) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *recordDataMatcher) FailureMessage(actual interface{}) string {
+	return format.Message(m.found, fmt.Sprintf("to contain record data at %q equal to", m.path), m.value)
+}
+
+func (m *recordDataMatcher) NegatedFailureMessage(actual interface{}) string {
+	return format.Message(m.found, fmt.Sprintf("not to contain record data at %q equal to", m.path), m.value)
+}
+
+// recordValue returns the value a record update or patch sets at path
+func recordValue(raw, path string) (interface{}, bool) {
+	parts := strings.Split(raw, interfaces.MessagePartSeparator)
+	if len(parts) < 2 || parts[0] != interfaces.TopicRecord {
+		return nil, false
+	}
+
+	switch {
+	case parts[1] == interfaces.ActionUpdate && len(parts) >= 5:
+		var data interface{}
+		if err := json.Unmarshal([]byte(parts[4]), &data); err != nil {
+			return nil, false
+		}
+		return message.LookupPath(data, path)
+	case parts[1] == interfaces.ActionPatch && len(parts) >= 6:
+		rest := strings.TrimPrefix(path, parts[4])
+		if rest == path || (rest != "" && rest[0] != '.' && rest[0] != '[') {
+			return nil, false
+		}
+		value, err := message.ParseTyped(parts[5])
+		if err != nil {
+			return nil, false
+		}
+		return message.LookupPath(normalize(value), rest)
+	}
+	return nil, false
+}
@@ -0,0 +1,175 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package matchers_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+	dstesting "github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/ga-con/deepstream.io-client-go/testing/matchers"
+	"github.com/gorilla/websocket"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func raw(parts ...string) string {
+	return strings.Join(parts, interfaces.MessagePartSeparator)
+}
+
+type fakeRecord map[string]interface{}
+
+func (r fakeRecord) GetPath(path string) interface{} {
+	return r[path]
+}
+
+var _ = Describe("Matchers Package", func() {
+	Describe("[Unit]", func() {
+		var sent []string
+
+		BeforeEach(func() {
+			sent = []string{
+				raw("C", "CHR", "localhost:6020"),
+				raw("A", "REQ", `{"username":"x","password":"y"}`),
+				raw("E", "S", "test1"),
+				raw("R", "U", "rec", "2", `{"name":"John","pets":[{"name":"Rex"}]}`),
+				raw("R", "P", "rec", "3", "age", "N3"),
+			}
+		})
+
+		Describe("HaveSentMessage", func() {
+			It("Should match a message in readable notation", func() {
+				Expect(sent).To(HaveSentMessage("E|S|test1"))
+				Expect(sent).To(HaveSentMessage("E|S|test1+"))
+				Expect(sent).NotTo(HaveSentMessage("E|S|test2"))
+			})
+
+			It("Should match messages in order", func() {
+				Expect(sent).To(HaveSentMessage("C|CHR|localhost:6020+E|S|test1+"))
+				Expect(sent).NotTo(HaveSentMessage("E|S|test1+C|CHR|localhost:6020+"))
+			})
+
+			It("Should compare JSON parts by value", func() {
+				Expect(sent).To(HaveSentMessage(`A|REQ|{"password":"y","username":"x"}`))
+			})
+
+			It("Should match any part with *", func() {
+				Expect(sent).To(HaveSentMessage("R|P|rec|*|age|N3"))
+				Expect(sent).NotTo(HaveSentMessage("R|P|rec|*|age"))
+			})
+
+			It("Should match raw messages and actions", func() {
+				Expect(raw("E", "S", "test1") + interfaces.MessageSeparator).To(HaveSentMessage("E|S|test1"))
+				action := message.NewChallengeResponseAction("localhost:6020")
+				Expect(action).To(HaveSentMessage("C|CHR|localhost:6020"))
+			})
+
+			It("Should fail for unsupported values", func() {
+				_, err := HaveSentMessage("E|S|test1").Match(42)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("Should describe the messages in readable notation", func() {
+				matcher := HaveSentMessage("E|S|test2")
+				Expect(matcher.Match(sent)).To(BeFalse())
+				Expect(matcher.FailureMessage(sent)).To(ContainSubstring("E|S|test1+\n"))
+				Expect(matcher.FailureMessage(sent)).To(ContainSubstring("E|S|test2"))
+			})
+		})
+
+		Describe("HaveLastSentMessage", func() {
+			It("Should match the last messages", func() {
+				Expect(sent).To(HaveLastSentMessage("R|P|rec|3|age|N3"))
+				Expect(sent).To(HaveLastSentMessage("R|U|rec|2|*+R|P|rec|3|age|N3"))
+				Expect(sent).NotTo(HaveLastSentMessage("E|S|test1"))
+			})
+		})
+
+		Describe("BeMessage", func() {
+			It("Should match the messages exactly", func() {
+				Expect(raw("E", "S", "test1")).To(BeMessage("E|S|test1"))
+				Expect(sent).NotTo(BeMessage("E|S|test1"))
+			})
+		})
+
+		Describe("HaveSentMessages", func() {
+			It("Should count the messages", func() {
+				Expect(sent).To(HaveSentMessages(5))
+				Expect([]string{}).To(HaveSentMessages(0))
+			})
+		})
+
+		Describe("HaveTopicAndAction", func() {
+			It("Should match the topic and action of a message", func() {
+				Expect(sent).To(HaveTopicAndAction(interfaces.TopicEvent, interfaces.ActionSubscribe))
+				Expect(sent).NotTo(HaveTopicAndAction(interfaces.TopicEvent, interfaces.ActionUnsubscribe))
+				Expect(sent).NotTo(HaveTopicAndAction(interfaces.TopicConnection, "CH"))
+			})
+		})
+
+		Describe("HaveRecordData", func() {
+			It("Should match the data of updates", func() {
+				Expect(sent).To(HaveRecordData("name", "John"))
+				Expect(sent).To(HaveRecordData("pets[0].name", "Rex"))
+				Expect(sent).To(HaveRecordData("", map[string]interface{}{
+					"name": "John",
+					"pets": []interface{}{map[string]interface{}{"name": "Rex"}},
+				}))
+				Expect(sent).NotTo(HaveRecordData("name", "Bob"))
+			})
+
+			It("Should match the data of patches", func() {
+				Expect(sent).To(HaveRecordData("age", 3))
+				Expect(sent).NotTo(HaveRecordData("ag", 3))
+			})
+
+			It("Should match the data of a record", func() {
+				record := fakeRecord{"name": "John", "age": 3}
+				Expect(record).To(HaveRecordData("age", 3.0))
+				Expect(record).NotTo(HaveRecordData("name", "Bob"))
+			})
+		})
+
+		Describe("JSONEqual", func() {
+			It("Should compare JSON documents by value", func() {
+				Expect(JSONEqual(`{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1.0}`)).To(BeTrue())
+				Expect(JSONEqual(`{"a":1}`, `{"a":2}`)).To(BeFalse())
+				Expect(JSONEqual("Sabc", "Sabc")).To(BeTrue())
+			})
+		})
+
+		Describe("MockProtocol", func() {
+			It("Should match the sent actions", func() {
+				protocol := dstesting.NewMockProtocol()
+				Expect(protocol.SendAction(message.NewChallengeResponseAction("localhost:6020"))).To(Succeed())
+
+				Expect(protocol).To(HaveSentMessage("C|CHR|localhost:6020"))
+				Expect(protocol).To(HaveSentMessages(1))
+			})
+		})
+	})
+
+	Describe("[Integration]", func() {
+		Describe("FakeServer", func() {
+			It("Should match the received messages", func() {
+				server := dstesting.NewFakeServer()
+				defer server.Close()
+
+				ws, _, err := websocket.DefaultDialer.Dial(server.URL, nil)
+				Expect(err).NotTo(HaveOccurred())
+				defer ws.Close()
+				Expect(ws.WriteMessage(websocket.TextMessage, []byte(raw("E", "S", "test1")+interfaces.MessageSeparator))).To(Succeed())
+
+				Eventually(server, time.Second).Should(HaveSentMessage("E|S|test1"))
+				Expect(server).To(HaveTopicAndAction(interfaces.TopicEvent, interfaces.ActionSubscribe))
+			})
+		})
+	})
+})
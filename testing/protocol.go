@@ -7,15 +7,63 @@
 
 package testing
 
-import "github.com/ga-con/deepstream.io-client-go/interfaces"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
-//MockProtocol should be used for unit tests
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+)
+
+const (
+	//MethodConnect names Connect for FailOn
+	MethodConnect = "Connect"
+	//MethodClose names Close for FailOn
+	MethodClose = "Close"
+	//MethodSendAction names SendAction for FailOn
+	MethodSendAction = "SendAction"
+	//MethodRecvActions names RecvActions for FailOn
+	MethodRecvActions = "RecvActions"
+)
+
+//ErrMockClosed is returned by RecvActions once the protocol is closed
+var ErrMockClosed = fmt.Errorf("mock protocol is closed")
+
+//SentAction is an action sent through a MockProtocol
+type SentAction struct {
+	Action interfaces.Action
+	// Raw is the encoded action without the trailing message separator, like
+	// the messages of FakeServer
+	Raw string
+}
+
+//Readable returns the raw action in readable notation without the trailing +,
+//e.g. E|S|test1
+func (s SentAction) Readable() string {
+	return message.ToReadable(s.Raw)
+}
+
+//MockProtocol should be used for unit tests. It records the sent actions, delivers
+//the queued inbound ones and fails the calls scripted with FailOn. RecvActions
+//blocks until an action is queued or the protocol is closed, like a connection.
 type MockProtocol struct {
+	// Error is returned by every call while set
 	Error           error
 	IsClosed        bool
 	HasConnected    bool
 	IsAuthenticated bool
 	AuthParams      map[string]interface{}
+
+	mu       sync.Mutex
+	sent     []SentAction
+	inbound  [][]interfaces.Action
+	calls    map[string]int
+	failures map[string]map[int]error
+	closed   bool
+	changed  chan struct{}
 }
 
 //NewMockProtocol returns a new MockProtocol
@@ -25,26 +73,137 @@ func NewMockProtocol(errOrNil ...error) *MockProtocol {
 		err = errOrNil[0]
 	}
 	return &MockProtocol{
-		Error: err,
+		Error:    err,
+		calls:    map[string]int{},
+		failures: map[string]map[int]error{},
+		changed:  make(chan struct{}),
+	}
+}
+
+// call counts a call to method and returns the error it must fail with, m.mu must be held
+func (m *MockProtocol) call(method string) error {
+	m.calls[method]++
+	if err, ok := m.failures[method][m.calls[method]]; ok {
+		return err
+	}
+	return m.Error
+}
+
+// notify wakes up the goroutines waiting for a change, m.mu must be held
+func (m *MockProtocol) notify() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+//FailOn scripts the nth call, starting at 1, of method to fail with err
+func (m *MockProtocol) FailOn(method string, n int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failures[method] == nil {
+		m.failures[method] = map[int]error{}
 	}
+	m.failures[method][n] = err
+}
+
+//Calls returns how many times method was called
+func (m *MockProtocol) Calls(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.calls[method]
 }
 
-//Connect mocks connection
+//Connect mocks connection, reopening the protocol if it was closed
 func (m *MockProtocol) Connect() error {
-	if m.Error != nil {
-		return m.Error
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.call(MethodConnect); err != nil {
+		return err
 	}
 
 	m.HasConnected = true
+	m.IsClosed = false
+	m.closed = false
+	m.notify()
 	return nil
 }
 
+//SendAction records action
 func (m *MockProtocol) SendAction(action interfaces.Action) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.call(MethodSendAction); err != nil {
+		return err
+	}
+
+	raw := strings.TrimSuffix(action.ToAction(), interfaces.MessageSeparator)
+	m.sent = append(m.sent, SentAction{Action: action, Raw: raw})
+	m.notify()
 	return nil
 }
 
+//RecvActions returns the next queued batch of actions, blocking until one is
+//queued or the protocol is closed
 func (m *MockProtocol) RecvActions() ([]interfaces.Action, error) {
-	return nil, nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.call(MethodRecvActions); err != nil {
+		return nil, err
+	}
+	for len(m.inbound) == 0 {
+		if m.closed {
+			return nil, ErrMockClosed
+		}
+		changed := m.changed
+		m.mu.Unlock()
+		<-changed
+		m.mu.Lock()
+	}
+
+	actions := m.inbound[0]
+	m.inbound = m.inbound[1:]
+	return actions, nil
+}
+
+//Deliver queues actions to be returned together by RecvActions
+func (m *MockProtocol) Deliver(actions ...interfaces.Action) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inbound = append(m.inbound, actions)
+	m.notify()
+}
+
+//DeliverRaw queues raw messages, without the trailing message separator, to be
+//returned together by RecvActions, e.g. "E" + MessagePartSeparator + "A"
+func (m *MockProtocol) DeliverRaw(msgs ...string) error {
+	actions, err := parseActions(strings.Join(msgs, interfaces.MessageSeparator))
+	if err != nil {
+		return err
+	}
+	m.Deliver(actions...)
+	return nil
+}
+
+// parseActions parses raw messages in the wire format like the client does
+func parseActions(raw string) ([]interfaces.Action, error) {
+	parsed, err := message.ParseMessages(raw)
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]interfaces.Action, 0, len(parsed))
+	for _, msg := range parsed {
+		action, err := message.CathegorizeAction(msg)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
 }
 
 //Authenticate mock protocol
@@ -60,12 +219,83 @@ func (m *MockProtocol) Authenticate(authParams map[string]interface{}) error {
 	return nil
 }
 
-//Close mock connection
+//Close mock connection, pending and future RecvActions fail with ErrMockClosed
+//until Connect is called again
 func (m *MockProtocol) Close() error {
-	if m.Error != nil {
-		return m.Error
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.call(MethodClose); err != nil {
+		return err
 	}
 
 	m.IsClosed = true
+	m.closed = true
+	m.notify()
 	return nil
 }
+
+//Sent returns the actions sent so far
+func (m *MockProtocol) Sent() []SentAction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SentAction{}, m.sent...)
+}
+
+//SentMessages returns the raw actions sent so far
+func (m *MockProtocol) SentMessages() []string {
+	sent := m.Sent()
+	msgs := make([]string, len(sent))
+	for i, action := range sent {
+		msgs[i] = action.Raw
+	}
+	return msgs
+}
+
+//LastSent returns the last raw action sent, or an empty string
+func (m *MockProtocol) LastSent() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.sent) == 0 {
+		return ""
+	}
+	return m.sent[len(m.sent)-1].Raw
+}
+
+//ResetSent forgets the actions sent so far
+func (m *MockProtocol) ResetSent() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = nil
+}
+
+//WaitForSent waits until an action whose readable form, see SentAction.Readable,
+//matches the pattern regexp is sent, e.g. `^E\|S\|test1$`, failing after timeout
+func (m *MockProtocol) WaitForSent(pattern string, timeout time.Duration) (SentAction, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return SentAction{}, err
+	}
+
+	deadline := time.After(timeout)
+	for {
+		m.mu.Lock()
+		for _, action := range m.sent {
+			if re.MatchString(action.Readable()) {
+				m.mu.Unlock()
+				return action, nil
+			}
+		}
+		changed := m.changed
+		m.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return SentAction{}, fmt.Errorf("no action matching %q was sent after %s, got %q", pattern, timeout, m.SentMessages())
+		}
+	}
+}
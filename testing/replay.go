@@ -0,0 +1,305 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+	"github.com/gorilla/websocket"
+)
+
+//ErrReplayClosed is returned by a ReplayProtocol once closed, either by the
+//client or because the recorded connection was closed
+var ErrReplayClosed = fmt.Errorf("replayed connection is closed")
+
+//LoadFrames reads the frames recorded to path, see client.WithRecorder
+func LoadFrames(path string) ([]message.Frame, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return message.ReadFrames(file)
+}
+
+//SequentialUIDs returns a generator of ids counting from 1 for client.WithUIDs.
+//A session recorded with it is replayed with the same RPC correlation ids when
+//the replaying client uses it too.
+func SequentialUIDs() func() string {
+	var mu sync.Mutex
+	last := 0
+	return func() string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		last++
+		return strconv.Itoa(last)
+	}
+}
+
+//ReplayProtocol plays a recorded session back to a client, e.g. with
+//client.New(url, NewReplayProtocol(frames)). The server frames are delivered in
+//order, each one once the client sent the frames recorded before it, and the
+//recorded disconnections are replayed. The frames sent by the client that don't
+//match the recording are reported by Mismatches, the redacted logins match any
+//credentials.
+type ReplayProtocol struct {
+	frames []message.Frame
+
+	mu         sync.Mutex
+	pos        int
+	closed     bool
+	mismatches []string
+	changed    chan struct{}
+}
+
+//NewReplayProtocol returns a protocol replaying frames
+func NewReplayProtocol(frames []message.Frame) *ReplayProtocol {
+	return &ReplayProtocol{
+		frames:  frames,
+		closed:  true,
+		changed: make(chan struct{}),
+	}
+}
+
+// notify wakes up the goroutines waiting for a change, p.mu must be held
+func (p *ReplayProtocol) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// next returns the frame to replay, or false at the end of the recording, p.mu must be held
+func (p *ReplayProtocol) next() (message.Frame, bool) {
+	if p.pos >= len(p.frames) {
+		return message.Frame{}, false
+	}
+	return p.frames[p.pos], true
+}
+
+//Connect opens the replayed connection
+func (p *ReplayProtocol) Connect() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if frame, ok := p.next(); ok && frame.Direction == message.FrameConnect {
+		p.pos++
+	}
+	p.closed = false
+	p.notify()
+	return nil
+}
+
+//Close closes the replayed connection
+func (p *ReplayProtocol) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if frame, ok := p.next(); ok && frame.Direction == message.FrameClose {
+		p.pos++
+	}
+	p.closed = true
+	p.notify()
+	return nil
+}
+
+//SendAction checks action against the next frame recorded as sent by the client
+func (p *ReplayProtocol) SendAction(action interfaces.Action) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrReplayClosed
+	}
+	sent := action.ToAction()
+	frame, ok := p.next()
+	if !ok || frame.Direction != message.FrameOutbound {
+		p.mismatches = append(p.mismatches, fmt.Sprintf(
+			"frame %d: unexpected %s sent", p.pos, message.ToReadable(sent),
+		))
+		return nil
+	}
+	if frame.Data != message.RedactFrame(sent) && frame.Data != sent {
+		p.mismatches = append(p.mismatches, fmt.Sprintf(
+			"frame %d: expected %s to be sent, got %s", p.pos, frame.Readable(), message.ToReadable(sent),
+		))
+	}
+	p.pos++
+	p.notify()
+	return nil
+}
+
+//RecvActions blocks until the next server frame is due, returning
+//ErrReplayClosed when the recorded connection was lost. Once the recording is
+//over, or when the client closed the recorded connection, it blocks until closed.
+func (p *ReplayProtocol) RecvActions() ([]interfaces.Action, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrReplayClosed
+		}
+		frame, ok := p.next()
+		if ok && frame.Direction == message.FrameClose && frame.Data != message.FrameClosedByClient {
+			p.pos++
+			p.closed = true
+			p.notify()
+			p.mu.Unlock()
+			return nil, ErrReplayClosed
+		}
+		if ok && frame.Direction == message.FrameInbound {
+			p.pos++
+			p.notify()
+			p.mu.Unlock()
+			return parseActions(frame.Data)
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		<-changed
+	}
+}
+
+//Mismatches describes the frames sent by the client that differ from the recording
+func (p *ReplayProtocol) Mismatches() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string{}, p.mismatches...)
+}
+
+//Remaining returns how many recorded frames are left to replay
+func (p *ReplayProtocol) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.frames) - p.pos
+}
+
+//WaitForEnd waits until every recorded frame was replayed, failing after timeout
+func (p *ReplayProtocol) WaitForEnd(timeout time.Duration) error {
+	deadline := time.After(timeout)
+	for {
+		p.mu.Lock()
+		if p.pos >= len(p.frames) {
+			p.mu.Unlock()
+			return nil
+		}
+		pos := p.pos
+		frame := p.frames[pos]
+		changed := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return fmt.Errorf("replay stuck on frame %d %s %s after %s",
+				pos, frame.Direction, frame.Readable(), timeout)
+		}
+	}
+}
+
+//ReplayToServer dials url like the recorded client and sends it the frames the
+//client sent, e.g. to a FakeServer. Before each frame it waits for as many
+//messages from the server as were recorded, up to timeout, and recorded
+//disconnections are replayed by dialing again. The redacted logins are sent as
+//recorded, so the server must accept them. It returns the frames received from
+//the server.
+func ReplayToServer(url string, frames []message.Frame, timeout time.Duration) ([]message.Frame, error) {
+	received := []message.Frame{}
+	var ws *websocket.Conn
+	defer func() {
+		if ws != nil {
+			ws.Close()
+		}
+	}()
+
+	// expected counts the server messages recorded since the last client frame,
+	// servers are free to batch them in frames differently
+	expected := 0
+	flush := func() error {
+		for expected > 0 {
+			ws.SetReadDeadline(time.Now().Add(timeout))
+			_, body, err := ws.ReadMessage()
+			if err != nil {
+				return fmt.Errorf("expected %d more messages from the server: %s", expected, err)
+			}
+			received = append(received, message.Frame{
+				Time:      time.Now(),
+				Direction: message.FrameInbound,
+				Data:      string(body),
+			})
+			expected -= countMessages(string(body))
+		}
+		expected = 0
+		return nil
+	}
+
+	// hangUp waits for the server messages recorded before the connection was closed
+	hangUp := func() error {
+		if ws == nil {
+			expected = 0
+			return nil
+		}
+		err := flush()
+		ws.Close()
+		ws = nil
+		return err
+	}
+	dial := func() error {
+		if err := hangUp(); err != nil {
+			return err
+		}
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		ws = conn
+		return err
+	}
+
+	for i, frame := range frames {
+		switch frame.Direction {
+		case message.FrameConnect:
+			if err := dial(); err != nil {
+				return received, fmt.Errorf("frame %d: %s", i, err)
+			}
+		case message.FrameClose:
+			if err := hangUp(); err != nil {
+				return received, fmt.Errorf("frame %d: %s", i, err)
+			}
+		case message.FrameInbound:
+			expected += countMessages(frame.Data)
+		case message.FrameOutbound:
+			if ws == nil {
+				if err := dial(); err != nil {
+					return received, fmt.Errorf("frame %d: %s", i, err)
+				}
+			}
+			if err := flush(); err != nil {
+				return received, fmt.Errorf("frame %d: %s", i, err)
+			}
+			if err := ws.WriteMessage(websocket.TextMessage, []byte(frame.Data)); err != nil {
+				return received, fmt.Errorf("frame %d: %s", i, err)
+			}
+		}
+	}
+	if err := hangUp(); err != nil {
+		return received, err
+	}
+	return received, nil
+}
+
+// countMessages counts the messages of a raw frame
+func countMessages(raw string) int {
+	return len(strings.Split(strings.TrimSuffix(raw, interfaces.MessageSeparator), interfaces.MessageSeparator))
+}
@@ -0,0 +1,241 @@
+// deepstream.io-client-go
+// https://github.com/ga-con/deepstream.io-client-go
+//
+// Licensed under the MIT license:
+// http://www.opensource.org/licenses/mit-license
+// Copyright © 2017 Bernardo Heynemann <heynemann@gmail.com>
+
+package testing_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ga-con/deepstream.io-client-go/client"
+	"github.com/ga-con/deepstream.io-client-go/interfaces"
+	"github.com/ga-con/deepstream.io-client-go/message"
+	dstesting "github.com/ga-con/deepstream.io-client-go/testing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// frame builds a recorded frame from readable notation
+func frame(direction, readable string) message.Frame {
+	data := readable
+	if direction == message.FrameInbound || direction == message.FrameOutbound {
+		data = message.FromReadable(readable)
+	}
+	return message.Frame{Direction: direction, Data: data}
+}
+
+func replayOptions(opts *client.ClientOptions) error {
+	opts.ManualLogin = true
+	opts.RecIntvlMin = 10 * time.Millisecond
+	opts.RecIntvlMax = 50 * time.Millisecond
+	opts.Logger = client.NopLogger{}
+	return nil
+}
+
+var _ = Describe("Replay", func() {
+	handshake := []message.Frame{
+		frame(message.FrameConnect, "localhost:6020"),
+		frame(message.FrameInbound, "C|CH+"),
+		frame(message.FrameOutbound, "C|CHR|localhost:6020+"),
+		frame(message.FrameInbound, "C|A+"),
+	}
+
+	login := []message.Frame{
+		frame(message.FrameOutbound, `A|REQ|{"username":"userA"}+`),
+		frame(message.FrameInbound, "A|A+"),
+	}
+
+	// loggedIn waits for the handshake and logs in, the client only reads from
+	// the connection once logged in
+	loggedIn := func(cli *client.Client) {
+		Eventually(func() interfaces.ConnectionState {
+			return cli.State()
+		}).Should(Equal(interfaces.ConnectionStateAwaitingAuthentication))
+		Expect(cli.Login(map[string]interface{}{"username": "userA"})).To(Succeed())
+	}
+
+	Describe("[Unit]", func() {
+		It("Should replay a session to a client", func() {
+			replay := dstesting.NewReplayProtocol(append(append(append([]message.Frame{}, handshake...), login...),
+				frame(message.FrameOutbound, "E|S|test1+"),
+				frame(message.FrameInbound, "E|A|S|test1+E|EVT|test1|Sdata+"),
+			))
+
+			cli, err := client.New("localhost:6020", replay, replayOptions)
+			Expect(err).NotTo(HaveOccurred())
+			defer cli.Close()
+			loggedIn(cli)
+
+			received := make(chan interface{}, 1)
+			_, err = cli.SubscribeEvent("test1", func(data interface{}) {
+				received <- data
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(received).Should(Receive(Equal("data")))
+			Expect(replay.WaitForEnd(time.Second)).To(Succeed())
+			Expect(replay.Remaining()).To(BeZero())
+			Expect(replay.Mismatches()).To(BeEmpty())
+		})
+
+		It("Should replay RPCs made with the recorded ids", func() {
+			replay := dstesting.NewReplayProtocol(append(append(append([]message.Frame{}, handshake...), login...),
+				frame(message.FrameOutbound, "P|REQ|toUppercase|1|Sabc+"),
+				frame(message.FrameInbound, "P|A|REQ|toUppercase|1+P|RES|toUppercase|1|SABC+"),
+				frame(message.FrameOutbound, "P|REQ|toUppercase|2|Sdef+"),
+				frame(message.FrameInbound, "P|A|REQ|toUppercase|2+P|RES|toUppercase|2|SDEF+"),
+			))
+
+			cli, err := client.New("localhost:6020", replay, replayOptions, client.WithUIDs(dstesting.SequentialUIDs()))
+			Expect(err).NotTo(HaveOccurred())
+			defer cli.Close()
+			loggedIn(cli)
+
+			Expect(cli.Make("toUppercase", "abc")).To(Equal("ABC"))
+			Expect(cli.Make("toUppercase", "def")).To(Equal("DEF"))
+			Expect(replay.WaitForEnd(time.Second)).To(Succeed())
+			Expect(replay.Mismatches()).To(BeEmpty())
+		})
+
+		It("Should report the frames sent differently", func() {
+			replay := dstesting.NewReplayProtocol([]message.Frame{
+				frame(message.FrameConnect, "localhost:6020"),
+				frame(message.FrameInbound, "C|CH+"),
+				frame(message.FrameOutbound, "C|CHR|localhost:6021+"),
+			})
+
+			cli, err := client.New("localhost:6020", replay, replayOptions)
+			Expect(err).NotTo(HaveOccurred())
+			defer cli.Close()
+
+			Expect(replay.WaitForEnd(time.Second)).To(Succeed())
+			Expect(replay.Mismatches()).To(Equal([]string{
+				"frame 2: expected C|CHR|localhost:6021+ to be sent, got C|CHR|localhost:6020+",
+			}))
+		})
+
+		It("Should replay disconnections", func() {
+			frames := append(append([]message.Frame{}, handshake...), login...)
+			frames = append(frames, frame(message.FrameClose, ""))
+			replay := dstesting.NewReplayProtocol(append(frames, handshake...))
+
+			cli, err := client.New("localhost:6020", replay, replayOptions)
+			Expect(err).NotTo(HaveOccurred())
+			defer cli.Close()
+			loggedIn(cli)
+
+			Expect(replay.WaitForEnd(time.Second)).To(Succeed())
+			Eventually(func() interfaces.ConnectionState {
+				return cli.State()
+			}).Should(Equal(interfaces.ConnectionStateAwaitingAuthentication))
+			Expect(replay.Mismatches()).To(BeEmpty())
+		})
+
+		It("Should fail waiting when the client doesn't follow the recording", func() {
+			replay := dstesting.NewReplayProtocol(append(append([]message.Frame{}, handshake...),
+				frame(message.FrameOutbound, "E|S|test1+"),
+			))
+
+			cli, err := client.New("localhost:6020", replay, replayOptions)
+			Expect(err).NotTo(HaveOccurred())
+			defer cli.Close()
+
+			err = replay.WaitForEnd(50 * time.Millisecond)
+			Expect(err).To(MatchError("replay stuck on frame 4 out E|S|test1+ after 50ms"))
+		})
+	})
+
+	Describe("[Integration]", func() {
+		var server *dstesting.FakeServer
+
+		BeforeEach(func() {
+			server = dstesting.NewFakeServer()
+			server.OnConnect(msg("C", "CH"))
+			server.Reply(msg("C", "CHR", server.URL), msg("C", "A"))
+			server.Reply(msg("A", "REQ", `{"username":"userA"}`), msg("A", "A"))
+			// the recorded logins are redacted
+			server.Reply(msg("A", "REQ", message.FrameRedactedAuth), msg("A", "A"))
+			server.Reply(msg("E", "S", "test1"), msg("E", "A", "S", "test1"))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		record := func() []message.Frame {
+			dir, err := ioutil.TempDir("", "replay")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "session.jsonl")
+			file, err := os.Create(path)
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			cli, err := client.Dial(server.URL, replayOptions, client.WithRecorder(file), func(opts *client.ClientOptions) error {
+				opts.HandshakeTimeout = 0
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			loggedIn(cli)
+			_, err = cli.SubscribeEvent("test1", func(data interface{}) {})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() int {
+				frames, _ := dstesting.LoadFrames(path)
+				return len(frames)
+			}).Should(Equal(8))
+			Expect(cli.Close()).To(Succeed())
+
+			frames, err := dstesting.LoadFrames(path)
+			Expect(err).NotTo(HaveOccurred())
+			return frames
+		}
+
+		It("Should replay a recorded session to a client", func() {
+			frames := record()
+			Expect(frames).To(HaveLen(9))
+			Expect(frames[8].Direction).To(Equal(message.FrameClose))
+			Expect(frames[8].Data).To(Equal(message.FrameClosedByClient))
+			for _, frame := range frames {
+				Expect(frame.Data).NotTo(ContainSubstring("userA"))
+			}
+
+			replay := dstesting.NewReplayProtocol(frames)
+			cli, err := client.New(server.URL, replay, replayOptions)
+			Expect(err).NotTo(HaveOccurred())
+			loggedIn(cli)
+			_, err = cli.SubscribeEvent("test1", func(data interface{}) {})
+			Expect(err).NotTo(HaveOccurred())
+			// the client closed the connection once the subscription was acknowledged
+			Eventually(replay.Remaining).Should(Equal(1))
+			Expect(cli.Close()).To(Succeed())
+
+			Expect(replay.WaitForEnd(time.Second)).To(Succeed())
+			Expect(replay.Mismatches()).To(BeEmpty())
+		})
+
+		It("Should replay a recorded session to a server", func() {
+			frames := record()
+			server.ResetMessageCount()
+
+			received, err := dstesting.ReplayToServer(server.URL, frames, time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			readable := []string{}
+			for _, frame := range received {
+				readable = append(readable, frame.Readable())
+			}
+			Expect(readable).To(Equal([]string{"C|CH+", "C|A+", "A|A+", "E|A|S|test1+"}))
+			Expect(server.Received()).To(Equal([]string{
+				msg("C", "CHR", server.URL),
+				msg("A", "REQ", message.FrameRedactedAuth),
+				msg("E", "S", "test1"),
+			}))
+		})
+	})
+})